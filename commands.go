@@ -0,0 +1,48 @@
+package main
+
+import (
+	"ecommerce/internal/catalog"
+	"ecommerce/internal/phone"
+	"ecommerce/internal/visitor"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// runCommand runs a one-off maintenance command instead of the server, e.g.
+// `go run . normalize-phones`.
+func runCommand(db *gorm.DB, name string) {
+	switch name {
+	case "normalize-phones":
+		reports, err := phone.NormalizeExisting(db)
+		for _, r := range reports {
+			log.Printf("📱 %s: %d scanned, %d updated, %d invalid, %d conflicts", r.Column, r.Scanned, r.Updated, r.Invalid, r.Conflicts)
+		}
+		if err != nil {
+			log.Fatalf("Error normalizing phone numbers: %v", err)
+		}
+	case "rollup-visitors":
+		days, err := visitor.NewService(visitor.NewRepository(db)).RollUp()
+		log.Printf("📊 Rolled up visitor stats for %d days", days)
+		if err != nil {
+			log.Fatalf("Error rolling up visitor stats: %v", err)
+		}
+	case "visitor-retention":
+		result, err := visitor.NewService(visitor.NewRepository(db)).ApplyRetention(visitor.RetentionFromEnv())
+		if result != nil {
+			log.Printf("🗑️ Visitor retention: %d days rolled up, %d IPs anonymized, %d rows deleted",
+				result.RolledUpDays, result.Anonymized, result.Deleted)
+		}
+		if err != nil {
+			log.Fatalf("Error applying visitor retention: %v", err)
+		}
+	case "reindex-products":
+		count, err := catalog.ReindexSearch(db)
+		log.Printf("🔎 Reindexed %d products for search", count)
+		if err != nil {
+			log.Fatalf("Error reindexing products: %v", err)
+		}
+	default:
+		log.Fatalf("Unknown command %q (available: normalize-phones, rollup-visitors, visitor-retention, reindex-products)", name)
+	}
+}
@@ -1,13 +1,35 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
 	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/facebook"
 	"golang.org/x/oauth2/google"
-	"os"
 )
 
 var GoogleOAuthConfig *oauth2.Config
 
+// OAuthProvider is the configuration of one login provider. Every endpoint
+// can be overridden from the environment, which is how tests point the
+// providers at a local stub server.
+type OAuthProvider struct {
+	Name        string
+	OAuth2      *oauth2.Config
+	UserInfoURL string
+	PKCE        bool
+}
+
+// OAuthProviders holds the providers enabled by InitOAuthProviders, by name.
+var OAuthProviders = map[string]*OAuthProvider{}
+
 func InitGoogleAuth() {
 
 	GoogleOAuthConfig = &oauth2.Config{
@@ -18,6 +40,152 @@ func InitGoogleAuth() {
 			"https://www.googleapis.com/auth/userinfo.email",
 			"https://www.googleapis.com/auth/userinfo.profile",
 		},
-		Endpoint: google.Endpoint,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  envOr("GOOGLE_AUTH_URL", google.Endpoint.AuthURL),
+			TokenURL: envOr("GOOGLE_TOKEN_URL", google.Endpoint.TokenURL),
+		},
+	}
+}
+
+// InitOAuthProviders sets up Google plus any of Facebook and generic OIDC that
+// have a client ID configured.
+func InitOAuthProviders() {
+	InitGoogleAuth()
+	OAuthProviders["google"] = &OAuthProvider{
+		Name:        "google",
+		OAuth2:      GoogleOAuthConfig,
+		UserInfoURL: envOr("GOOGLE_USERINFO_URL", "https://www.googleapis.com/oauth2/v2/userinfo"),
+		PKCE:        true,
+	}
+
+	if clientID := os.Getenv("FACEBOOK_CLIENT_ID"); clientID != "" {
+		OAuthProviders["facebook"] = &OAuthProvider{
+			Name: "facebook",
+			OAuth2: &oauth2.Config{
+				ClientID:     clientID,
+				ClientSecret: os.Getenv("FACEBOOK_CLIENT_SECRET"),
+				RedirectURL:  os.Getenv("FACEBOOK_REDIRECT_URL"),
+				Scopes:       []string{"email", "public_profile"},
+				Endpoint: oauth2.Endpoint{
+					AuthURL:  envOr("FACEBOOK_AUTH_URL", facebook.Endpoint.AuthURL),
+					TokenURL: envOr("FACEBOOK_TOKEN_URL", facebook.Endpoint.TokenURL),
+				},
+			},
+			UserInfoURL: envOr("FACEBOOK_USERINFO_URL", "https://graph.facebook.com/me?fields=id,name,email"),
+		}
+	}
+
+	if clientID := os.Getenv("OIDC_CLIENT_ID"); clientID != "" {
+		provider, err := oidcProvider(clientID)
+		if err != nil {
+			log.Printf("OIDC provider disabled: %v", err)
+		} else {
+			OAuthProviders[provider.Name] = provider
+		}
+	}
+}
+
+// oidcProvider builds a generic OpenID Connect provider. Endpoints come from
+// OIDC_AUTH_URL, OIDC_TOKEN_URL and OIDC_USERINFO_URL, or are discovered from
+// OIDC_ISSUER when those are not set.
+func oidcProvider(clientID string) (*OAuthProvider, error) {
+	authURL := os.Getenv("OIDC_AUTH_URL")
+	tokenURL := os.Getenv("OIDC_TOKEN_URL")
+	userInfoURL := os.Getenv("OIDC_USERINFO_URL")
+
+	if issuer := os.Getenv("OIDC_ISSUER"); issuer != "" && (authURL == "" || tokenURL == "" || userInfoURL == "") {
+		discovered, err := discoverOIDC(issuer)
+		if err != nil {
+			return nil, err
+		}
+		authURL = firstNonEmpty(authURL, discovered.AuthorizationEndpoint)
+		tokenURL = firstNonEmpty(tokenURL, discovered.TokenEndpoint)
+		userInfoURL = firstNonEmpty(userInfoURL, discovered.UserInfoEndpoint)
+	}
+	if authURL == "" || tokenURL == "" || userInfoURL == "" {
+		return nil, fmt.Errorf("OIDC_ISSUER or OIDC_AUTH_URL, OIDC_TOKEN_URL and OIDC_USERINFO_URL are required")
+	}
+
+	scopes := strings.Fields(envOr("OIDC_SCOPES", "openid email profile"))
+	return &OAuthProvider{
+		Name: envOr("OIDC_PROVIDER_NAME", "oidc"),
+		OAuth2: &oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+			Scopes:       scopes,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  authURL,
+				TokenURL: tokenURL,
+			},
+		},
+		UserInfoURL: userInfoURL,
+		PKCE:        true,
+	}, nil
+}
+
+type oidcDiscovery struct {
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+func discoverOIDC(issuer string) (*oidcDiscovery, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(strings.TrimRight(issuer, "/") + "/.well-known/openid-configuration")
+	if err != nil {
+		return nil, fmt.Errorf("OIDC discovery failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OIDC discovery failed: status %d", resp.StatusCode)
+	}
+
+	var doc oidcDiscovery
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("OIDC discovery failed: %v", err)
+	}
+	return &doc, nil
+}
+
+// ConfigureTrustedProxies sets which proxies the router takes the client IP
+// from, for audit events, visitor tracking and rate limits. TRUSTED_PROXIES
+// lists proxy IPs or CIDRs; without it X-Forwarded-For is ignored, so
+// clients can't forge their IP. TRUSTED_PLATFORM instead trusts a platform's
+// client IP header: cloudflare, google or a header name.
+func ConfigureTrustedProxies(router *gin.Engine) error {
+	switch platform := os.Getenv("TRUSTED_PLATFORM"); strings.ToLower(platform) {
+	case "":
+	case "cloudflare":
+		router.TrustedPlatform = gin.PlatformCloudflare
+	case "google":
+		router.TrustedPlatform = gin.PlatformGoogleAppEngine
+	default:
+		router.TrustedPlatform = platform
+	}
+
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return router.SetTrustedProxies(proxies)
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
 	}
+	return ""
 }
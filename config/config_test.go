@@ -0,0 +1,54 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clientIP returns the client IP the configured router sees for a request
+// from httptest's default remote address, 192.0.2.1.
+func clientIP(t *testing.T, header http.Header) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	if err := ConfigureTrustedProxies(router); err != nil {
+		t.Fatal(err)
+	}
+	var ip string
+	router.GET("/", func(c *gin.Context) { ip = c.ClientIP() })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header = header
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	return ip
+}
+
+func TestConfigureTrustedProxies(t *testing.T) {
+	forwarded := http.Header{"X-Forwarded-For": {"203.0.113.7"}}
+
+	t.Setenv("TRUSTED_PROXIES", "")
+	t.Setenv("TRUSTED_PLATFORM", "")
+	if ip := clientIP(t, forwarded); ip != "192.0.2.1" {
+		t.Errorf("no trusted proxies: client IP %s, want the remote address", ip)
+	}
+
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.1, 192.0.2.0/24")
+	if ip := clientIP(t, forwarded); ip != "203.0.113.7" {
+		t.Errorf("trusted proxy: client IP %s, want the forwarded one", ip)
+	}
+
+	t.Setenv("TRUSTED_PROXIES", "")
+	t.Setenv("TRUSTED_PLATFORM", "cloudflare")
+	if ip := clientIP(t, http.Header{"Cf-Connecting-Ip": {"203.0.113.8"}}); ip != "203.0.113.8" {
+		t.Errorf("cloudflare: client IP %s, want the platform header", ip)
+	}
+
+	t.Setenv("TRUSTED_PLATFORM", "")
+	t.Setenv("TRUSTED_PROXIES", "not-an-ip")
+	if err := ConfigureTrustedProxies(gin.New()); err == nil {
+		t.Error("invalid proxy accepted")
+	}
+}
@@ -0,0 +1,81 @@
+package account
+
+import (
+	"bytes"
+	"ecommerce/internal/audit"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccountController struct {
+	accountService AccountService
+}
+
+func NewAccountController(accountService AccountService) *AccountController {
+	return &AccountController{accountService: accountService}
+}
+
+// ExportData downloads the user's data as JSON, or as a ZIP with
+// ?format=zip.
+func (c *AccountController) ExportData(ctx *gin.Context) {
+	userID := ctx.GetUint("userID")
+
+	export, err := c.accountService.Export(userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to export account data",
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("account-export-%d-%s", userID, time.Now().Format("20060102"))
+	switch ctx.DefaultQuery("format", "json") {
+	case "json":
+		ctx.Header("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+		ctx.IndentedJSON(http.StatusOK, export)
+	case "zip":
+		var buf bytes.Buffer
+		if err := c.accountService.WriteZip(&buf, export); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to export account data",
+			})
+			return
+		}
+		ctx.Header("Content-Disposition", `attachment; filename="`+filename+`.zip"`)
+		ctx.Data(http.StatusOK, "application/zip", buf.Bytes())
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "format must be 'json' or 'zip'",
+		})
+	}
+}
+
+func (c *AccountController) DeleteAccount(ctx *gin.Context) {
+	var req DeleteAccountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": `Send {"confirm": "DELETE"} to delete your account`,
+		})
+		return
+	}
+
+	err := c.accountService.DeleteAccount(audit.ActorFromContext(ctx), ctx.GetUint("userID"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrStaffAccount) {
+			status = http.StatusForbidden
+		}
+		ctx.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Your account has been deleted",
+	})
+}
@@ -0,0 +1,26 @@
+package account
+
+import (
+	"ecommerce/internal/auth"
+	"ecommerce/internal/order"
+	"time"
+)
+
+// Export is everything we hold about a customer, as returned by
+// GET /api/v1/profile/export. Payment proofs are nested in their orders.
+type Export struct {
+	ExportedAt time.Time      `json:"exported_at"`
+	Profile    *auth.User     `json:"profile"`
+	Addresses  []auth.Address `json:"addresses"`
+	Sessions   []auth.Session `json:"sessions"`
+	Orders     []order.Order  `json:"orders"`
+}
+
+// DeleteAccountRequest makes clients confirm deletion explicitly, since it
+// cannot be undone.
+type DeleteAccountRequest struct {
+	Confirm string `json:"confirm" binding:"required,eq=DELETE"`
+}
+
+// anonymizedName replaces the customer name on orders kept for accounting.
+const anonymizedName = "Deleted customer"
@@ -0,0 +1,120 @@
+package account
+
+import (
+	"ecommerce/internal/auth"
+	"ecommerce/internal/cart"
+	"ecommerce/internal/order"
+
+	"gorm.io/gorm"
+)
+
+type AccountRepository interface {
+	FindUser(userID uint) (*auth.User, error)
+	GetAddresses(userID uint) ([]auth.Address, error)
+	GetSessions(userID uint) ([]auth.Session, error)
+	GetOrders(userID uint) ([]order.Order, error)
+	Anonymize(user *auth.User) error
+}
+
+type accountRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountRepository(db *gorm.DB) AccountRepository {
+	return &accountRepository{db: db}
+}
+
+func (r *accountRepository) FindUser(userID uint) (*auth.User, error) {
+	var user auth.User
+	if err := r.db.Preload("Identities").First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *accountRepository) GetAddresses(userID uint) ([]auth.Address, error) {
+	var addresses []auth.Address
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&addresses).Error
+	return addresses, err
+}
+
+func (r *accountRepository) GetSessions(userID uint) ([]auth.Session, error) {
+	var sessions []auth.Session
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *accountRepository) GetOrders(userID uint) ([]order.Order, error) {
+	var orders []order.Order
+	err := r.db.Where("user_id = ?", userID).
+		Preload("Items").
+		Preload("PaymentProofs").
+		Order("created_at").
+		Find(&orders).Error
+	return orders, err
+}
+
+// Anonymize erases a user's personal data in one transaction. Orders and
+// payment proofs are kept, with totals and transaction IDs, for accounting;
+// everything else is deleted and the user row is scrubbed and soft-deleted.
+func (r *accountRepository) Anonymize(user *auth.User) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		userOrders := tx.Unscoped().Model(&order.Order{}).Select("id").Where("user_id = ?", user.ID)
+
+		if err := tx.Unscoped().Model(&order.PaymentProof{}).
+			Where("order_id IN (?)", userOrders).
+			Updates(map[string]interface{}{"sender_name": "", "sender_number": "", "screenshot": ""}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Model(&order.Order{}).
+			Where("user_id = ?", user.ID).
+			Updates(map[string]interface{}{
+				"customer_name":    anonymizedName,
+				"customer_phone":   "",
+				"customer_email":   "",
+				"shipping_address": "",
+				"notes":            "",
+				// Division, district and delivery zone are kept for regional
+				// sales figures; anything finer points at the customer
+				"shipping_address_id": nil,
+				"shipping_upazila":    "",
+				"shipping_area":       "",
+				"shipping_postcode":   "",
+			}).Error; err != nil {
+			return err
+		}
+
+		// Cart items go with the cart (ON DELETE CASCADE). Revoked access
+		// tokens are kept so they stay revoked until they expire.
+		for _, model := range []interface{}{
+			&auth.Address{}, &auth.UserIdentity{}, &auth.MFARecoveryCode{},
+			&auth.Session{}, &auth.RefreshToken{}, &auth.LoginCode{}, &cart.Cart{},
+		} {
+			if err := tx.Where("user_id = ?", user.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if user.Phone != "" {
+			if err := tx.Where("phone = ?", user.Phone).Delete(&auth.PhoneOTP{}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&auth.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"name":           anonymizedName,
+			"email":          "",
+			"email_verified": false,
+			"phone":          "",
+			"phone_verified": false,
+			"google_id":      "",
+			"birthday":       "",
+			"gender":         "",
+			"totp_secret":    "",
+			"totp_enabled":   false,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&auth.User{}, user.ID).Error
+	})
+}
@@ -0,0 +1,16 @@
+package account
+
+import (
+	"ecommerce/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupAccountRoutes(router *gin.Engine, accountController *AccountController) {
+	profile := router.Group("/api/v1/profile")
+	profile.Use(auth.JWTAuthMiddleware(), auth.BlockImpersonation())
+	{
+		profile.GET("/export", accountController.ExportData)
+		profile.DELETE("", accountController.DeleteAccount)
+	}
+}
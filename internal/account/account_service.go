@@ -0,0 +1,118 @@
+package account
+
+import (
+	"archive/zip"
+	"ecommerce/internal/audit"
+	"ecommerce/internal/auth"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"time"
+)
+
+var ErrStaffAccount = errors.New("staff and admin accounts must have their role revoked before deletion")
+
+type AccountService interface {
+	Export(userID uint) (*Export, error)
+	WriteZip(w io.Writer, export *Export) error
+	DeleteAccount(actor audit.Actor, userID uint) error
+}
+
+type accountService struct {
+	repo        AccountRepository
+	userService auth.UserService
+	audit       audit.Service
+}
+
+func NewAccountService(repo AccountRepository, userService auth.UserService, auditService audit.Service) AccountService {
+	return &accountService{
+		repo:        repo,
+		userService: userService,
+		audit:       auditService,
+	}
+}
+
+func (s *accountService) Export(userID uint) (*Export, error) {
+	user, err := s.repo.FindUser(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	addresses, err := s.repo.GetAddresses(userID)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := s.repo.GetSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := s.repo.GetOrders(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("📦 Account data exported: UserID=%d", userID)
+	return &Export{
+		ExportedAt: time.Now(),
+		Profile:    user,
+		Addresses:  addresses,
+		Sessions:   sessions,
+		Orders:     orders,
+	}, nil
+}
+
+// WriteZip writes the export as a ZIP with one JSON file per section.
+func (s *accountService) WriteZip(w io.Writer, export *Export) error {
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"addresses.json", export.Addresses},
+		{"sessions.json", export.Sessions},
+		{"orders.json", export.Orders},
+	}
+
+	archive := zip.NewWriter(w)
+	for _, file := range files {
+		entry, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(entry)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// DeleteAccount signs the user out everywhere, then erases their personal
+// data and soft-deletes the account. Order totals are kept for accounting.
+func (s *accountService) DeleteAccount(actor audit.Actor, userID uint) error {
+	user, err := s.repo.FindUser(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if user.Role != auth.RoleCustomer {
+		return ErrStaffAccount
+	}
+
+	// ✅ Revoke first, so a failure here leaves the account intact
+	if err := s.userService.RevokeAllSessions(userID); err != nil {
+		return err
+	}
+	if err := s.repo.Anonymize(user); err != nil {
+		return err
+	}
+
+	s.audit.Record(actor, audit.ActionAccountDelete, "user", userID, nil, nil)
+	log.Printf("🗑️ Account deleted and anonymized: UserID=%d", userID)
+	return nil
+}
@@ -0,0 +1,158 @@
+package account
+
+import (
+	"archive/zip"
+	"bytes"
+	"ecommerce/internal/audit"
+	"ecommerce/internal/auth"
+	"ecommerce/internal/order"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type fakeAccountRepo struct {
+	AccountRepository
+	users map[uint]*auth.User
+
+	// calls records the order of side effects across the fakes
+	calls *[]string
+}
+
+func (r *fakeAccountRepo) FindUser(userID uint) (*auth.User, error) {
+	user, ok := r.users[userID]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return user, nil
+}
+
+func (r *fakeAccountRepo) GetAddresses(userID uint) ([]auth.Address, error) {
+	return []auth.Address{{ID: 1, UserID: userID, Address: "House 1"}}, nil
+}
+
+func (r *fakeAccountRepo) GetSessions(userID uint) ([]auth.Session, error) {
+	return []auth.Session{{ID: "s1", UserID: userID}}, nil
+}
+
+func (r *fakeAccountRepo) GetOrders(userID uint) ([]order.Order, error) {
+	return []order.Order{{ID: 1, UserID: &userID, OrderNumber: "ORDABC"}}, nil
+}
+
+func (r *fakeAccountRepo) Anonymize(user *auth.User) error {
+	*r.calls = append(*r.calls, "anonymize")
+	return nil
+}
+
+type fakeUserService struct {
+	auth.UserService
+	err   error
+	calls *[]string
+}
+
+func (s *fakeUserService) RevokeAllSessions(userID uint) error {
+	*s.calls = append(*s.calls, "revoke")
+	return s.err
+}
+
+type recordingAudit struct {
+	audit.Service
+	actions []string
+}
+
+func (a *recordingAudit) Record(actor audit.Actor, action, targetType string, targetID interface{}, before, after interface{}) {
+	a.actions = append(a.actions, action)
+}
+
+func newAccountTestService() (*accountService, *fakeUserService, *recordingAudit, *[]string) {
+	calls := &[]string{}
+	repo := &fakeAccountRepo{
+		users: map[uint]*auth.User{
+			1: {ID: 1, Email: "jane@example.com", Role: auth.RoleCustomer},
+			2: {ID: 2, Email: "staff@example.com", Role: auth.RoleStaff},
+		},
+		calls: calls,
+	}
+	users := &fakeUserService{calls: calls}
+	log := &recordingAudit{}
+	return &accountService{repo: repo, userService: users, audit: log}, users, log, calls
+}
+
+func TestExportWritesEverySection(t *testing.T) {
+	s, _, _, _ := newAccountTestService()
+	export, err := s.Export(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if export.Profile.Email != "jane@example.com" || len(export.Addresses) != 1 || len(export.Sessions) != 1 || len(export.Orders) != 1 {
+		t.Fatalf("export = %+v", export)
+	}
+
+	var buf bytes.Buffer
+	if err := s.WriteZip(&buf, export); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"profile.json", "addresses.json", "sessions.json", "orders.json"}
+	if len(archive.File) != len(want) {
+		t.Fatalf("%d files in the archive, want %d", len(archive.File), len(want))
+	}
+	for i, file := range archive.File {
+		if file.Name != want[i] {
+			t.Errorf("file %d = %s, want %s", i, file.Name, want[i])
+		}
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var data interface{}
+		if err := json.NewDecoder(rc).Decode(&data); err != nil {
+			t.Errorf("%s: %v", file.Name, err)
+		}
+		rc.Close()
+	}
+
+	if _, err := s.Export(99); err == nil {
+		t.Error("exported a missing user")
+	}
+}
+
+func TestDeleteAccount(t *testing.T) {
+	s, _, log, calls := newAccountTestService()
+
+	if err := s.DeleteAccount(audit.Actor{UserID: 1}, 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(*calls) != 2 || (*calls)[0] != "revoke" || (*calls)[1] != "anonymize" {
+		t.Errorf("calls = %v, want sessions revoked before anonymizing", *calls)
+	}
+	if len(log.actions) != 1 || log.actions[0] != audit.ActionAccountDelete {
+		t.Errorf("audit actions = %v", log.actions)
+	}
+}
+
+func TestDeleteAccountRefusesStaff(t *testing.T) {
+	s, _, _, calls := newAccountTestService()
+
+	if err := s.DeleteAccount(audit.Actor{UserID: 2}, 2); !errors.Is(err, ErrStaffAccount) {
+		t.Errorf("got %v, want ErrStaffAccount", err)
+	}
+	if len(*calls) != 0 {
+		t.Errorf("calls = %v, want none", *calls)
+	}
+}
+
+func TestDeleteAccountKeepsDataWhenSignOutFails(t *testing.T) {
+	s, users, log, calls := newAccountTestService()
+	users.err = errors.New("token store down")
+
+	if err := s.DeleteAccount(audit.Actor{UserID: 1}, 1); err == nil {
+		t.Fatal("deletion succeeded without signing the user out")
+	}
+	if len(*calls) != 1 || len(log.actions) != 0 {
+		t.Errorf("calls = %v, audit = %v; want nothing after the failed sign-out", *calls, log.actions)
+	}
+}
@@ -0,0 +1,41 @@
+package audit
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Controller struct {
+	service Service
+}
+
+func NewController(service Service) *Controller {
+	return &Controller{service: service}
+}
+
+func (c *Controller) ListEvents(ctx *gin.Context) {
+	var filter EventFilter
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	filter.normalize()
+	events, total, err := c.service.List(filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve audit events",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"events":    events,
+		"total":     total,
+		"page":      filter.Page,
+		"page_size": filter.PageSize,
+	})
+}
@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the request ID in both directions.
+const RequestIDHeader = "X-Request-ID"
+
+// Only short, plain IDs from clients or proxies are trusted as-is
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{8,64}$`)
+
+// RequestIDMiddleware gives every request an ID, reusing the caller's
+// X-Request-ID when it looks sane, and echoes it in the response so support
+// can match a complaint to its audit events and logs.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+
+		c.Set("requestID", id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// ActorFromContext describes the authenticated caller of a request, as set
+// by the auth middleware. For an impersonation token the caller is the admin
+// behind it, not the customer whose ID the handlers see.
+func ActorFromContext(c *gin.Context) Actor {
+	actor := Actor{
+		UserID:    c.GetUint("userID"),
+		Role:      c.GetString("userRole"),
+		IP:        c.ClientIP(),
+		RequestID: c.GetString("requestID"),
+	}
+	if keyID, ok := c.Get("apiKeyID"); ok {
+		id := keyID.(uint)
+		actor.APIKeyID = &id
+	}
+	if impersonatorID := c.GetUint("impersonatorID"); impersonatorID != 0 {
+		customerID := actor.UserID
+		actor.UserID = impersonatorID
+		actor.Role = c.GetString("impersonatorRole")
+		actor.OnBehalfOf = &customerID
+	}
+	return actor
+}
@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestIDMiddleware())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("requestID"))
+	})
+
+	tests := map[string]bool{
+		"abc-123_DEF.456":           true,
+		"short":                     false,
+		"has spaces in it":          false,
+		"<script>alert(1)</script>": false,
+		"":                          false,
+	}
+	for id, kept := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(RequestIDHeader, id)
+		router.ServeHTTP(w, req)
+
+		got := w.Header().Get(RequestIDHeader)
+		if got != w.Body.String() || got == "" {
+			t.Errorf("%q: header %q, context %q", id, got, w.Body.String())
+		}
+		if (got == id) != kept {
+			t.Errorf("%q: request ID became %q, kept = %v", id, got, kept)
+		}
+	}
+}
+
+func TestActorFromContext(t *testing.T) {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx.Set("userID", uint(3))
+	ctx.Set("userRole", "admin")
+	ctx.Set("requestID", "req-12345")
+
+	actor := ActorFromContext(ctx)
+	if actor.UserID != 3 || actor.Role != "admin" || actor.RequestID != "req-12345" || actor.APIKeyID != nil {
+		t.Errorf("actor = %+v", actor)
+	}
+
+	ctx.Set("apiKeyID", uint(9))
+	if actor := ActorFromContext(ctx); actor.APIKeyID == nil || *actor.APIKeyID != 9 {
+		t.Errorf("API key actor = %+v", actor)
+	}
+}
+
+func TestActorFromContextWhileImpersonating(t *testing.T) {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx.Set("userID", uint(2))
+	ctx.Set("userRole", "customer")
+	ctx.Set("impersonatorID", uint(1))
+	ctx.Set("impersonatorRole", "admin")
+
+	actor := ActorFromContext(ctx)
+	if actor.UserID != 1 || actor.Role != "admin" || actor.OnBehalfOf == nil || *actor.OnBehalfOf != 2 {
+		t.Errorf("actor = %+v, want the admin acting for customer 2", actor)
+	}
+}
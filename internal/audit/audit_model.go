@@ -0,0 +1,114 @@
+package audit
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Actions recorded in the audit log.
+const (
+	ActionOrderStatusUpdate    = "order.status_update"
+	ActionPaymentProofReview   = "payment_proof.review"
+	ActionProductCreate        = "product.create"
+	ActionProductUpdate        = "product.update"
+	ActionProductDelete        = "product.delete"
+	ActionCategoryCreate       = "category.create"
+	ActionCategoryUpdate       = "category.update"
+	ActionCategoryDelete       = "category.delete"
+	ActionSubCategoryCreate    = "subcategory.create"
+	ActionSubCategoryDelete    = "subcategory.delete"
+	ActionSubSubCategoryCreate = "sub_subcategory.create"
+	ActionSubSubCategoryDelete = "sub_subcategory.delete"
+	ActionVariantCreate        = "variant.create"
+	ActionVariantUpdate        = "variant.update"
+	ActionVariantDelete        = "variant.delete"
+	ActionOptionTypeCreate     = "option_type.create"
+	ActionAttributeCreate      = "attribute.create"
+	ActionAttributeUpdate      = "attribute.update"
+	ActionAttributeDelete      = "attribute.delete"
+	ActionRoleGrant            = "user.role_grant"
+	ActionRoleRevoke           = "user.role_revoke"
+	ActionAPIKeyCreate         = "api_key.create"
+	ActionAPIKeyRevoke         = "api_key.revoke"
+	ActionImpersonationStart   = "user.impersonate"
+	ActionImpersonatedRequest  = "impersonation.request"
+	ActionAccountDelete        = "user.delete"
+	ActionUserBlock            = "user.block"
+	ActionUserUnblock          = "user.unblock"
+)
+
+// Actor is who performed an action and the request it came from. While an
+// admin impersonates a customer, the admin is the actor and OnBehalfOf is the
+// customer.
+type Actor struct {
+	UserID     uint
+	Role       string
+	APIKeyID   *uint
+	OnBehalfOf *uint
+	IP         string
+	RequestID  string
+}
+
+// Changes holds the fields of a target that an action changed, stored as jsonb.
+type Changes map[string]interface{}
+
+func (c Changes) Value() (driver.Value, error) {
+	if c == nil {
+		return nil, nil
+	}
+	return json.Marshal(c)
+}
+
+func (c *Changes) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type for audit changes")
+	}
+	return json.Unmarshal(data, c)
+}
+
+// Event is one entry in the append-only audit log. Rows are never updated or
+// deleted; the table rejects both.
+type Event struct {
+	ID         uint    `gorm:"primaryKey" json:"id"`
+	ActorID    uint    `gorm:"index" json:"actor_id"`
+	ActorRole  string  `json:"actor_role"`
+	APIKeyID   *uint   `json:"api_key_id,omitempty"`
+	OnBehalfOf *uint   `json:"on_behalf_of,omitempty"`
+	Action     string  `gorm:"not null;index" json:"action"`
+	TargetType string  `gorm:"not null" json:"target_type"`
+	TargetID   string  `gorm:"not null" json:"target_id"`
+	Before     Changes `gorm:"type:jsonb" json:"before,omitempty"`
+	After      Changes `gorm:"type:jsonb" json:"after,omitempty"`
+	IP         string  `json:"ip"`
+	RequestID  string  `json:"request_id"`
+
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+func (Event) TableName() string {
+	return "audit_events"
+}
+
+// EventFilter narrows down GET /api/v1/admin/audit. Zero values match anything.
+type EventFilter struct {
+	ActorID    uint      `form:"actor_id"`
+	Action     string    `form:"action"`
+	TargetType string    `form:"target_type"`
+	TargetID   string    `form:"target_id"`
+	RequestID  string    `form:"request_id"`
+	From       time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To         time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page       int       `form:"page"`
+	PageSize   int       `form:"page_size"`
+}
@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"gorm.io/gorm"
+)
+
+// Repository only appends and reads; there is deliberately no update or
+// delete.
+type Repository interface {
+	Create(event *Event) error
+	List(filter EventFilter) ([]Event, int64, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(event *Event) error {
+	return r.db.Create(event).Error
+}
+
+func (r *repository) List(filter EventFilter) ([]Event, int64, error) {
+	query := r.db.Model(&Event{})
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []Event
+	offset := (filter.Page - 1) * filter.PageSize
+	err := query.Order("created_at DESC, id DESC").
+		Offset(offset).Limit(filter.PageSize).
+		Find(&events).Error
+	return events, total, err
+}
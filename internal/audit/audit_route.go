@@ -0,0 +1,16 @@
+package audit
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAuditRoutes registers the audit log endpoint behind the given
+// middleware. main passes the auth checks in, since auth itself writes to the
+// audit log and cannot be imported here.
+func SetupAuditRoutes(router *gin.Engine, controller *Controller, middleware ...gin.HandlerFunc) {
+	admin := router.Group("/api/v1/admin")
+	admin.Use(middleware...)
+	{
+		admin.GET("/audit", controller.ListEvents)
+	}
+}
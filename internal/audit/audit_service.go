@@ -0,0 +1,129 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// Service records privileged actions. Other packages' services hold one and
+// call Record after a change has been saved.
+type Service interface {
+	Record(actor Actor, action, targetType string, targetID interface{}, before, after interface{})
+	List(filter EventFilter) ([]Event, int64, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+// Record writes an audit event. before and after are the target's state
+// around the change (structs, maps or nil); only the fields that differ are
+// stored. A failed write is logged but does not undo the action, which has
+// already been saved.
+func (s *service) Record(actor Actor, action, targetType string, targetID interface{}, before, after interface{}) {
+	changedBefore, changedAfter := Diff(before, after)
+
+	event := &Event{
+		ActorID:    actor.UserID,
+		ActorRole:  actor.Role,
+		APIKeyID:   actor.APIKeyID,
+		OnBehalfOf: actor.OnBehalfOf,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   fmt.Sprint(targetID),
+		Before:     changedBefore,
+		After:      changedAfter,
+		IP:         actor.IP,
+		RequestID:  actor.RequestID,
+	}
+	if err := s.repo.Create(event); err != nil {
+		log.Printf("🚨 Failed to write audit event: Action=%s, Target=%s/%v, ActorID=%d, Error=%v",
+			action, targetType, targetID, actor.UserID, err)
+	}
+}
+
+func (s *service) List(filter EventFilter) ([]Event, int64, error) {
+	filter.normalize()
+	return s.repo.List(filter)
+}
+
+func (f *EventFilter) normalize() {
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.PageSize < 1 {
+		f.PageSize = defaultPageSize
+	}
+	if f.PageSize > maxPageSize {
+		f.PageSize = maxPageSize
+	}
+}
+
+// ignoredFields change on every save and would only add noise to a diff.
+var ignoredFields = map[string]bool{
+	"updated_at": true,
+	"created_at": true,
+}
+
+// Diff returns the fields that differ between before and after, using their
+// JSON representation. A nil side (a create or a delete) yields nil.
+func Diff(before, after interface{}) (Changes, Changes) {
+	b := toChanges(before)
+	a := toChanges(after)
+	if b == nil || a == nil {
+		return b, a
+	}
+
+	changedBefore, changedAfter := Changes{}, Changes{}
+	for key, value := range b {
+		if ignoredFields[key] {
+			continue
+		}
+		if !reflect.DeepEqual(value, a[key]) {
+			changedBefore[key] = value
+			changedAfter[key] = a[key]
+		}
+	}
+	for key, value := range a {
+		if _, seen := b[key]; !seen && !ignoredFields[key] {
+			changedAfter[key] = value
+		}
+	}
+	return changedBefore, changedAfter
+}
+
+func toChanges(value interface{}) Changes {
+	if value == nil {
+		return nil
+	}
+	if rv := reflect.ValueOf(value); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var changes Changes
+	if err := json.Unmarshal(data, &changes); err != nil {
+		// Not an object, e.g. a plain status string
+		var scalar interface{}
+		json.Unmarshal(data, &scalar)
+		return Changes{"value": scalar}
+	}
+	for key := range ignoredFields {
+		delete(changes, key)
+	}
+	return changes
+}
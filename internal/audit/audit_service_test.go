@@ -0,0 +1,123 @@
+package audit
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeRepo struct {
+	Repository
+	events []*Event
+	err    error
+	filter EventFilter
+}
+
+func (r *fakeRepo) Create(event *Event) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *fakeRepo) List(filter EventFilter) ([]Event, int64, error) {
+	r.filter = filter
+	return nil, 0, nil
+}
+
+func TestDiff(t *testing.T) {
+	type product struct {
+		Name      string    `json:"name"`
+		Price     float64   `json:"price"`
+		Stock     int       `json:"stock"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+	before := product{Name: "Tea", Price: 100, Stock: 5, UpdatedAt: time.Unix(1, 0)}
+	after := product{Name: "Tea", Price: 120, Stock: 5, UpdatedAt: time.Unix(2, 0)}
+
+	b, a := Diff(before, &after)
+	if !reflect.DeepEqual(b, Changes{"price": 100.0}) || !reflect.DeepEqual(a, Changes{"price": 120.0}) {
+		t.Errorf("Diff = %v, %v; want only the price", b, a)
+	}
+
+	// A create has no before; the whole target is recorded, minus timestamps
+	b, a = Diff(nil, after)
+	if b != nil || a["name"] != "Tea" || a["updated_at"] != nil {
+		t.Errorf("create: Diff = %v, %v", b, a)
+	}
+	var missing *product
+	if b, a := Diff(before, missing); a != nil || b == nil {
+		t.Errorf("delete: Diff = %v, %v", b, a)
+	}
+
+	b, a = Diff("pending", "shipped")
+	if b["value"] != "pending" || a["value"] != "shipped" {
+		t.Errorf("scalars: Diff = %v, %v", b, a)
+	}
+
+	b, a = Diff(map[string]interface{}{"role": "staff"}, map[string]interface{}{"role": "staff", "note": "x"})
+	if len(b) != 0 || !reflect.DeepEqual(a, Changes{"note": "x"}) {
+		t.Errorf("added field: Diff = %v, %v", b, a)
+	}
+}
+
+func TestChangesValueScan(t *testing.T) {
+	value, err := Changes{"role": "admin"}.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var scanned Changes
+	if err := scanned.Scan(value); err != nil {
+		t.Fatal(err)
+	}
+	if scanned["role"] != "admin" {
+		t.Errorf("round trip = %v", scanned)
+	}
+
+	if value, _ := Changes(nil).Value(); value != nil {
+		t.Errorf("nil changes stored as %v", value)
+	}
+	if err := scanned.Scan(nil); err != nil || scanned != nil {
+		t.Errorf("scan NULL: %v, %v", scanned, err)
+	}
+	if err := scanned.Scan(42); err == nil {
+		t.Error("scanned an int")
+	}
+}
+
+func TestRecord(t *testing.T) {
+	repo := &fakeRepo{}
+	s := NewService(repo)
+	keyID := uint(9)
+	actor := Actor{UserID: 1, Role: "admin", APIKeyID: &keyID, IP: "10.0.0.1", RequestID: "req-12345"}
+
+	s.Record(actor, ActionRoleGrant, "user", 5, map[string]interface{}{"role": "customer"}, map[string]interface{}{"role": "staff"})
+	if len(repo.events) != 1 {
+		t.Fatalf("%d events recorded", len(repo.events))
+	}
+	event := repo.events[0]
+	if event.ActorID != 1 || event.ActorRole != "admin" || *event.APIKeyID != 9 || event.TargetID != "5" ||
+		event.IP != "10.0.0.1" || event.RequestID != "req-12345" || event.After["role"] != "staff" {
+		t.Errorf("event = %+v", event)
+	}
+
+	// A failed write must not panic or surface to the caller
+	repo.err = errors.New("db down")
+	s.Record(actor, ActionRoleGrant, "user", 5, nil, nil)
+}
+
+func TestListNormalizesPaging(t *testing.T) {
+	repo := &fakeRepo{}
+	s := NewService(repo)
+
+	s.List(EventFilter{})
+	if repo.filter.Page != 1 || repo.filter.PageSize != defaultPageSize {
+		t.Errorf("defaults: page %d, size %d", repo.filter.Page, repo.filter.PageSize)
+	}
+	s.List(EventFilter{Page: 3, PageSize: 10000})
+	if repo.filter.Page != 3 || repo.filter.PageSize != maxPageSize {
+		t.Errorf("capped: page %d, size %d", repo.filter.Page, repo.filter.PageSize)
+	}
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"ecommerce/internal/audit"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyController struct {
+	apiKeyService APIKeyService
+}
+
+func NewAPIKeyController(apiKeyService APIKeyService) *APIKeyController {
+	return &APIKeyController{apiKeyService: apiKeyService}
+}
+
+func (c *APIKeyController) CreateKey(ctx *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	key, raw, err := c.apiKeyService.CreateKey(audit.ActorFromContext(ctx), req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "API key created. Copy it now, it will not be shown again.",
+		"key":     raw,
+		"api_key": key,
+	})
+}
+
+func (c *APIKeyController) ListKeys(ctx *gin.Context) {
+	keys, err := c.apiKeyService.ListKeys()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get API keys",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"api_keys": keys,
+		"count":    len(keys),
+	})
+}
+
+func (c *APIKeyController) RevokeKey(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid API key ID",
+		})
+		return
+	}
+
+	if err := c.apiKeyService.RevokeKey(audit.ActorFromContext(ctx), uint(id)); err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "API key revoked",
+	})
+}
@@ -0,0 +1,15 @@
+package auth
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+func SetupAPIKeyRoutes(router *gin.Engine, apiKeyController *APIKeyController) {
+	admin := router.Group("/api/v1/admin/api-keys")
+	admin.Use(JWTAuthMiddleware(), RequireRole(RoleAdmin))
+	{
+		admin.GET("", apiKeyController.ListKeys)
+		admin.POST("", RequireMFA(), apiKeyController.CreateKey)
+		admin.DELETE("/:id", apiKeyController.RevokeKey)
+	}
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"crypto/rand"
+	"ecommerce/internal/audit"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+type APIKeyService interface {
+	// CreateKey returns the new key and its raw value, which is only shown once
+	CreateKey(actor audit.Actor, req CreateAPIKeyRequest) (*APIKey, string, error)
+	ListKeys() ([]APIKey, error)
+	RevokeKey(actor audit.Actor, id uint) error
+}
+
+type apiKeyService struct {
+	repo  APIKeyRepository
+	audit audit.Service
+}
+
+func NewAPIKeyService(repo APIKeyRepository, auditService audit.Service) APIKeyService {
+	return &apiKeyService{repo: repo, audit: auditService}
+}
+
+func (s *apiKeyService) CreateKey(actor audit.Actor, req CreateAPIKeyRequest) (*APIKey, string, error) {
+	scopes := make([]string, 0, len(req.Scopes))
+	seen := map[string]bool{}
+	for _, scope := range req.Scopes {
+		if !IsValidScope(scope) {
+			return nil, "", fmt.Errorf("invalid scope %q", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	prefixBytes := make([]byte, 4)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return nil, "", err
+	}
+	prefix := hex.EncodeToString(prefixBytes)
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	raw := "ak_" + prefix + "_" + secret
+
+	key := &APIKey{
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   hashToken(raw),
+		Scopes:    scopes,
+		CreatedBy: actor.UserID,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+	if err := s.repo.Create(key); err != nil {
+		return nil, "", err
+	}
+
+	s.audit.Record(actor, audit.ActionAPIKeyCreate, "api_key", key.ID, nil, key)
+	log.Printf("🔑 API key created: ID=%d, Prefix=%s, Scopes=%v, CreatedBy=%d", key.ID, prefix, scopes, actor.UserID)
+	return key, raw, nil
+}
+
+func (s *apiKeyService) ListKeys() ([]APIKey, error) {
+	return s.repo.List()
+}
+
+func (s *apiKeyService) RevokeKey(actor audit.Actor, id uint) error {
+	revoked, err := s.repo.Revoke(id)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errors.New("API key not found")
+	}
+
+	s.audit.Record(actor, audit.ActionAPIKeyRevoke, "api_key", id,
+		map[string]interface{}{"revoked": false}, map[string]interface{}{"revoked": true})
+	log.Printf("🗑️ API key revoked: ID=%d", id)
+	return nil
+}
@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"ecommerce/internal/audit"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type fakeAPIKeyRepo struct {
+	keys []*APIKey
+}
+
+func (r *fakeAPIKeyRepo) Create(key *APIKey) error {
+	key.ID = uint(len(r.keys) + 1)
+	r.keys = append(r.keys, key)
+	return nil
+}
+
+func (r *fakeAPIKeyRepo) FindByPrefix(prefix string) (*APIKey, error) {
+	for _, key := range r.keys {
+		if key.Prefix == prefix {
+			found := *key
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeAPIKeyRepo) List() ([]APIKey, error) {
+	keys := make([]APIKey, len(r.keys))
+	for i, key := range r.keys {
+		keys[i] = *key
+	}
+	return keys, nil
+}
+
+func (r *fakeAPIKeyRepo) Revoke(id uint) (bool, error) {
+	for _, key := range r.keys {
+		if key.ID == id && key.RevokedAt == nil {
+			now := time.Now()
+			key.RevokedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeAPIKeyRepo) TouchLastUsed(id uint, ip string) error {
+	now := time.Now()
+	r.keys[id-1].LastUsedAt = &now
+	r.keys[id-1].LastUsedIP = ip
+	return nil
+}
+
+// newAPIKeyTestService installs fake repositories for the middleware and
+// returns a service backed by them. User 1 is an admin and user 2 staff.
+func newAPIKeyTestService(t *testing.T) (*apiKeyService, *fakeAPIKeyRepo) {
+	t.Helper()
+	repo := &fakeAPIKeyRepo{}
+	SetAPIKeyRepository(repo)
+	SetUserRepository(newFakeUserRepo(
+		&User{ID: 1, Role: RoleAdmin},
+		&User{ID: 2, Role: RoleStaff},
+	))
+	t.Cleanup(func() {
+		SetAPIKeyRepository(nil)
+		SetUserRepository(nil)
+	})
+	return &apiKeyService{repo: repo, audit: &recordingAudit{}}, repo
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	s, repo := newAPIKeyTestService(t)
+
+	key, raw, err := s.CreateKey(audit.Actor{UserID: 7, Role: RoleAdmin}, CreateAPIKeyRequest{
+		Name:          "warehouse",
+		Scopes:        []string{ScopeOrdersRead, ScopeOrdersRead, ScopeOrdersWrite},
+		ExpiresInDays: 30,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(raw, "ak_"+key.Prefix+"_") {
+		t.Errorf("raw key %q does not carry prefix %q", raw, key.Prefix)
+	}
+	if repo.keys[0].KeyHash == raw || strings.Contains(repo.keys[0].KeyHash, raw) {
+		t.Error("raw key stored")
+	}
+	if len(key.Scopes) != 2 || key.CreatedBy != 7 || key.ExpiresAt == nil {
+		t.Errorf("key = %+v", key)
+	}
+
+	if _, _, err := s.CreateKey(audit.Actor{UserID: 7, Role: RoleAdmin}, CreateAPIKeyRequest{Name: "x", Scopes: []string{"admin"}}); err == nil {
+		t.Error("unknown scope accepted")
+	}
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	s, repo := newAPIKeyTestService(t)
+	_, raw, err := s.CreateKey(audit.Actor{UserID: 1, Role: RoleAdmin}, CreateAPIKeyRequest{Name: "k", Scopes: []string{ScopeOrdersRead}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := authenticateAPIKey(raw); err != nil {
+		t.Fatalf("valid key rejected: %v", err)
+	}
+	for name, bad := range map[string]string{
+		"wrong secret": raw[:len(raw)-2] + "xx",
+		"no prefix":    "ak__secret",
+		"not a key":    "Bearer abc",
+		"unknown":      "ak_00000000_secret",
+	} {
+		if _, err := authenticateAPIKey(bad); !errors.Is(err, ErrInvalidAPIKey) {
+			t.Errorf("%s: got %v, want ErrInvalidAPIKey", name, err)
+		}
+	}
+
+	past := time.Now().Add(-time.Minute)
+	repo.keys[0].ExpiresAt = &past
+	if _, err := authenticateAPIKey(raw); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("expired key: got %v, want ErrInvalidAPIKey", err)
+	}
+
+	repo.keys[0].ExpiresAt = nil
+	if err := s.RevokeKey(audit.Actor{UserID: 1, Role: RoleAdmin}, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authenticateAPIKey(raw); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("revoked key: got %v, want ErrInvalidAPIKey", err)
+	}
+	if err := s.RevokeKey(audit.Actor{UserID: 1, Role: RoleAdmin}, 1); err == nil {
+		t.Error("revoking twice succeeded")
+	}
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	s, repo := newAPIKeyTestService(t)
+	_, raw, err := s.CreateKey(audit.Actor{UserID: 1, Role: RoleAdmin}, CreateAPIKeyRequest{Name: "k", Scopes: []string{ScopeOrdersRead}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := http.Header{"X-Api-Key": {raw}}
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   int
+	}{
+		{"granted scope", header, http.StatusOK},
+		{"bearer form", bearer(raw), http.StatusOK},
+		{"bad key", http.Header{"X-Api-Key": {"ak_x_y"}}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		w := serve(tt.header, JWTOrAPIKeyMiddleware(), RequireScope(ScopeOrdersRead), RequireRole(RoleAdmin), RequireMFA())
+		if w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+	if repo.keys[0].LastUsedAt == nil {
+		t.Error("key use was not recorded")
+	}
+
+	if w := serve(header, JWTOrAPIKeyMiddleware(), RequireScope(ScopeOrdersWrite), RequireRole(RoleAdmin)); w.Code != http.StatusForbidden {
+		t.Errorf("missing scope: status %d, want 403", w.Code)
+	}
+	// A route without RequireScope is closed to API keys, even an admin's
+	if w := serve(header, JWTOrAPIKeyMiddleware(), RequireRole(RoleAdmin)); w.Code != http.StatusForbidden {
+		t.Errorf("unscoped route: status %d, want 403", w.Code)
+	}
+}
+
+func TestAPIKeyActsWithCreatorsCurrentRole(t *testing.T) {
+	s, _ := newAPIKeyTestService(t)
+	users := blockRepo.(*fakeUserRepo)
+	create := func(actor audit.Actor) http.Header {
+		t.Helper()
+		_, raw, err := s.CreateKey(actor, CreateAPIKeyRequest{Name: "k", Scopes: []string{ScopeOrdersRead}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return http.Header{"X-Api-Key": {raw}}
+	}
+	request := func(header http.Header) int {
+		return serve(header, JWTOrAPIKeyMiddleware(), RequireScope(ScopeOrdersRead), RequireRole(RoleAdmin)).Code
+	}
+
+	// A staff member's key can't reach admin routes
+	if code := request(create(audit.Actor{UserID: 2, Role: RoleStaff})); code != http.StatusForbidden {
+		t.Errorf("staff key on admin route: status %d, want 403", code)
+	}
+
+	admin := create(audit.Actor{UserID: 1, Role: RoleAdmin})
+	if code := request(admin); code != http.StatusOK {
+		t.Fatalf("admin key: status %d, want 200", code)
+	}
+
+	users.users[1].Role = RoleCustomer
+	if code := request(admin); code != http.StatusUnauthorized {
+		t.Errorf("key of a demoted admin: status %d, want 401", code)
+	}
+
+	users.users[1].Role = RoleAdmin
+	blockedAt := time.Now()
+	users.users[1].BlockedAt = &blockedAt
+	if code := request(admin); code != http.StatusUnauthorized {
+		t.Errorf("key of a blocked admin: status %d, want 401", code)
+	}
+}
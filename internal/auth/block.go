@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// blockCacheTTL bounds how long a block takes to reach other replicas. Blocking
+// also revokes the user's tokens, which every replica sees immediately.
+const blockCacheTTL = 30 * time.Second
+
+var ErrUserBlocked = errors.New("this account has been blocked")
+
+type BlockUserRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// ✅ JWTAuthMiddleware looks up blocked users through a package-level
+// repository, like the API key middleware. main sets it.
+var blockRepo UserRepository
+
+// SetUserRepository sets the repository the JWT middleware checks blocked
+// users against.
+func SetUserRepository(repo UserRepository) {
+	blockRepo = repo
+}
+
+type blockStatus struct {
+	blocked   bool
+	checkedAt time.Time
+}
+
+var blockCache = struct {
+	sync.Mutex
+	users map[uint]blockStatus
+}{users: make(map[uint]blockStatus)}
+
+// isUserBlocked reports whether userID is blocked, caching the answer for
+// blockCacheTTL. Lookup errors let the request through: the revoked tokens of
+// a blocked user are still rejected, and that check fails closed.
+func isUserBlocked(userID uint) bool {
+	if blockRepo == nil {
+		return false
+	}
+
+	blockCache.Lock()
+	status, ok := blockCache.users[userID]
+	blockCache.Unlock()
+	if ok && time.Since(status.checkedAt) < blockCacheTTL {
+		return status.blocked
+	}
+
+	blocked, err := blockRepo.IsBlocked(userID)
+	if err != nil {
+		log.Printf("⚠️ Block check failed: UserID=%d, err=%v", userID, err)
+		return false
+	}
+
+	blockCache.Lock()
+	if len(blockCache.users) > 10000 {
+		blockCache.users = make(map[uint]blockStatus)
+	}
+	blockCache.users[userID] = blockStatus{blocked: blocked, checkedAt: time.Now()}
+	blockCache.Unlock()
+	return blocked
+}
+
+// forgetBlockStatus drops the cached status so this replica sees a block or
+// unblock on the next request.
+func forgetBlockStatus(userID uint) {
+	blockCache.Lock()
+	delete(blockCache.users, userID)
+	blockCache.Unlock()
+}
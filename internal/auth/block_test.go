@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"ecommerce/internal/audit"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func newBlockTestService(t *testing.T) (*userService, *fakeUserRepo, *recordingAudit) {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "test-secret")
+	SetTokenStore(NewMemoryTokenStore())
+	repo := newFakeUserRepo(
+		&User{ID: 1, Role: RoleAdmin},
+		&User{ID: 2, Role: RoleCustomer},
+		&User{ID: 3, Role: RoleAdmin},
+	)
+	SetUserRepository(repo)
+	t.Cleanup(func() { SetUserRepository(nil) })
+	log := &recordingAudit{}
+	return &userService{repo: repo, audit: log}, repo, log
+}
+
+func TestBlockUserSignsOutEverywhere(t *testing.T) {
+	s, repo, log := newBlockTestService(t)
+	admin := audit.Actor{UserID: 1, Role: RoleAdmin}
+	tokens, err := s.IssueTokens(2, SessionMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.BlockUser(admin, 2, "chargeback fraud"); err != nil {
+		t.Fatal(err)
+	}
+	if repo.users[2].BlockedAt == nil || repo.users[2].BlockedReason != "chargeback fraud" {
+		t.Errorf("user = %+v, want blocked with the reason", repo.users[2])
+	}
+	if len(log.events) != 1 || log.events[0].action != audit.ActionUserBlock {
+		t.Errorf("audit events = %+v", log.events)
+	}
+
+	if w := serve(bearer(tokens.AccessToken), JWTAuthMiddleware()); w.Code == http.StatusOK {
+		t.Error("access token from before the block still works")
+	}
+	if _, err := s.RefreshTokens(tokens.RefreshToken, SessionMeta{}); err == nil {
+		t.Error("refresh token from before the block still works")
+	}
+	if _, err := s.IssueTokens(2, SessionMeta{}); !errors.Is(err, ErrUserBlocked) {
+		t.Errorf("IssueTokens: got %v, want ErrUserBlocked", err)
+	}
+	if _, err := s.IssueLoginCode(2); !errors.Is(err, ErrUserBlocked) {
+		t.Errorf("IssueLoginCode: got %v, want ErrUserBlocked", err)
+	}
+
+	if _, err := s.UnblockUser(admin, 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.IssueTokens(2, SessionMeta{}); err != nil {
+		t.Errorf("IssueTokens after unblock: %v", err)
+	}
+}
+
+func TestJWTAuthMiddlewareRejectsBlockedUser(t *testing.T) {
+	s, repo, _ := newBlockTestService(t)
+	token, err := s.GenerateToken(2, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(bearer(token), JWTAuthMiddleware()); w.Code != http.StatusOK {
+		t.Fatalf("before block: status %d, want 200", w.Code)
+	}
+
+	// Blocked straight in the database, so the token is still live
+	repo.SetBlocked(2, new(time.Time), "")
+	forgetBlockStatus(2)
+	if w := serve(bearer(token), JWTAuthMiddleware()); w.Code != http.StatusForbidden {
+		t.Errorf("after block: status %d, want 403", w.Code)
+	}
+}
+
+func TestBlockUserPermissions(t *testing.T) {
+	s, repo, _ := newBlockTestService(t)
+	admin := audit.Actor{UserID: 1, Role: RoleAdmin}
+
+	if _, err := s.BlockUser(admin, 1, "oops"); err == nil {
+		t.Error("admin blocked themselves")
+	}
+	if _, err := s.BlockUser(admin, 3, "rival"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("admin blocking admin: got %v, want ErrForbidden", err)
+	}
+	if repo.users[3].BlockedAt != nil {
+		t.Error("admin was blocked by another admin")
+	}
+
+	superAdmin := audit.Actor{UserID: 9, Role: RoleSuperAdmin}
+	if _, err := s.BlockUser(superAdmin, 3, "left the company"); err != nil {
+		t.Errorf("super admin blocking admin: %v", err)
+	}
+}
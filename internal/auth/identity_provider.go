@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"context"
+	"ecommerce/config"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// ExternalIdentity is a user as reported by a login provider.
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string // Provider's stable user ID
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// IdentityProvider is one way to log in: it builds the consent URL, trades
+// the callback code for a token and maps the provider's profile to an
+// ExternalIdentity.
+type IdentityProvider interface {
+	Name() string
+	AuthCodeURL(state, verifier string) string
+	Exchange(ctx context.Context, code, verifier string) (*oauth2.Token, error)
+	UserInfo(ctx context.Context, token *oauth2.Token) (*ExternalIdentity, error)
+}
+
+// oauthIdentityProvider covers every OAuth2/OIDC provider we support; they
+// only differ in configuration and in how the profile JSON is shaped.
+type oauthIdentityProvider struct {
+	cfg       *config.OAuthProvider
+	mapFields func(profile map[string]interface{}) *ExternalIdentity
+}
+
+func (p *oauthIdentityProvider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *oauthIdentityProvider) AuthCodeURL(state, verifier string) string {
+	if p.cfg.PKCE {
+		return p.cfg.OAuth2.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier))
+	}
+	return p.cfg.OAuth2.AuthCodeURL(state)
+}
+
+func (p *oauthIdentityProvider) Exchange(ctx context.Context, code, verifier string) (*oauth2.Token, error) {
+	if p.cfg.PKCE {
+		return p.cfg.OAuth2.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	}
+	return p.cfg.OAuth2.Exchange(ctx, code)
+}
+
+func (p *oauthIdentityProvider) UserInfo(ctx context.Context, token *oauth2.Token) (*ExternalIdentity, error) {
+	resp, err := p.cfg.OAuth2.Client(ctx, token).Get(p.cfg.UserInfoURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("user info request failed with status %d", resp.StatusCode)
+	}
+
+	var profile map[string]interface{}
+	if err := json.Unmarshal(body, &profile); err != nil {
+		return nil, err
+	}
+
+	identity := p.mapFields(profile)
+	identity.Provider = p.cfg.Name
+	if identity.Subject == "" {
+		return nil, errors.New("provider did not return a user ID")
+	}
+	identity.Email = strings.ToLower(strings.TrimSpace(identity.Email))
+	return identity, nil
+}
+
+// NewIdentityProviders wraps every provider enabled in config.OAuthProviders.
+func NewIdentityProviders() map[string]IdentityProvider {
+	providers := make(map[string]IdentityProvider)
+	for name, cfg := range config.OAuthProviders {
+		switch name {
+		case "google":
+			providers[name] = &oauthIdentityProvider{cfg: cfg, mapFields: mapGoogleProfile}
+		case "facebook":
+			providers[name] = &oauthIdentityProvider{cfg: cfg, mapFields: mapFacebookProfile}
+		default:
+			providers[name] = &oauthIdentityProvider{cfg: cfg, mapFields: mapOIDCProfile}
+		}
+	}
+	return providers
+}
+
+// Google's v2 userinfo endpoint uses "id" and "verified_email"; the OIDC
+// endpoint uses "sub" and "email_verified". Accept both.
+func mapGoogleProfile(profile map[string]interface{}) *ExternalIdentity {
+	identity := mapOIDCProfile(profile)
+	if identity.Subject == "" {
+		identity.Subject = stringField(profile, "id")
+	}
+	if !identity.EmailVerified {
+		identity.EmailVerified = boolField(profile, "verified_email")
+	}
+	return identity
+}
+
+// Facebook only returns confirmed email addresses.
+func mapFacebookProfile(profile map[string]interface{}) *ExternalIdentity {
+	email := stringField(profile, "email")
+	return &ExternalIdentity{
+		Subject:       stringField(profile, "id"),
+		Email:         email,
+		EmailVerified: email != "",
+		Name:          stringField(profile, "name"),
+	}
+}
+
+func mapOIDCProfile(profile map[string]interface{}) *ExternalIdentity {
+	return &ExternalIdentity{
+		Subject:       stringField(profile, "sub"),
+		Email:         stringField(profile, "email"),
+		EmailVerified: boolField(profile, "email_verified"),
+		Name:          stringField(profile, "name"),
+	}
+}
+
+func stringField(profile map[string]interface{}, key string) string {
+	switch v := profile[key].(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%.0f", v)
+	}
+	return ""
+}
+
+// boolField also accepts "true", which some providers send as a string.
+func boolField(profile map[string]interface{}, key string) bool {
+	switch v := profile[key].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"ecommerce/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+// stubUserInfo serves profile as the provider's user info endpoint.
+func stubUserInfo(t *testing.T, status int, profile string) *config.OAuthProvider {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(profile))
+	}))
+	t.Cleanup(srv.Close)
+	return &config.OAuthProvider{Name: "test", OAuth2: &oauth2.Config{}, UserInfoURL: srv.URL}
+}
+
+func TestUserInfoMapsProfiles(t *testing.T) {
+	tests := []struct {
+		name      string
+		mapFields func(map[string]interface{}) *ExternalIdentity
+		profile   string
+		want      ExternalIdentity
+	}{
+		{
+			"google v2", mapGoogleProfile,
+			`{"id":"123","email":" Jane@Example.com ","verified_email":true,"name":"Jane"}`,
+			ExternalIdentity{Provider: "test", Subject: "123", Email: "jane@example.com", EmailVerified: true, Name: "Jane"},
+		},
+		{
+			"oidc", mapOIDCProfile,
+			`{"sub":"abc","email":"a@example.com","email_verified":"true"}`,
+			ExternalIdentity{Provider: "test", Subject: "abc", Email: "a@example.com", EmailVerified: true},
+		},
+		{
+			"oidc unverified", mapOIDCProfile,
+			`{"sub":"abc","email":"a@example.com"}`,
+			ExternalIdentity{Provider: "test", Subject: "abc", Email: "a@example.com"},
+		},
+		{
+			"facebook numeric id", mapFacebookProfile,
+			`{"id":10158,"email":"f@example.com"}`,
+			ExternalIdentity{Provider: "test", Subject: "10158", Email: "f@example.com", EmailVerified: true},
+		},
+	}
+	for _, tt := range tests {
+		p := &oauthIdentityProvider{cfg: stubUserInfo(t, http.StatusOK, tt.profile), mapFields: tt.mapFields}
+		got, err := p.UserInfo(context.Background(), &oauth2.Token{AccessToken: "access"})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if *got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, *got, tt.want)
+		}
+	}
+}
+
+func TestUserInfoErrors(t *testing.T) {
+	for name, cfg := range map[string]*config.OAuthProvider{
+		"no subject": stubUserInfo(t, http.StatusOK, `{"email":"a@example.com"}`),
+		"bad status": stubUserInfo(t, http.StatusBadGateway, `{"sub":"abc"}`),
+		"bad json":   stubUserInfo(t, http.StatusOK, `<html>`),
+	} {
+		p := &oauthIdentityProvider{cfg: cfg, mapFields: mapOIDCProfile}
+		if _, err := p.UserInfo(context.Background(), &oauth2.Token{AccessToken: "access"}); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
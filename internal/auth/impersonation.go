@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"ecommerce/internal/audit"
+	"errors"
+	"time"
+)
+
+// impersonationTTL keeps support sessions short; there is no refresh token,
+// so support asks for a new one if the call runs long.
+const impersonationTTL = 15 * time.Minute
+
+var ErrCannotImpersonate = errors.New("only customer accounts can be impersonated")
+
+type ImpersonateRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"` // e.g. the support ticket
+}
+
+type ImpersonationToken struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	UserID      uint      `json:"user_id"`
+}
+
+// ✅ The JWT middleware has no service to hand, so impersonated requests are
+// audited through a package-level recorder, like the token store. main sets it.
+var requestAuditor audit.Service
+
+// SetAuditService sets where the middleware records impersonated requests.
+func SetAuditService(service audit.Service) {
+	requestAuditor = service
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"ecommerce/internal/audit"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newImpersonationTestService(t *testing.T) (*userService, *recordingAudit) {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "test-secret")
+	SetTokenStore(NewMemoryTokenStore())
+	log := &recordingAudit{}
+	SetAuditService(log)
+	t.Cleanup(func() { SetAuditService(nil) })
+	repo := newFakeUserRepo(
+		&User{ID: 1, Role: RoleAdmin},
+		&User{ID: 2, Role: RoleCustomer},
+		&User{ID: 3, Role: RoleStaff},
+	)
+	return &userService{repo: repo, audit: log}, log
+}
+
+var supportAdmin = audit.Actor{UserID: 1, Role: RoleAdmin}
+
+func TestImpersonate(t *testing.T) {
+	s, log := newImpersonationTestService(t)
+
+	token, err := s.Impersonate(supportAdmin, 2, "ticket 42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := validateToken(token.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	act, _ := claims["act"].(map[string]interface{})
+	if claims["user_id"] != float64(2) || act["sub"] != float64(1) || act["role"] != RoleAdmin {
+		t.Errorf("claims = %v", claims)
+	}
+	if len(log.events) != 1 || log.events[0].action != audit.ActionImpersonationStart {
+		t.Errorf("audit events = %+v", log.events)
+	}
+
+	if _, err := s.Impersonate(supportAdmin, 3, "x"); !errors.Is(err, ErrCannotImpersonate) {
+		t.Errorf("impersonating staff: got %v, want ErrCannotImpersonate", err)
+	}
+	if _, err := s.Impersonate(supportAdmin, 1, "x"); err == nil {
+		t.Error("admin impersonated themselves")
+	}
+}
+
+func TestImpersonatedRequests(t *testing.T) {
+	s, log := newImpersonationTestService(t)
+	token, err := s.Impersonate(supportAdmin, 2, "ticket 42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.events = nil
+
+	if w := serve(bearer(token.AccessToken), JWTAuthMiddleware()); w.Code != http.StatusOK {
+		t.Fatalf("impersonated request: status %d", w.Code)
+	}
+	if len(log.events) != 1 {
+		t.Fatalf("%d audit events, want 1", len(log.events))
+	}
+	event := log.events[0]
+	if event.action != audit.ActionImpersonatedRequest || event.actor.UserID != 1 ||
+		event.actor.OnBehalfOf == nil || *event.actor.OnBehalfOf != 2 {
+		t.Errorf("audit event = %+v", event)
+	}
+
+	if w := serve(bearer(token.AccessToken), JWTAuthMiddleware(), BlockImpersonation()); w.Code != http.StatusForbidden {
+		t.Errorf("blocked route: status %d, want 403", w.Code)
+	}
+
+	// The customer's own tokens are unaffected
+	own, err := s.GenerateToken(2, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(bearer(own), JWTAuthMiddleware(), BlockImpersonation()); w.Code != http.StatusOK {
+		t.Errorf("customer's own request: status %d, want 200", w.Code)
+	}
+}
+
+func TestSigningOutEverywhereEndsImpersonation(t *testing.T) {
+	s, _ := newImpersonationTestService(t)
+	token, err := s.Impersonate(supportAdmin, 2, "ticket 42")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tokenStore.RevokeUser(2); err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(bearer(token.AccessToken), JWTAuthMiddleware()); w.Code != http.StatusUnauthorized {
+		t.Errorf("after revoking the customer's tokens: status %d, want 401", w.Code)
+	}
+}
+
+func TestImpersonationBlocksAccountChanges(t *testing.T) {
+	s, _ := newImpersonationTestService(t)
+	token, err := s.Impersonate(supportAdmin, 2, "ticket 42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	SetupAuthRoutes(router, &UserController{})
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPost, "/api/v1/addresses"},
+		{http.MethodPut, "/api/v1/addresses/1"},
+		{http.MethodPut, "/api/v1/addresses/1/default"},
+		{http.MethodDelete, "/api/v1/addresses/1"},
+		{http.MethodPut, "/api/v1/profile"},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(route.method, route.path, nil)
+		req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s %s: status %d, want 403", route.method, route.path, w.Code)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// jwtKey is one asymmetric key. The signing key has Private set; keys that
+// are only trusted for verification (e.g. the previous key during a
+// rotation) only have Public.
+type jwtKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// jwtKeyring holds the configured keys. With no signing key, tokens fall back
+// to HS256 with JWT_SECRET, as before asymmetric keys were supported.
+type jwtKeyring struct {
+	signing *jwtKey
+	verify  map[string]*jwtKey
+	// allowHS256 keeps accepting JWT_SECRET tokens, for the switch-over from
+	// HS256 until the last of those tokens has expired
+	allowHS256 bool
+}
+
+var (
+	keysMu  sync.RWMutex
+	jwtKeys = &jwtKeyring{verify: map[string]*jwtKey{}, allowHS256: true}
+)
+
+// LoadJWTKeys reads the asymmetric keys from the environment:
+//
+//	JWT_SIGNING_KEY_FILE  PEM private key (RSA >= 2048 bits or Ed25519) used to sign
+//	JWT_SIGNING_KEY_ID    its kid; defaults to the RFC 7638 thumbprint
+//	JWT_VERIFY_KEY_FILES  comma-separated PEM public keys still accepted, as
+//	                      "path" or "kid=path"
+//	JWT_ACCEPT_HS256      "true" to keep accepting HS256 tokens after switching
+//
+// Without JWT_SIGNING_KEY_FILE nothing changes and HS256 is used.
+func LoadJWTKeys() error {
+	ring := &jwtKeyring{verify: map[string]*jwtKey{}}
+
+	if path := os.Getenv("JWT_SIGNING_KEY_FILE"); path != "" {
+		key, err := loadJWTKey(path, os.Getenv("JWT_SIGNING_KEY_ID"))
+		if err != nil {
+			return err
+		}
+		if key.Private == nil {
+			return fmt.Errorf("%s: signing key must be a private key", path)
+		}
+		ring.signing = key
+		ring.verify[key.ID] = key
+	}
+
+	for _, entry := range strings.Split(os.Getenv("JWT_VERIFY_KEY_FILES"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kid, path := "", entry
+		if i := strings.Index(entry, "="); i > 0 {
+			kid, path = entry[:i], entry[i+1:]
+		}
+		key, err := loadJWTKey(path, kid)
+		if err != nil {
+			return err
+		}
+		if _, exists := ring.verify[key.ID]; exists {
+			return fmt.Errorf("duplicate JWT key id %q", key.ID)
+		}
+		ring.verify[key.ID] = key
+	}
+
+	ring.allowHS256 = ring.signing == nil || os.Getenv("JWT_ACCEPT_HS256") == "true"
+
+	keysMu.Lock()
+	jwtKeys = ring
+	keysMu.Unlock()
+	return nil
+}
+
+func currentJWTKeys() *jwtKeyring {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	return jwtKeys
+}
+
+func loadJWTKey(path, kid string) (*jwtKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data", path)
+	}
+
+	key := &jwtKey{}
+	switch block.Type {
+	case "PRIVATE KEY", "RSA PRIVATE KEY":
+		var parsed interface{}
+		if block.Type == "RSA PRIVATE KEY" {
+			parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		} else {
+			parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("%s: unsupported private key", path)
+		}
+		key.Private = signer
+		key.Public = signer.Public()
+	case "PUBLIC KEY":
+		if key.Public, err = x509.ParsePKIXPublicKey(block.Bytes); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", path, block.Type)
+	}
+
+	switch pub := key.Public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("%s: RSA keys must be at least 2048 bits", path)
+		}
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("%s: only RSA and Ed25519 keys are supported", path)
+	}
+
+	key.ID = kid
+	if key.ID == "" {
+		key.ID = jwkThumbprint(key.Public)
+	}
+	return key, nil
+}
+
+// signJWT signs claims with the current signing key, or HS256 with
+// JWT_SECRET when no asymmetric key is configured.
+func signJWT(claims jwt.MapClaims) (string, error) {
+	ring := currentJWTKeys()
+	if ring.signing != nil {
+		token := jwt.NewWithClaims(ring.signing.Method, claims)
+		token.Header["kid"] = ring.signing.ID
+		return token.SignedString(ring.signing.Private)
+	}
+
+	// ✅ SECURE: Strong secret requirement (no fallback)
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwtSecret == "" {
+		return "", errors.New("JWT_SECRET environment variable is required")
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(jwtSecret))
+}
+
+// jwtKeyFunc picks the verification key for a token by its kid and checks the
+// algorithm matches that key, so an RSA public key can never be used as an
+// HMAC secret.
+func jwtKeyFunc(token *jwt.Token) (interface{}, error) {
+	ring := currentJWTKeys()
+
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if !ring.allowHS256 {
+			return nil, errors.New("unexpected signing method")
+		}
+		jwtSecret := os.Getenv("JWT_SECRET")
+		if jwtSecret == "" {
+			return nil, errors.New("JWT_SECRET environment variable required")
+		}
+		return []byte(jwtSecret), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ring.verify[kid]
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.Public, nil
+}
+
+// JWK is a public key in JSON Web Key format.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+func toJWK(key *jwtKey) JWK {
+	jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+	switch pub := key.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return jwk
+}
+
+// jwkThumbprint is the RFC 7638 thumbprint of a public key.
+func jwkThumbprint(pub crypto.PublicKey) string {
+	var members interface{}
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		jwk := toJWK(&jwtKey{Public: k, Method: jwt.SigningMethodRS256})
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	case ed25519.PublicKey:
+		jwk := toJWK(&jwtKey{Public: k, Method: jwt.SigningMethodEdDSA})
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// JWKS publishes the public verification keys so other services can check our
+// tokens without the signing key.
+func JWKS(ctx *gin.Context) {
+	ring := currentJWTKeys()
+	keys := make([]JWK, 0, len(ring.verify))
+	if ring.signing != nil {
+		keys = append(keys, toJWK(ring.signing))
+	}
+	for id, key := range ring.verify {
+		if ring.signing != nil && id == ring.signing.ID {
+			continue
+		}
+		keys = append(keys, toJWK(key))
+	}
+
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, gin.H{
+		"keys": keys,
+	})
+}
@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// writeKey writes key as PEM into dir and returns the path.
+func writeKey(t *testing.T, name string, key interface{}, public bool) string {
+	t.Helper()
+	var (
+		der []byte
+		err error
+	)
+	blockType := "PRIVATE KEY"
+	if public {
+		blockType = "PUBLIC KEY"
+		der, err = x509.MarshalPKIXPublicKey(key)
+	} else {
+		der, err = x509.MarshalPKCS8PrivateKey(key)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// useJWTKeys loads keys from the current environment and restores the HS256
+// default when the test ends.
+func useJWTKeys(t *testing.T) {
+	t.Helper()
+	if err := LoadJWTKeys(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		keysMu.Lock()
+		jwtKeys = &jwtKeyring{verify: map[string]*jwtKey{}, allowHS256: true}
+		keysMu.Unlock()
+	})
+}
+
+func testClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"user_id": 1,
+		"iss":     "ecommerce-api",
+		"aud":     "ecommerce-app",
+		"exp":     now.Add(time.Hour).Unix(),
+		"iat":     now.Unix(),
+		"nbf":     now.Unix(),
+	}
+}
+
+func parseWithKeys(token string) error {
+	_, err := jwt.Parse(token, jwtKeyFunc)
+	return err
+}
+
+func TestSignJWTWithEd25519Key(t *testing.T) {
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	t.Setenv("JWT_SIGNING_KEY_FILE", writeKey(t, "ed.pem", priv, false))
+	t.Setenv("JWT_SIGNING_KEY_ID", "2026-10")
+	useJWTKeys(t)
+
+	token, err := signJWT(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _ := jwt.Parse(token, jwtKeyFunc)
+	if parsed == nil || parsed.Header["alg"] != "EdDSA" || parsed.Header["kid"] != "2026-10" {
+		t.Fatalf("token header = %v", parsed)
+	}
+	if err := parseWithKeys(token); err != nil {
+		t.Errorf("own token rejected: %v", err)
+	}
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+	oldKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	t.Setenv("JWT_SIGNING_KEY_FILE", writeKey(t, "old.pem", oldKey, false))
+	useJWTKeys(t)
+	oldToken, err := signJWT(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldKid := currentJWTKeys().signing.ID
+
+	// Rotate: sign with the new key, still accept the old one
+	_, newKey, _ := ed25519.GenerateKey(rand.Reader)
+	t.Setenv("JWT_SIGNING_KEY_FILE", writeKey(t, "new.pem", newKey, false))
+	t.Setenv("JWT_VERIFY_KEY_FILES", oldKid+"="+writeKey(t, "old.pub", &oldKey.PublicKey, true))
+	useJWTKeys(t)
+
+	if err := parseWithKeys(oldToken); err != nil {
+		t.Errorf("token from the previous key rejected: %v", err)
+	}
+
+	// ...until it is dropped
+	t.Setenv("JWT_VERIFY_KEY_FILES", "")
+	useJWTKeys(t)
+	if err := parseWithKeys(oldToken); err == nil {
+		t.Error("token from a retired key accepted")
+	}
+}
+
+func TestJWTKeyFuncRejectsHS256AfterSwitching(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	hs256, err := signJWT(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	pubPath := writeKey(t, "rsa.pub", &rsaKey.PublicKey, true)
+	t.Setenv("JWT_SIGNING_KEY_FILE", writeKey(t, "rsa.pem", rsaKey, false))
+	useJWTKeys(t)
+	if err := parseWithKeys(hs256); err == nil {
+		t.Error("HS256 token accepted after switching keys")
+	}
+
+	// Nor can the public key be used as an HMAC secret
+	pubPEM, _ := os.ReadFile(pubPath)
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+	forged.Header["kid"] = currentJWTKeys().signing.ID
+	forgedToken, _ := forged.SignedString(pubPEM)
+	if err := parseWithKeys(forgedToken); err == nil {
+		t.Error("token signed with the public key as HMAC secret accepted")
+	}
+
+	t.Setenv("JWT_ACCEPT_HS256", "true")
+	useJWTKeys(t)
+	if err := parseWithKeys(hs256); err != nil {
+		t.Errorf("HS256 token rejected during switch-over: %v", err)
+	}
+}
+
+func TestLoadJWTKeyRejectsWeakKeys(t *testing.T) {
+	weak, _ := rsa.GenerateKey(rand.Reader, 1024)
+	if _, err := loadJWTKey(writeKey(t, "weak.pem", weak, false), ""); err == nil {
+		t.Error("1024-bit RSA key accepted")
+	}
+
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	t.Setenv("JWT_SIGNING_KEY_FILE", writeKey(t, "ed.pub", priv.Public(), true))
+	if err := LoadJWTKeys(); err == nil {
+		t.Error("public key accepted as the signing key")
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	t.Setenv("JWT_SIGNING_KEY_FILE", writeKey(t, "ed.pem", priv, false))
+	t.Setenv("JWT_VERIFY_KEY_FILES", writeKey(t, "rsa.pub", &rsaKey.PublicKey, true))
+	useJWTKeys(t)
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	JWKS(ctx)
+
+	var body struct{ Keys []JWK }
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || len(body.Keys) != 2 {
+		t.Fatalf("status %d, keys %+v", w.Code, body.Keys)
+	}
+	signing := body.Keys[0]
+	if signing.Kty != "OKP" || signing.Crv != "Ed25519" || signing.Kid != jwkThumbprint(priv.Public()) {
+		t.Errorf("signing key listed as %+v", signing)
+	}
+	if body.Keys[1].Kty != "RSA" || body.Keys[1].E != "AQAB" {
+		t.Errorf("verify key listed as %+v", body.Keys[1])
+	}
+}
@@ -1,35 +1,22 @@
 package auth
 
 import (
-	"encoding/json"
+	"ecommerce/internal/audit"
 	"errors"
-	"fmt"
 	"log"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
-	"gorm.io/gorm"
 )
 
 // ✅ SECURE: Complete token validation with all security checks
 func validateToken(tokenString string) (jwt.MapClaims, error) {
-	// ✅ Require strong secret
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		return nil, errors.New("JWT_SECRET environment variable required")
-	}
-
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// ✅ Strict signing method validation
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(jwtSecret), nil
-	})
+	// ✅ Strict signing method validation: the key is chosen by kid and must
+	// match the token's algorithm
+	token, err := jwt.Parse(tokenString, jwtKeyFunc)
 
 	if err != nil {
 		return nil, err
@@ -82,9 +69,24 @@ func validateToken(tokenString string) (jwt.MapClaims, error) {
 
 // ✅ SECURE: Enhanced JWT middleware
 func JWTAuthMiddleware() gin.HandlerFunc {
+	return jwtAuth(true)
+}
+
+// OptionalJWTAuthMiddleware authenticates the request when it carries a token
+// and lets anonymous requests through. A token that is present but invalid is
+// still rejected, so clients know to refresh it.
+func OptionalJWTAuthMiddleware() gin.HandlerFunc {
+	return jwtAuth(false)
+}
+
+func jwtAuth(required bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
+			if !required {
+				c.Next()
+				return
+			}
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "Authorization header required",
 			})
@@ -113,46 +115,223 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		userID := uint(claims["user_id"].(float64))
+		if isUserBlocked(userID) {
+			log.Printf("🚫 Blocked user attempted access: UserID=%d", userID)
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "This account has been blocked",
+				"blocked": true,
+			})
+			c.Abort()
+			return
+		}
+
 		// Set user context for controllers
-		c.Set("userID", uint(claims["user_id"].(float64)))
+		c.Set("userID", userID)
 		if jti, exists := claims["jti"]; exists {
 			c.Set("tokenID", jti)
 		}
+		if sid, ok := claims["sid"].(string); ok {
+			c.Set("sessionID", sid)
+		}
+		if role, ok := claims["role"].(string); ok && role != "" {
+			c.Set("userRole", role)
+		} else {
+			c.Set("userRole", RoleCustomer)
+		}
+		if mfa, ok := claims["mfa"].(float64); ok {
+			c.Set("mfaAt", int64(mfa))
+		}
+
+		// ✅ Impersonation tokens name the admin behind them in the act claim
+		act, impersonating := claims["act"].(map[string]interface{})
+		if !impersonating {
+			c.Next()
+			return
+		}
+
+		impersonatorID, _ := act["sub"].(float64)
+		impersonatorRole, _ := act["role"].(string)
+		c.Set("impersonatorID", uint(impersonatorID))
+		c.Set("impersonatorRole", impersonatorRole)
+
+		c.Next()
+
+		// ✅ Every impersonated request is logged and audited
+		log.Printf("🕵️ Impersonated request: ImpersonatorID=%d, UserID=%v, %s %s -> %d",
+			uint(impersonatorID), c.Value("userID"), c.Request.Method, c.Request.URL.Path, c.Writer.Status())
+		if requestAuditor != nil {
+			requestAuditor.Record(audit.ActorFromContext(c), audit.ActionImpersonatedRequest, "user", c.GetUint("userID"), nil,
+				map[string]interface{}{
+					"method": c.Request.Method,
+					"path":   c.Request.URL.Path,
+					"status": c.Writer.Status(),
+				})
+		}
+	}
+}
+
+// ✅ BlockImpersonation refuses actions taken as the customer, such as placing
+// orders, changing addresses or submitting payment proof, while support is
+// impersonating them. Impersonation is for looking, not acting.
+// Must be used after JWTAuthMiddleware.
+func BlockImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetUint("impersonatorID") != 0 {
+			log.Printf("🕵️ Blocked while impersonating: ImpersonatorID=%d, UserID=%v, Path=%s",
+				c.GetUint("impersonatorID"), c.Value("userID"), c.FullPath())
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":         "This action is not available while impersonating a customer",
+				"impersonating": true,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ✅ RequireMFA makes staff and admins pass a recent two-factor check before
+// sensitive actions. The check is the token's mfa claim, obtained from
+// POST /auth/mfa/verify. Must be used after JWTAuthMiddleware.
+func RequireMFA() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if apiKeyAuthorized(c) {
+			c.Next()
+			return
+		}
+
+		switch c.GetString("userRole") {
+		case RoleStaff, RoleAdmin, RoleSuperAdmin:
+		default:
+			c.Next()
+			return
+		}
+
+		mfaAt := c.GetInt64("mfaAt")
+		if mfaAt == 0 || time.Since(time.Unix(mfaAt, 0)) > mfaStepUpTTL {
+			log.Printf("🛡️ Two-factor check required: UserID=%v, Path=%s", c.Value("userID"), c.FullPath())
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":        "Two-factor verification required",
+				"mfa_required": true,
+			})
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
 }
 
-func LocationTrackingMiddleware(db *gorm.DB) gin.HandlerFunc {
-	return func(ctx *gin.Context) {
-		ip := ctx.ClientIP()
-		if ip == "" || ip == "127.0.0.1" || ip == "::1" {
-			ip = "8.8.8.8" // fallback for local testing
+// JWTOrAPIKeyMiddleware accepts either a user JWT or an API key, sent as
+// "X-API-Key: ak_..." or "Authorization: Bearer ak_...". API key requests
+// carry their creator's role; routes must also grant them access with
+// RequireScope.
+func JWTOrAPIKeyMiddleware() gin.HandlerFunc {
+	jwtAuth := JWTAuthMiddleware()
+	return func(c *gin.Context) {
+		raw := c.GetHeader("X-API-Key")
+		if raw == "" {
+			if bearer := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "); strings.HasPrefix(bearer, "ak_") {
+				raw = bearer
+			}
+		}
+		if raw == "" {
+			jwtAuth(c)
+			return
+		}
+
+		key, err := authenticateAPIKey(raw)
+		if err != nil {
+			log.Printf("🚨 API key rejected from %s", c.ClientIP())
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid or revoked API key",
+			})
+			c.Abort()
+			return
 		}
 
-		resp, err := http.Get(fmt.Sprintf("http://ip-api.com/json/%s", ip))
+		role, err := apiKeyCreatorRole(key)
 		if err != nil {
-			ctx.Next()
+			log.Printf("🚫 API key of blocked or demoted user used: APIKeyID=%d, CreatedBy=%d", key.ID, key.CreatedBy)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid or revoked API key",
+			})
+			c.Abort()
 			return
 		}
-		defer resp.Body.Close()
 
-		var data struct {
-			Country    string `json:"country"`
-			RegionName string `json:"regionName"`
-			City       string `json:"city"`
+		if err := apiKeyRepo.TouchLastUsed(key.ID, c.ClientIP()); err != nil {
+			log.Printf("⚠️ Failed to record API key use: %v", err)
+		}
+
+		// Handlers that record who acted see the key's creator, and
+		// RequireRole checks the creator's current role
+		c.Set("userID", key.CreatedBy)
+		c.Set("userRole", role)
+		c.Set("apiKeyID", key.ID)
+		c.Set("apiKey", key)
+
+		c.Next()
+	}
+}
+
+// ✅ RequireScope lets API keys through only when they hold scope. It has no
+// effect on JWT requests, which still go through RequireRole and RequireMFA.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, isAPIKey := c.Get("apiKey")
+		if !isAPIKey {
+			c.Next()
+			return
 		}
-		if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-			ctx.Next()
+
+		key := value.(*APIKey)
+		if !key.HasScope(scope) {
+			log.Printf("🚫 Scope check failed: APIKeyID=%d, Scope=%s, Path=%s", key.ID, scope, c.FullPath())
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "API key is missing the " + scope + " scope",
+			})
+			c.Abort()
 			return
 		}
 
-		// Store in DB
-		db.Exec(
-			"INSERT INTO visitor_logs (ip, country, region, city, visited_at) VALUES (?, ?, ?, ?, ?)",
-			ip, data.Country, data.RegionName, data.City, time.Now(),
-		)
+		c.Set("apiKeyScoped", true)
+		c.Next()
+	}
+}
+
+// apiKeyAuthorized reports whether this is an API key request that passed
+// RequireScope. MFA checks don't apply to those; any other API key request
+// is refused by RequireRole and RequireMFA.
+func apiKeyAuthorized(c *gin.Context) bool {
+	return c.GetBool("apiKeyScoped")
+}
+
+// ✅ RequireRole only lets through users whose role is one of roles.
+// Super-admins are always allowed. API key requests are checked against
+// their creator's role and must have passed RequireScope. Must be used after
+// JWTAuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(roles)+1)
+	for _, role := range roles {
+		allowed[role] = true
+	}
+	allowed[RoleSuperAdmin] = true
 
-		ctx.Next()
+	return func(c *gin.Context) {
+		_, isAPIKey := c.Get("apiKey")
+		role := c.GetString("userRole")
+		if !allowed[role] || (isAPIKey && !apiKeyAuthorized(c)) {
+			log.Printf("🚫 Role check failed: UserID=%v, Role=%q, Path=%s", c.Value("userID"), role, c.FullPath())
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "You do not have permission to access this resource",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
 	}
 }
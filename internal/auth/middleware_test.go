@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// serve runs a GET / through handlers, ending in one that responds 200, and
+// returns the recorded response.
+func serve(header http.Header, handlers ...gin.HandlerFunc) *httptest.ResponseRecorder {
+	router := gin.New()
+	handlers = append(handlers, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.Value("userID"), "role": c.GetString("userRole")})
+	})
+	router.GET("/", handlers...)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// withRole stands in for JWTAuthMiddleware.
+func withRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		if role != "" {
+			c.Set("userRole", role)
+		}
+	}
+}
+
+func bearer(token string) http.Header {
+	return http.Header{"Authorization": {"Bearer " + token}}
+}
+
+func TestRequireRole(t *testing.T) {
+	tests := []struct {
+		role string
+		want int
+	}{
+		{RoleAdmin, http.StatusOK},
+		{RoleStaff, http.StatusOK},
+		{RoleSuperAdmin, http.StatusOK},
+		{RoleCustomer, http.StatusForbidden},
+		{"", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		w := serve(nil, withRole(tt.role), RequireRole(RoleStaff, RoleAdmin))
+		if w.Code != tt.want {
+			t.Errorf("role %q: status %d, want %d", tt.role, w.Code, tt.want)
+		}
+	}
+}
+
+func TestJWTAuthMiddlewareSetsRole(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	s := &userService{repo: newFakeUserRepo(
+		&User{ID: 1, Role: RoleAdmin},
+		&User{ID: 2},
+	)}
+
+	admin, err := s.GenerateToken(1, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := serve(bearer(admin), JWTAuthMiddleware(), RequireRole(RoleAdmin))
+	if w.Code != http.StatusOK {
+		t.Errorf("admin token: status %d, want 200", w.Code)
+	}
+
+	// A token without a role claim counts as a customer's
+	customer, err := s.GenerateToken(2, "s2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w = serve(bearer(customer), JWTAuthMiddleware(), RequireRole(RoleAdmin))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("customer token: status %d, want 403", w.Code)
+	}
+}
+
+func TestJWTAuthMiddlewareRejectsBadTokens(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	for name, header := range map[string]http.Header{
+		"missing":    nil,
+		"not bearer": {"Authorization": {"Token abc"}},
+		"garbage":    bearer("abc.def.ghi"),
+	} {
+		if w := serve(header, JWTAuthMiddleware()); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: status %d, want 401", name, w.Code)
+		}
+	}
+}
+
+func TestOptionalJWTAuthMiddleware(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	s := &userService{repo: newFakeUserRepo(&User{ID: 1})}
+	token, err := s.GenerateToken(1, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w := serve(nil, OptionalJWTAuthMiddleware()); w.Code != http.StatusOK {
+		t.Errorf("anonymous: status %d, want 200", w.Code)
+	}
+	w := serve(bearer(token), OptionalJWTAuthMiddleware())
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"user_id":1`) {
+		t.Errorf("signed in: status %d, body %s", w.Code, w.Body)
+	}
+	// A bad token is still rejected so the client knows to refresh
+	if w := serve(bearer("abc.def.ghi"), OptionalJWTAuthMiddleware()); w.Code != http.StatusUnauthorized {
+		t.Errorf("bad token: status %d, want 401", w.Code)
+	}
+}
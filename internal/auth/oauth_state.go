@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	oauthStateCookie = "oauth_state"
+	oauthStateTTL    = 10 * time.Minute
+
+	defaultLoginRedirect = "/auth/success"
+)
+
+var ErrInvalidOAuthState = errors.New("invalid or expired login state")
+
+// oauthState is what Login binds to the browser. It travels in a signed,
+// short-lived cookie so the callback can prove the login started here.
+type oauthState struct {
+	State      string `json:"s"`
+	Verifier   string `json:"v"` // PKCE code verifier
+	Provider   string `json:"p"`
+	Redirect   string `json:"r"`
+	LinkUserID uint   `json:"l,omitempty"` // set when linking to a signed-in user
+	ExpiresAt  int64  `json:"e"`
+}
+
+// oauthStateSecret signs the state cookie. OAUTH_STATE_SECRET lets it differ
+// from the JWT secret; otherwise JWT_SECRET is used.
+func oauthStateSecret() ([]byte, error) {
+	secret := os.Getenv("OAUTH_STATE_SECRET")
+	if secret == "" {
+		secret = os.Getenv("JWT_SECRET")
+	}
+	if secret == "" {
+		return nil, errors.New("OAUTH_STATE_SECRET or JWT_SECRET environment variable is required")
+	}
+	return []byte(secret), nil
+}
+
+func signOAuthState(st oauthState) (string, error) {
+	secret, err := oauthStateSecret()
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(st)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(encoded))
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func verifyOAuthState(value string) (*oauthState, error) {
+	secret, err := oauthStateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, sig, ok := strings.Cut(value, ".")
+	if !ok {
+		return nil, ErrInvalidOAuthState
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(encoded))
+	expected := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(sig), []byte(expected)) {
+		return nil, ErrInvalidOAuthState
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidOAuthState
+	}
+	var st oauthState
+	if err := json.Unmarshal(payload, &st); err != nil {
+		return nil, ErrInvalidOAuthState
+	}
+	if time.Now().Unix() > st.ExpiresAt {
+		return nil, ErrInvalidOAuthState
+	}
+	return &st, nil
+}
+
+// setOAuthStateCookie stores the signed state. The login request is usually a
+// cross-site XHR from the storefront, so in production the cookie has to be
+// SameSite=None; set COOKIE_SECURE=false for plain-http local development.
+func setOAuthStateCookie(ctx *gin.Context, value string, maxAge int) {
+	secure := os.Getenv("COOKIE_SECURE") != "false"
+	sameSite := http.SameSiteNoneMode
+	if !secure {
+		sameSite = http.SameSiteLaxMode
+	}
+
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     oauthStateCookie,
+		Value:    value,
+		Path:     "/api/v1/auth",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: sameSite,
+	})
+}
+
+// consumeOAuthState checks the callback's state against the cookie and clears
+// the cookie so it cannot be replayed.
+func consumeOAuthState(ctx *gin.Context) (*oauthState, error) {
+	cookie, err := ctx.Cookie(oauthStateCookie)
+	setOAuthStateCookie(ctx, "", -1)
+	if err != nil || cookie == "" {
+		return nil, ErrInvalidOAuthState
+	}
+
+	st, err := verifyOAuthState(cookie)
+	if err != nil {
+		return nil, err
+	}
+
+	state := ctx.Query("state")
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(st.State)) != 1 {
+		return nil, ErrInvalidOAuthState
+	}
+	return st, nil
+}
+
+// frontendURL is where users land after logging in.
+func frontendURL() string {
+	if u := os.Getenv("FRONTEND_URL"); u != "" {
+		return strings.TrimRight(u, "/")
+	}
+	return "https://alrizvan.com"
+}
+
+// resolveLoginRedirect validates the redirect a login was started with.
+// Relative paths are resolved against FRONTEND_URL; absolute URLs must point
+// at FRONTEND_URL or one of the origins in ALLOWED_REDIRECT_ORIGINS.
+func resolveLoginRedirect(redirect string) (string, error) {
+	if redirect == "" {
+		redirect = defaultLoginRedirect
+	}
+
+	if strings.HasPrefix(redirect, "/") {
+		// "//host" and "/\host" are protocol-relative in browsers
+		if strings.HasPrefix(redirect, "//") || strings.HasPrefix(redirect, "/\\") {
+			return "", errors.New("invalid redirect")
+		}
+		return frontendURL() + redirect, nil
+	}
+
+	target, err := url.Parse(redirect)
+	if err != nil || (target.Scheme != "https" && target.Scheme != "http") || target.Host == "" {
+		return "", errors.New("invalid redirect")
+	}
+	origin := target.Scheme + "://" + target.Host
+
+	allowed := append([]string{frontendURL()}, strings.Split(os.Getenv("ALLOWED_REDIRECT_ORIGINS"), ",")...)
+	for _, o := range allowed {
+		if strings.EqualFold(strings.TrimRight(strings.TrimSpace(o), "/"), origin) {
+			return redirect, nil
+		}
+	}
+	return "", errors.New("redirect is not an allowed origin")
+}
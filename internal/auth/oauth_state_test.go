@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestOAuthStateRoundTrip(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	want := oauthState{State: "s", Verifier: "v", Redirect: "/r", ExpiresAt: time.Now().Add(time.Minute).Unix()}
+	signed, err := signOAuthState(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := verifyOAuthState(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != want {
+		t.Errorf("verifyOAuthState = %+v, want %+v", *got, want)
+	}
+}
+
+func TestVerifyOAuthStateRejectsTampering(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	signed, err := signOAuthState(oauthState{State: "s", ExpiresAt: time.Now().Add(time.Minute).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := signOAuthState(oauthState{State: "s", ExpiresAt: time.Now().Add(-time.Minute).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, value := range map[string]string{
+		"payload":   flipFirst(signed),
+		"signature": signed[:len(signed)-1] + flipFirst(signed[len(signed)-1:]),
+		"unsigned":  "e30",
+		"expired":   expired,
+	} {
+		if _, err := verifyOAuthState(value); !errors.Is(err, ErrInvalidOAuthState) {
+			t.Errorf("%s: got %v, want ErrInvalidOAuthState", name, err)
+		}
+	}
+
+	// Signed with another secret
+	t.Setenv("OAUTH_STATE_SECRET", "other-secret")
+	if _, err := verifyOAuthState(signed); !errors.Is(err, ErrInvalidOAuthState) {
+		t.Errorf("other secret: got %v, want ErrInvalidOAuthState", err)
+	}
+}
+
+// flipFirst changes the first character of s.
+func flipFirst(s string) string {
+	if s[0] == 'A' {
+		return "B" + s[1:]
+	}
+	return "A" + s[1:]
+}
+
+func TestConsumeOAuthState(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	signed, err := signOAuthState(oauthState{State: "expected", ExpiresAt: time.Now().Add(time.Minute).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, cookie, state string
+		wantErr             bool
+	}{
+		{"matching", signed, "expected", false},
+		{"wrong state", signed, "other", true},
+		{"missing state", signed, "", true},
+		{"no cookie", "", "expected", true},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/callback?state="+tt.state, nil)
+		if tt.cookie != "" {
+			ctx.Request.AddCookie(&http.Cookie{Name: oauthStateCookie, Value: tt.cookie})
+		}
+
+		_, err := consumeOAuthState(ctx)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		// The cookie is cleared whatever the outcome
+		if cleared := w.Result().Cookies(); len(cleared) != 1 || cleared[0].MaxAge >= 0 {
+			t.Errorf("%s: state cookie not cleared: %v", tt.name, cleared)
+		}
+	}
+}
+
+func TestResolveLoginRedirect(t *testing.T) {
+	t.Setenv("FRONTEND_URL", "https://shop.example/")
+	t.Setenv("ALLOWED_REDIRECT_ORIGINS", "https://admin.example, http://localhost:3000")
+
+	tests := []struct {
+		redirect, want string
+		wantErr        bool
+	}{
+		{"", "https://shop.example/auth/success", false},
+		{"/account", "https://shop.example/account", false},
+		{"https://shop.example/cart", "https://shop.example/cart", false},
+		{"https://admin.example/dash", "https://admin.example/dash", false},
+		{"http://localhost:3000/", "http://localhost:3000/", false},
+		{"//evil.example", "", true},
+		{"/\\evil.example", "", true},
+		{"https://evil.example", "", true},
+		{"https://shop.example.evil.example", "", true},
+		{"javascript:alert(1)", "", true},
+	}
+	for _, tt := range tests {
+		got, err := resolveLoginRedirect(tt.redirect)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("resolveLoginRedirect(%q) = %q, %v; want %q, wantErr %v", tt.redirect, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"crypto/rand"
+	"ecommerce/internal/phone"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	otpLength         = 6
+	otpTTL            = 5 * time.Minute
+	otpMaxAttempts    = 5
+	otpResendCooldown = time.Minute
+	otpHourlyLimit    = 5  // Codes per phone number per hour
+	otpIPHourlyLimit  = 10 // Codes per client IP per hour
+)
+
+var (
+	ErrInvalidPhone       = phone.ErrInvalid
+	ErrInvalidOTP         = errors.New("invalid or expired verification code")
+	ErrOTPTooManyAttempts = errors.New("too many attempts, request a new code")
+	ErrOTPCooldown        = errors.New("please wait before requesting another code")
+	ErrOTPRateLimited     = errors.New("too many codes requested, try again later")
+)
+
+// otpGlobalHourlyLimit reads OTP_GLOBAL_HOURLY_LIMIT, the number of codes
+// sent per hour across all phones, which caps the SMS bill. Defaults to 1000.
+func otpGlobalHourlyLimit() int64 {
+	limit, err := strconv.ParseInt(os.Getenv("OTP_GLOBAL_HOURLY_LIMIT"), 10, 64)
+	if err != nil || limit < 1 {
+		return 1000
+	}
+	return limit
+}
+
+// SMSSender delivers text messages to a phone number in E.164 format.
+type SMSSender interface {
+	Send(phone, message string) error
+}
+
+// logSMSSender writes messages to the log instead of sending them. It is the
+// default so development works without an SMS gateway.
+type logSMSSender struct{}
+
+func (logSMSSender) Send(phone, message string) error {
+	log.Printf("📱 SMS to %s: %s", phone, message)
+	return nil
+}
+
+var smsSender SMSSender = logSMSSender{}
+
+// SetSMSSender replaces the sender used for OTP codes.
+func SetSMSSender(sender SMSSender) {
+	smsSender = sender
+}
+
+// generateOTPCode returns a uniformly random numeric code.
+func generateOTPCode() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < otpLength; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", otpLength, n), nil
+}
+
+// hashOTP binds the code to the phone so equal codes hash differently.
+func hashOTP(phone, code string) string {
+	return hashToken(phone + ":" + code)
+}
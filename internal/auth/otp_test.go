@@ -0,0 +1,246 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func (r *fakeUserRepo) CreatePhoneOTP(otp *PhoneOTP) error {
+	otp.ID = uint(len(r.otps) + 1)
+	if otp.CreatedAt.IsZero() {
+		otp.CreatedAt = time.Now()
+	}
+	r.otps = append(r.otps, otp)
+	return nil
+}
+
+func (r *fakeUserRepo) FindLatestPhoneOTP(phone string) (*PhoneOTP, error) {
+	for i := len(r.otps) - 1; i >= 0; i-- {
+		if r.otps[i].Phone == phone {
+			found := *r.otps[i]
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepo) CountPhoneOTPsSince(phone string, since time.Time) (int64, error) {
+	var count int64
+	for _, otp := range r.otps {
+		if otp.Phone == phone && otp.CreatedAt.After(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeUserRepo) CountIPPhoneOTPsSince(ip string, since time.Time) (int64, error) {
+	var count int64
+	for _, otp := range r.otps {
+		if otp.IP == ip && otp.CreatedAt.After(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeUserRepo) CountAllPhoneOTPsSince(since time.Time) (int64, error) {
+	var count int64
+	for _, otp := range r.otps {
+		if otp.CreatedAt.After(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeUserRepo) IncrementOTPAttempts(id uint, maxAttempts int) (bool, error) {
+	otp := r.otps[id-1]
+	if otp.Attempts >= maxAttempts {
+		return false, nil
+	}
+	otp.Attempts++
+	return true, nil
+}
+
+func (r *fakeUserRepo) ConsumePhoneOTP(id uint) (bool, error) {
+	otp := r.otps[id-1]
+	if otp.ConsumedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	otp.ConsumedAt = &now
+	return true, nil
+}
+
+const testIP = "203.0.113.7"
+
+// recordingSMSSender keeps the last code it was asked to send.
+type recordingSMSSender struct {
+	phone, code string
+}
+
+var otpInMessage = regexp.MustCompile(`\b\d{6}\b`)
+
+func (s *recordingSMSSender) Send(phone, message string) error {
+	s.phone = phone
+	s.code = otpInMessage.FindString(message)
+	return nil
+}
+
+func newOTPTestService(t *testing.T) (*userService, *fakeUserRepo, *recordingSMSSender) {
+	t.Helper()
+	sender := &recordingSMSSender{}
+	SetSMSSender(sender)
+	t.Cleanup(func() { SetSMSSender(logSMSSender{}) })
+	repo := newFakeUserRepo()
+	return &userService{repo: repo}, repo, sender
+}
+
+func TestOTPLoginCreatesThenFindsUser(t *testing.T) {
+	s, repo, sender := newOTPTestService(t)
+
+	if err := s.RequestOTP("01712345678", testIP); err != nil {
+		t.Fatal(err)
+	}
+	if sender.phone != "+8801712345678" || sender.code == "" {
+		t.Fatalf("sent %q to %q", sender.code, sender.phone)
+	}
+	if repo.otps[0].CodeHash == sender.code {
+		t.Error("code stored in the clear")
+	}
+
+	user, err := s.VerifyOTP("+880 1712-345678", sender.code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.PhoneVerified || user.Phone != "+8801712345678" || user.Role != RoleCustomer {
+		t.Errorf("new user = %+v", user)
+	}
+	if _, err := s.VerifyOTP("01712345678", sender.code); !errors.Is(err, ErrInvalidOTP) {
+		t.Errorf("reused code: got %v, want ErrInvalidOTP", err)
+	}
+
+	repo.otps[0].CreatedAt = time.Now().Add(-2 * otpResendCooldown)
+	if err := s.RequestOTP("01712345678", testIP); err != nil {
+		t.Fatal(err)
+	}
+	again, err := s.VerifyOTP("01712345678", sender.code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != user.ID {
+		t.Errorf("second login got user %d, want %d", again.ID, user.ID)
+	}
+}
+
+func TestRequestOTPLimits(t *testing.T) {
+	s, repo, _ := newOTPTestService(t)
+
+	if err := s.RequestOTP("01712345678", testIP); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RequestOTP("01712345678", testIP); !errors.Is(err, ErrOTPCooldown) {
+		t.Errorf("resend within cooldown: got %v, want ErrOTPCooldown", err)
+	}
+
+	for len(repo.otps) < otpHourlyLimit {
+		repo.otps[len(repo.otps)-1].CreatedAt = time.Now().Add(-2 * otpResendCooldown)
+		if err := s.RequestOTP("01712345678", testIP); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo.otps[len(repo.otps)-1].CreatedAt = time.Now().Add(-2 * otpResendCooldown)
+	if err := s.RequestOTP("01712345678", testIP); !errors.Is(err, ErrOTPRateLimited) {
+		t.Errorf("over the hourly limit: got %v, want ErrOTPRateLimited", err)
+	}
+
+	if err := s.RequestOTP("12345", testIP); !errors.Is(err, ErrInvalidPhone) {
+		t.Errorf("bad number: got %v, want ErrInvalidPhone", err)
+	}
+}
+
+func TestRequestOTPLimitsPerIP(t *testing.T) {
+	s, repo, sender := newOTPTestService(t)
+
+	// One client texting many numbers
+	for i := 0; i < otpIPHourlyLimit; i++ {
+		if err := s.RequestOTP(fmt.Sprintf("0171234%04d", i), testIP); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.RequestOTP("01812345678", testIP); !errors.Is(err, ErrOTPRateLimited) {
+		t.Errorf("over the IP limit: got %v, want ErrOTPRateLimited", err)
+	}
+	if sender.phone == "+8801812345678" {
+		t.Error("code sent over the IP limit")
+	}
+	if repo.otps[0].IP != testIP {
+		t.Errorf("stored IP = %q, want %q", repo.otps[0].IP, testIP)
+	}
+
+	// Other clients are unaffected
+	if err := s.RequestOTP("01812345678", "198.51.100.1"); err != nil {
+		t.Errorf("another IP: %v", err)
+	}
+}
+
+func TestRequestOTPGlobalLimit(t *testing.T) {
+	t.Setenv("OTP_GLOBAL_HOURLY_LIMIT", "3")
+	s, _, _ := newOTPTestService(t)
+
+	for i := 0; i < 3; i++ {
+		if err := s.RequestOTP(fmt.Sprintf("0171234%04d", i), fmt.Sprintf("203.0.113.%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.RequestOTP("01812345678", "198.51.100.1"); !errors.Is(err, ErrOTPRateLimited) {
+		t.Errorf("over the global limit: got %v, want ErrOTPRateLimited", err)
+	}
+
+	t.Setenv("OTP_GLOBAL_HOURLY_LIMIT", "nonsense")
+	if got := otpGlobalHourlyLimit(); got != 1000 {
+		t.Errorf("limit with a bad setting = %d, want the default", got)
+	}
+}
+
+func TestVerifyOTPLimitsAttempts(t *testing.T) {
+	s, _, sender := newOTPTestService(t)
+	if err := s.RequestOTP("01712345678", testIP); err != nil {
+		t.Fatal(err)
+	}
+	wrong := "000000"
+	if sender.code == wrong {
+		wrong = "111111"
+	}
+
+	for i := 0; i < otpMaxAttempts; i++ {
+		if _, err := s.VerifyOTP("01712345678", wrong); !errors.Is(err, ErrInvalidOTP) {
+			t.Fatalf("attempt %d: got %v, want ErrInvalidOTP", i+1, err)
+		}
+	}
+	// Even the right code is refused once the attempts are used up
+	if _, err := s.VerifyOTP("01712345678", sender.code); !errors.Is(err, ErrOTPTooManyAttempts) {
+		t.Errorf("after %d attempts: got %v, want ErrOTPTooManyAttempts", otpMaxAttempts, err)
+	}
+}
+
+func TestVerifyOTPExpires(t *testing.T) {
+	s, repo, sender := newOTPTestService(t)
+	if _, err := s.VerifyOTP("01712345678", "123456"); !errors.Is(err, ErrInvalidOTP) {
+		t.Errorf("no code sent: got %v, want ErrInvalidOTP", err)
+	}
+
+	if err := s.RequestOTP("01712345678", testIP); err != nil {
+		t.Fatal(err)
+	}
+	repo.otps[0].ExpiresAt = time.Now().Add(-time.Second)
+	if _, err := s.VerifyOTP("01712345678", sender.code); !errors.Is(err, ErrInvalidOTP) {
+		t.Errorf("expired code: got %v, want ErrInvalidOTP", err)
+	}
+}
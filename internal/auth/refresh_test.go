@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newRefreshTestService(t *testing.T) (*userService, *fakeUserRepo) {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "test-secret")
+	SetTokenStore(NewMemoryTokenStore())
+	repo := newFakeUserRepo(&User{ID: 1, Role: RoleCustomer})
+	return &userService{repo: repo}, repo
+}
+
+func TestRefreshTokensRotates(t *testing.T) {
+	s, _ := newRefreshTestService(t)
+	first, err := s.IssueTokens(1, SessionMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := s.RefreshTokens(first.RefreshToken, SessionMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Error("refresh token was not rotated")
+	}
+	if _, err := s.RefreshTokens(second.RefreshToken, SessionMeta{}); err != nil {
+		t.Errorf("rotated token rejected: %v", err)
+	}
+}
+
+func TestRefreshTokensDetectsReuse(t *testing.T) {
+	s, _ := newRefreshTestService(t)
+	first, err := s.IssueTokens(1, SessionMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.RefreshTokens(first.RefreshToken, SessionMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.RefreshTokens(first.RefreshToken, SessionMeta{}); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("reused token: got %v, want ErrRefreshTokenReused", err)
+	}
+	// The whole session is gone, including the token rotated to legitimately
+	if _, err := s.RefreshTokens(second.RefreshToken, SessionMeta{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("token after reuse: got %v, want ErrInvalidRefreshToken", err)
+	}
+	// ...and so is the access token minted with it
+	claims, err := validateToken(second.AccessToken)
+	if err == nil {
+		t.Errorf("access token still valid after reuse: %v", claims)
+	}
+}
+
+func TestRefreshTokensRejectsUnknownAndExpired(t *testing.T) {
+	s, repo := newRefreshTestService(t)
+	if _, err := s.RefreshTokens("unknown", SessionMeta{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("unknown token: got %v, want ErrInvalidRefreshToken", err)
+	}
+
+	pair, err := s.IssueTokens(1, SessionMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo.refreshTokens[0].ExpiresAt = time.Now().Add(-time.Minute)
+	if _, err := s.RefreshTokens(pair.RefreshToken, SessionMeta{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("expired token: got %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestLoginCodeWorksOnce(t *testing.T) {
+	s, repo := newRefreshTestService(t)
+	code, err := s.IssueLoginCode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.loginCodes[0].CodeHash == code {
+		t.Error("login code stored in the clear")
+	}
+
+	tokens, err := s.ExchangeLoginCode(code, SessionMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Errorf("exchange returned %+v", tokens)
+	}
+	if _, err := s.ExchangeLoginCode(code, SessionMeta{}); !errors.Is(err, ErrInvalidLoginCode) {
+		t.Errorf("second exchange: got %v, want ErrInvalidLoginCode", err)
+	}
+}
+
+func TestLoginCodeExpires(t *testing.T) {
+	s, repo := newRefreshTestService(t)
+	code, err := s.IssueLoginCode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo.loginCodes[0].ExpiresAt = time.Now().Add(-time.Second)
+	if _, err := s.ExchangeLoginCode(code, SessionMeta{}); !errors.Is(err, ErrInvalidLoginCode) {
+		t.Errorf("expired code: got %v, want ErrInvalidLoginCode", err)
+	}
+}
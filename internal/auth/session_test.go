@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDescribeDevice(t *testing.T) {
+	tests := map[string]string{
+		"": "Unknown device",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)": "iPhone",
+		"Mozilla/5.0 (Linux; Android 14; Pixel 8)":               "Android",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64)":              "Windows",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0)":           "Mac",
+		"Mozilla/5.0 (X11; Linux x86_64)":                        "Linux",
+		"curl/8.4.0":                                             "Other",
+	}
+	for ua, want := range tests {
+		if got := describeDevice(ua); got != want {
+			t.Errorf("describeDevice(%q) = %q, want %q", ua, got, want)
+		}
+	}
+}
+
+func TestIssueTokensEnforcesSessionLimit(t *testing.T) {
+	s, repo := newRefreshTestService(t)
+	t.Setenv("SESSION_LIMIT", "2")
+
+	oldest, err := s.IssueTokens(1, SessionMeta{UserAgent: "iPhone"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := s.IssueTokens(1, SessionMeta{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sessions, _ := repo.GetActiveSessions(1)
+	if len(sessions) != 2 {
+		t.Fatalf("%d active sessions, want 2", len(sessions))
+	}
+	if _, err := validateToken(oldest.AccessToken); err == nil {
+		t.Error("evicted session's access token still valid")
+	}
+	if _, err := s.RefreshTokens(oldest.RefreshToken, SessionMeta{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("evicted session's refresh token: got %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestRevokeSessionChecksOwner(t *testing.T) {
+	s, repo := newRefreshTestService(t)
+	repo.users[2] = &User{ID: 2}
+	pair, err := s.IssueTokens(1, SessionMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionID := repo.sessions[0].ID
+
+	if err := s.RevokeSession(2, sessionID); err == nil {
+		t.Error("another user's session was revoked")
+	}
+	if _, err := validateToken(pair.AccessToken); err != nil {
+		t.Fatalf("session ended by another user: %v", err)
+	}
+
+	if err := s.RevokeSession(1, sessionID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := validateToken(pair.AccessToken); err == nil {
+		t.Error("access token still valid after its session was revoked")
+	}
+}
+
+func TestLogoutEndsOnlyCurrentSession(t *testing.T) {
+	s, repo := newRefreshTestService(t)
+	phone, err := s.IssueTokens(1, SessionMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	laptop, err := s.IssueTokens(1, SessionMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Logout(1, repo.sessions[0].ID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := validateToken(phone.AccessToken); err == nil {
+		t.Error("logged out session still valid")
+	}
+	if _, err := s.RefreshTokens(laptop.RefreshToken, SessionMeta{}); err != nil {
+		t.Errorf("other session signed out too: %v", err)
+	}
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	s, _ := newRefreshTestService(t)
+	var pairs []*TokenPair
+	for i := 0; i < 3; i++ {
+		pair, err := s.IssueTokens(1, SessionMeta{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pairs = append(pairs, pair)
+	}
+
+	if err := s.RevokeAllSessions(1); err != nil {
+		t.Fatal(err)
+	}
+	for i, pair := range pairs {
+		if _, err := validateToken(pair.AccessToken); err == nil {
+			t.Errorf("session %d: access token still valid", i)
+		}
+		if _, err := s.RefreshTokens(pair.RefreshToken, SessionMeta{}); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("session %d: refresh got %v, want ErrInvalidRefreshToken", i, err)
+		}
+	}
+}
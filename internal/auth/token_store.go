@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TokenStore keeps track of issued access tokens and which of them have been
+// revoked. Entries only matter until the JWT's own exp, so stores are free to
+// forget them after that.
+type TokenStore interface {
+	// Track records a freshly issued token for userID's session.
+	Track(userID uint, sessionID string, tokenID string, expiresAt time.Time) error
+	// Revoke marks a token as revoked. expiresAt is only used when the token
+	// was never tracked, so the entry still expires eventually.
+	Revoke(tokenID string, expiresAt time.Time) error
+	// RevokeUser revokes every live token issued to userID.
+	RevokeUser(userID uint) error
+	// RevokeSession revokes every live token issued for sessionID.
+	RevokeSession(sessionID string) error
+	IsRevoked(tokenID string) (bool, error)
+	// PurgeExpired drops entries whose tokens have expired.
+	PurgeExpired() error
+}
+
+// AuthToken is the database row behind the Postgres token store.
+type AuthToken struct {
+	TokenID   string     `gorm:"primaryKey"`
+	UserID    uint       `gorm:"not null;index"`
+	SessionID string     `gorm:"index"`
+	ExpiresAt time.Time  `gorm:"not null;index"`
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+type postgresTokenStore struct {
+	db *gorm.DB
+}
+
+func NewPostgresTokenStore(db *gorm.DB) TokenStore {
+	return &postgresTokenStore{db: db}
+}
+
+func (s *postgresTokenStore) Track(userID uint, sessionID string, tokenID string, expiresAt time.Time) error {
+	return s.db.Create(&AuthToken{
+		TokenID:   tokenID,
+		UserID:    userID,
+		SessionID: sessionID,
+		ExpiresAt: expiresAt,
+	}).Error
+}
+
+func (s *postgresTokenStore) Revoke(tokenID string, expiresAt time.Time) error {
+	now := time.Now()
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"revoked_at": now}),
+	}).Create(&AuthToken{
+		TokenID:   tokenID,
+		ExpiresAt: expiresAt,
+		RevokedAt: &now,
+	}).Error
+}
+
+func (s *postgresTokenStore) RevokeUser(userID uint) error {
+	return s.db.Model(&AuthToken{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (s *postgresTokenStore) RevokeSession(sessionID string) error {
+	return s.db.Model(&AuthToken{}).
+		Where("session_id = ? AND revoked_at IS NULL AND expires_at > ?", sessionID, time.Now()).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (s *postgresTokenStore) IsRevoked(tokenID string) (bool, error) {
+	var count int64
+	err := s.db.Model(&AuthToken{}).
+		Where("token_id = ? AND revoked_at IS NOT NULL", tokenID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (s *postgresTokenStore) PurgeExpired() error {
+	return s.db.Where("expires_at < ?", time.Now()).Delete(&AuthToken{}).Error
+}
+
+// memoryTokenStore is an in-process TokenStore for tests and local runs. It
+// does not survive restarts and is not shared between replicas.
+type memoryTokenStore struct {
+	mu     sync.RWMutex
+	tokens map[string]*memoryToken
+}
+
+type memoryToken struct {
+	userID    uint
+	sessionID string
+	expiresAt time.Time
+	revoked   bool
+}
+
+func NewMemoryTokenStore() TokenStore {
+	return &memoryTokenStore{tokens: make(map[string]*memoryToken)}
+}
+
+func (s *memoryTokenStore) Track(userID uint, sessionID string, tokenID string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[tokenID] = &memoryToken{userID: userID, sessionID: sessionID, expiresAt: expiresAt}
+	return nil
+}
+
+func (s *memoryTokenStore) Revoke(tokenID string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if token, ok := s.tokens[tokenID]; ok {
+		token.revoked = true
+		return nil
+	}
+	s.tokens[tokenID] = &memoryToken{expiresAt: expiresAt, revoked: true}
+	return nil
+}
+
+func (s *memoryTokenStore) RevokeUser(userID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, token := range s.tokens {
+		if token.userID == userID {
+			token.revoked = true
+		}
+	}
+	return nil
+}
+
+func (s *memoryTokenStore) RevokeSession(sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, token := range s.tokens {
+		if token.sessionID == sessionID {
+			token.revoked = true
+		}
+	}
+	return nil
+}
+
+func (s *memoryTokenStore) IsRevoked(tokenID string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	token, ok := s.tokens[tokenID]
+	return ok && token.revoked, nil
+}
+
+func (s *memoryTokenStore) PurgeExpired() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for id, token := range s.tokens {
+		if token.expiresAt.Before(now) {
+			delete(s.tokens, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMemoryTokenStoreRevoke(t *testing.T) {
+	store := NewMemoryTokenStore()
+	expiresAt := time.Now().Add(time.Hour)
+	store.Track(1, "s1", "t1", expiresAt)
+	store.Track(1, "s1", "t2", expiresAt)
+
+	if revoked, _ := store.IsRevoked("t1"); revoked {
+		t.Fatal("fresh token reported as revoked")
+	}
+	if err := store.Revoke("t1", expiresAt); err != nil {
+		t.Fatal(err)
+	}
+	if revoked, _ := store.IsRevoked("t1"); !revoked {
+		t.Error("revoked token not reported as revoked")
+	}
+	if revoked, _ := store.IsRevoked("t2"); revoked {
+		t.Error("revoking one token revoked another")
+	}
+
+	// A token that was never tracked, e.g. issued before a restart
+	store.Revoke("untracked", expiresAt)
+	if revoked, _ := store.IsRevoked("untracked"); !revoked {
+		t.Error("untracked token not revoked")
+	}
+	if revoked, _ := store.IsRevoked("unknown"); revoked {
+		t.Error("unknown token reported as revoked")
+	}
+}
+
+func TestMemoryTokenStoreRevokeUser(t *testing.T) {
+	store := NewMemoryTokenStore()
+	expiresAt := time.Now().Add(time.Hour)
+	store.Track(1, "s1", "u1a", expiresAt)
+	store.Track(1, "s2", "u1b", expiresAt)
+	store.Track(2, "s3", "u2", expiresAt)
+
+	store.RevokeUser(1)
+	for token, want := range map[string]bool{"u1a": true, "u1b": true, "u2": false} {
+		if revoked, _ := store.IsRevoked(token); revoked != want {
+			t.Errorf("IsRevoked(%s) = %v, want %v", token, revoked, want)
+		}
+	}
+}
+
+func TestMemoryTokenStoreRevokeSession(t *testing.T) {
+	store := NewMemoryTokenStore()
+	expiresAt := time.Now().Add(time.Hour)
+	store.Track(1, "s1", "phone", expiresAt)
+	store.Track(1, "s2", "laptop", expiresAt)
+
+	store.RevokeSession("s1")
+	for token, want := range map[string]bool{"phone": true, "laptop": false} {
+		if revoked, _ := store.IsRevoked(token); revoked != want {
+			t.Errorf("IsRevoked(%s) = %v, want %v", token, revoked, want)
+		}
+	}
+}
+
+func TestMemoryTokenStorePurgeExpired(t *testing.T) {
+	store := NewMemoryTokenStore().(*memoryTokenStore)
+	store.Track(1, "s1", "expired", time.Now().Add(-time.Minute))
+	store.Track(1, "s1", "live", time.Now().Add(time.Hour))
+	store.Revoke("expired", time.Time{})
+
+	if err := store.PurgeExpired(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.tokens["expired"]; ok {
+		t.Error("expired token was not purged")
+	}
+	if _, ok := store.tokens["live"]; !ok {
+		t.Error("live token was purged")
+	}
+}
+
+// failingTokenStore fails every revocation check.
+type failingTokenStore struct {
+	TokenStore
+}
+
+func (failingTokenStore) IsRevoked(tokenID string) (bool, error) {
+	return false, errors.New("store unavailable")
+}
+
+func TestIsTokenDeletedFailsClosed(t *testing.T) {
+	SetTokenStore(failingTokenStore{})
+	defer SetTokenStore(NewMemoryTokenStore())
+
+	if !IsTokenDeleted("t1") {
+		t.Error("token accepted while the store was unavailable")
+	}
+}
+
+func TestLogoutRevokesToken(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	SetTokenStore(NewMemoryTokenStore())
+	s := &userService{repo: newFakeUserRepo(&User{ID: 1})}
+
+	// A token from before sessions existed is revoked on its own
+	token, err := s.GenerateToken(1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := validateToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Logout(1, "", claims["jti"].(string)); err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(bearer(token), JWTAuthMiddleware()); w.Code != http.StatusUnauthorized {
+		t.Errorf("token after logout: status %d, want 401", w.Code)
+	}
+}
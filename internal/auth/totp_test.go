@@ -0,0 +1,285 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (r *fakeUserRepo) SetTOTPSecret(userID uint, secret string) error {
+	if !r.users[userID].TOTPEnabled {
+		r.users[userID].TOTPSecret = secret
+	}
+	return nil
+}
+
+func (r *fakeUserRepo) EnableTOTP(userID uint) error {
+	r.users[userID].TOTPEnabled = true
+	return nil
+}
+
+func (r *fakeUserRepo) DisableTOTP(userID uint) error {
+	user := r.users[userID]
+	user.TOTPEnabled, user.TOTPSecret, user.TOTPLastStep = false, "", 0
+	r.recoveryCodes = nil
+	return nil
+}
+
+func (r *fakeUserRepo) UseTOTPStep(userID uint, step int64) (bool, error) {
+	user := r.users[userID]
+	if step <= user.TOTPLastStep {
+		return false, nil
+	}
+	user.TOTPLastStep = step
+	return true, nil
+}
+
+func (r *fakeUserRepo) BeginMFAAttempt(userID uint, maxAttempts int, lockout time.Duration) (bool, error) {
+	user := r.users[userID]
+	now := time.Now()
+	if user.MFALockedUntil != nil && user.MFALockedUntil.After(now) {
+		return false, nil
+	}
+	user.MFAFailedAttempts++
+	user.MFALockedUntil = nil
+	if user.MFAFailedAttempts >= maxAttempts {
+		until := now.Add(lockout)
+		user.MFALockedUntil = &until
+	}
+	return true, nil
+}
+
+func (r *fakeUserRepo) ResetMFAAttempts(userID uint) error {
+	r.users[userID].MFAFailedAttempts = 0
+	r.users[userID].MFALockedUntil = nil
+	return nil
+}
+
+func (r *fakeUserRepo) ReplaceRecoveryCodes(userID uint, hashes []string) error {
+	r.recoveryCodes = nil
+	for _, hash := range hashes {
+		r.recoveryCodes = append(r.recoveryCodes, &MFARecoveryCode{UserID: userID, CodeHash: hash})
+	}
+	return nil
+}
+
+func (r *fakeUserRepo) UseRecoveryCode(userID uint, hash string) (bool, error) {
+	for _, code := range r.recoveryCodes {
+		if code.UserID == userID && code.CodeHash == hash && code.UsedAt == nil {
+			now := time.Now()
+			code.UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeUserRepo) CountRecoveryCodes(userID uint) (int64, error) {
+	var count int64
+	for _, code := range r.recoveryCodes {
+		if code.UserID == userID && code.UsedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeUserRepo) SetSessionMFA(sessionID string, at time.Time) error {
+	for _, session := range r.sessions {
+		if session.ID == sessionID {
+			session.MFAAt = &at
+		}
+	}
+	return nil
+}
+
+// rfcSecret is the RFC 6238 SHA-1 test key "12345678901234567890".
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeRFC6238Vectors(t *testing.T) {
+	// The RFC lists 8-digit codes; ours are their last 6 digits
+	tests := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, want := range tests {
+		got, err := totpCode(rfcSecret, unix/totpPeriod)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("code at %d = %s, want %s", unix, got, want)
+		}
+	}
+}
+
+func TestMatchTOTPAllowsOneStepOfSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := now.Unix() / totpPeriod
+	for offset, want := range map[int64]bool{-2: false, -1: true, 0: true, 1: true, 2: false} {
+		code, _ := totpCode(rfcSecret, step+offset)
+		if got := matchTOTP(rfcSecret, code, now) != 0; got != want {
+			t.Errorf("offset %d: matched = %v, want %v", offset, got, want)
+		}
+	}
+	if matchTOTP(rfcSecret, "12345", now) != 0 {
+		t.Error("short code matched")
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	format := regexp.MustCompile(`^[a-z2-7]{5}-[a-z2-7]{5}$`)
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if !format.MatchString(code) || seen[code] {
+			t.Errorf("bad or repeated recovery code %q", code)
+		}
+		seen[code] = true
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Errorf("%d codes, want %d", len(codes), recoveryCodeCount)
+	}
+	if hashRecoveryCode(1, " ABCDE-fghij") != hashRecoveryCode(1, "abcdefghij") {
+		t.Error("recovery codes are not normalized before hashing")
+	}
+}
+
+// enrolledMFAService returns a service whose user 1 has two-factor enabled
+// and a live session, plus the user's recovery codes.
+func enrolledMFAService(t *testing.T) (*userService, *fakeUserRepo, string, []string) {
+	t.Helper()
+	s, repo := newRefreshTestService(t)
+	if _, err := s.IssueTokens(1, SessionMeta{}); err != nil {
+		t.Fatal(err)
+	}
+	sessionID := repo.sessions[0].ID
+
+	enrollment, err := s.EnrollTOTP(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _ := totpCode(enrollment.Secret, time.Now().Unix()/totpPeriod-1)
+	recovery, err := s.ConfirmTOTP(1, sessionID, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, repo, sessionID, recovery
+}
+
+func TestVerifyMFA(t *testing.T) {
+	s, repo, sessionID, _ := enrolledMFAService(t)
+	if _, err := s.EnrollTOTP(1); !errors.Is(err, ErrMFAAlreadyEnrolled) {
+		t.Errorf("second enrollment: got %v, want ErrMFAAlreadyEnrolled", err)
+	}
+
+	code, _ := totpCode(repo.users[1].TOTPSecret, time.Now().Unix()/totpPeriod)
+	token, err := s.VerifyMFA(1, sessionID, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := validateToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := claims["mfa"]; !ok {
+		t.Error("verified token has no mfa claim")
+	}
+
+	// A code works once, even within its time step
+	if _, err := s.VerifyMFA(1, sessionID, code); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("replayed code: got %v, want ErrInvalidMFACode", err)
+	}
+}
+
+func TestVerifyMFAWithRecoveryCode(t *testing.T) {
+	s, _, sessionID, recovery := enrolledMFAService(t)
+
+	if _, err := s.VerifyMFA(1, sessionID, recovery[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.VerifyMFA(1, sessionID, recovery[0]); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("reused recovery code: got %v, want ErrInvalidMFACode", err)
+	}
+	status, err := s.GetMFAStatus(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.RecoveryCodesLeft != recoveryCodeCount-1 {
+		t.Errorf("%d recovery codes left, want %d", status.RecoveryCodesLeft, recoveryCodeCount-1)
+	}
+}
+
+func TestVerifyMFALocksAfterFailures(t *testing.T) {
+	s, repo, sessionID, _ := enrolledMFAService(t)
+
+	for i := 0; i < mfaMaxAttempts; i++ {
+		if _, err := s.VerifyMFA(1, sessionID, "000000"); !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("attempt %d: got %v, want ErrInvalidMFACode", i+1, err)
+		}
+	}
+	code, _ := totpCode(repo.users[1].TOTPSecret, time.Now().Unix()/totpPeriod)
+	if _, err := s.VerifyMFA(1, sessionID, code); !errors.Is(err, ErrMFALocked) {
+		t.Fatalf("correct code while locked: got %v, want ErrMFALocked", err)
+	}
+
+	past := time.Now().Add(-time.Second)
+	repo.users[1].MFALockedUntil = &past
+	if _, err := s.VerifyMFA(1, sessionID, code); err != nil {
+		t.Fatalf("after lockout: %v", err)
+	}
+	if repo.users[1].MFAFailedAttempts != 0 {
+		t.Errorf("failed attempts = %d after a good code, want 0", repo.users[1].MFAFailedAttempts)
+	}
+}
+
+func TestDisableTOTPRequiresCode(t *testing.T) {
+	s, repo, _, recovery := enrolledMFAService(t)
+
+	if err := s.DisableTOTP(1, "000000"); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("wrong code: got %v, want ErrInvalidMFACode", err)
+	}
+	if err := s.DisableTOTP(1, recovery[1]); err != nil {
+		t.Fatal(err)
+	}
+	if repo.users[1].TOTPEnabled {
+		t.Error("two-factor still enabled")
+	}
+}
+
+func TestRequireMFA(t *testing.T) {
+	tests := []struct {
+		name  string
+		role  string
+		mfaAt time.Time
+		want  int
+	}{
+		{"customer", RoleCustomer, time.Time{}, http.StatusOK},
+		{"admin without check", RoleAdmin, time.Time{}, http.StatusForbidden},
+		{"admin with recent check", RoleAdmin, time.Now().Add(-time.Minute), http.StatusOK},
+		{"admin with stale check", RoleAdmin, time.Now().Add(-mfaStepUpTTL - time.Minute), http.StatusForbidden},
+		{"staff without check", RoleStaff, time.Time{}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		mfaAt := tt.mfaAt
+		setMFA := func(c *gin.Context) {
+			if !mfaAt.IsZero() {
+				c.Set("mfaAt", mfaAt.Unix())
+			}
+		}
+		if w := serve(nil, withRole(tt.role), setMFA, RequireMFA()); w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+}
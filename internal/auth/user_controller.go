@@ -2,17 +2,16 @@ package auth
 
 import (
 	"crypto/rand"
-	"ecommerce/config"
-	"ecommerce/database"
+	"ecommerce/internal/audit"
 	"encoding/base64"
-	"encoding/json"
-	"fmt"
-	"io"
+	"errors"
 	"net/http"
-	"os"
+	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
 	//"log"
 	//"strings"
 	//"io"
@@ -21,36 +20,108 @@ import (
 
 type UserController struct {
 	userService UserService
+	providers   map[string]IdentityProvider
 }
 
-func NewUserController(userService UserService) *UserController {
+func NewUserController(userService UserService, providers map[string]IdentityProvider) *UserController {
 	return &UserController{
 		userService: userService,
+		providers:   providers,
 	}
 }
 
-func (c *UserController) GoogleLogin(ctx *gin.Context) {
-	state := c.generateRandomState()
+// Login starts an OAuth login with the provider named in the path.
+func (c *UserController) Login(ctx *gin.Context) {
+	c.startOAuth(ctx, 0)
+}
+
+// LinkProvider starts an OAuth flow that links the provider to the signed-in
+// user instead of logging in.
+func (c *UserController) LinkProvider(ctx *gin.Context) {
+	c.startOAuth(ctx, ctx.GetUint("userID"))
+}
+
+func (c *UserController) startOAuth(ctx *gin.Context, linkUserID uint) {
+	provider, ok := c.providers[ctx.Param("provider")]
+	if !ok {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Unknown login provider",
+		})
+		return
+	}
+
+	redirect, err := resolveLoginRedirect(ctx.Query("redirect"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
 
-	url := config.GoogleOAuthConfig.AuthCodeURL(state)
+	state := c.generateRandomState()
+	verifier := oauth2.GenerateVerifier()
+
+	// ✅ Bind state, PKCE verifier and redirect to this browser
+	cookie, err := signOAuthState(oauthState{
+		State:      state,
+		Verifier:   verifier,
+		Provider:   provider.Name(),
+		Redirect:   redirect,
+		LinkUserID: linkUserID,
+		ExpiresAt:  time.Now().Add(oauthStateTTL).Unix(),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to start login: " + err.Error(),
+		})
+		return
+	}
+	setOAuthStateCookie(ctx, cookie, int(oauthStateTTL.Seconds()))
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"auth_url": url,
-		"message":  "Redirect to this url to login with Google",
+		"auth_url": provider.AuthCodeURL(state, verifier),
+		"message":  "Redirect to this url to login with " + provider.Name(),
 		"state":    state,
 	})
 }
 
-func (c *UserController) GoogleCallBack(ctx *gin.Context) {
+func (c *UserController) Callback(ctx *gin.Context) {
+	provider, ok := c.providers[ctx.Param("provider")]
+	if !ok {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Unknown login provider",
+		})
+		return
+	}
+
+	// ✅ Reject callbacks that this browser did not start (login CSRF)
+	loginState, err := consumeOAuthState(ctx)
+	if err == nil && loginState.Provider != provider.Name() {
+		err = ErrInvalidOAuthState
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if oauthErr := ctx.Query("error"); oauthErr != "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Login failed: " + oauthErr,
+		})
+		return
+	}
+
 	code := ctx.Query("code")
 	if code == "" {
 		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Authorization code not provided by google",
+			"error": "Authorization code not provided by " + provider.Name(),
 		})
 		return
 	}
 	//exchange code for token
-	token, err := config.GoogleOAuthConfig.Exchange(ctx, code)
+	token, err := provider.Exchange(ctx, code, loginState.Verifier)
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
@@ -60,34 +131,64 @@ func (c *UserController) GoogleCallBack(ctx *gin.Context) {
 	}
 	// get user info by using token
 
-	userInfo, err := c.getUserInfoFromGoogle(token.AccessToken)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get user info from Google: " + err.Error(),
-		})
-		return
-	}
-	user, err := c.saveOrUpdateUser(userInfo)
+	identity, err := provider.UserInfo(ctx, token)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to save user to database: " + err.Error(),
+			"error": "Failed to get user info from " + provider.Name() + ": " + err.Error(),
 		})
 		return
 	}
-	jwtToken, err :=c.userService.GenerateToken(user.ID)
+
+	redirectURL, err := url.Parse(loginState.Redirect)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to generate authentication token: " + err.Error(),
+			"error": "Invalid login redirect",
 		})
 		return
 	}
-	frontendURL := os.Getenv("FRONTEND_URL")
-	if frontendURL == "" {
-		frontendURL = "https://alrizvan.com"
+	query := redirectURL.Query()
+
+	if loginState.LinkUserID != 0 {
+		if _, err := c.userService.LinkIdentity(loginState.LinkUserID, identity); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, ErrIdentityLinked) {
+				status = http.StatusConflict
+			}
+			ctx.JSON(status, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		query.Set("linked", provider.Name())
+	} else {
+		user, err := c.userService.LoginWithIdentity(identity)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to save user to database: " + err.Error(),
+			})
+			return
+		}
+		// ✅ Only a one-time code goes in the URL; the frontend trades it for
+		// tokens at POST /auth/exchange
+		code, err := c.userService.IssueLoginCode(user.ID)
+		if errors.Is(err, ErrUserBlocked) {
+			ctx.JSON(http.StatusForbidden, gin.H{
+				"error":   err.Error(),
+				"blocked": true,
+			})
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to generate login code: " + err.Error(),
+			})
+			return
+		}
+		query.Set("code", code)
 	}
+	redirectURL.RawQuery = query.Encode()
 
-	redirectURL := fmt.Sprintf("%s/auth/success?token=%s", frontendURL, jwtToken)
-	ctx.Redirect(http.StatusTemporaryRedirect, redirectURL)
+	ctx.Redirect(http.StatusTemporaryRedirect, redirectURL.String())
 }
 
 func (c *UserController) generateRandomState() string {
@@ -96,70 +197,43 @@ func (c *UserController) generateRandomState() string {
 	return base64.URLEncoding.EncodeToString(bytes)
 }
 
-func (c *UserController) getUserInfoFromGoogle(accessToken string) (*GoogleUserInfo, error) {
-	url := fmt.Sprintf("https://www.googleapis.com/oauth2/v2/userinfo?access_token=%s", accessToken)
-
-	resp, err := http.Get(url)
-
+func (c *UserController) ListIdentities(ctx *gin.Context) {
+	identities, err := c.userService.ListIdentities(ctx.GetUint("userID"))
 	if err != nil {
-		return nil, err
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get linked logins",
+		})
+		return
 	}
 
-	defer resp.Body.Close()
+	ctx.JSON(http.StatusOK, gin.H{
+		"identities": identities,
+	})
+}
 
-	body, err := io.ReadAll(resp.Body)
+func (c *UserController) UnlinkIdentity(ctx *gin.Context) {
+	identityID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
 	if err != nil {
-		return nil, err
-	}
-
-	var userInfo GoogleUserInfo
-
-	if err := json.Unmarshal(body, &userInfo); err != nil {
-		return nil, err
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid identity ID",
+		})
+		return
 	}
 
-	return &userInfo, nil
-}
-
-func (c *UserController) saveOrUpdateUser(googleUser *GoogleUserInfo) (*User, error) {
-	var user User
-
-	// Try to find existing user by Google ID
-	result := database.DB.Where("google_id = ?", googleUser.ID).First(&user)
-
-	if result.Error != nil {
-		// User doesn't exist, create new one
-		user = User{
-			GoogleID: googleUser.ID,
-			Email:    googleUser.Email,
-			Name:     googleUser.Name,
-		}
-
-		if err := database.DB.Create(&user).Error; err != nil {
-			return nil, fmt.Errorf("failed to create user: %v", err)
-		}
-
-		fmt.Printf("✅ New user created: %s (%s)\n", user.Name, user.Email)
-	} else {
-		// User exists, check if any data has changed before updating
-		needsUpdate := user.Email != googleUser.Email ||
-			user.Name != googleUser.Name
-
-		if needsUpdate {
-			user.Email = googleUser.Email
-			user.Name = googleUser.Name
-
-			if err := database.DB.Save(&user).Error; err != nil {
-				return nil, fmt.Errorf("failed to update user: %v", err)
-			}
-
-			fmt.Printf("✅ User updated: %s (%s)\n", user.Name, user.Email)
-		} else {
-			fmt.Printf("✅ User login: %s (%s) - no updates needed\n", user.Name, user.Email)
+	if err := c.userService.UnlinkIdentity(ctx.GetUint("userID"), uint(identityID)); err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "identity not found" {
+			status = http.StatusNotFound
 		}
+		ctx.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
 	}
 
-	return &user, nil
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Login method unlinked successfully",
+	})
 }
 
 func (c *UserController) GetProfile(ctx *gin.Context) {
@@ -352,6 +426,29 @@ func (c *UserController) DeleteAddress(ctx *gin.Context) {
 	})
 }
 
+func (c *UserController) SetDefaultAddress(ctx *gin.Context) {
+	addressID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid address ID",
+		})
+		return
+	}
+
+	address, err := c.userService.SetDefaultAddress(ctx.GetUint("userID"), uint(addressID))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Default address updated",
+		"address": address,
+	})
+}
+
 // Add this method to your user_controller.go
 
 func (c *UserController) Logout(ctx *gin.Context) {
@@ -364,8 +461,8 @@ func (c *UserController) Logout(ctx *gin.Context) {
 		return
 	}
 
-	// Delete the token
-	err := c.userService.Logout(tokenID.(string))
+	// Delete the token and end its session
+	err := c.userService.Logout(ctx.GetUint("userID"), ctx.GetString("sessionID"), tokenID.(string))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
@@ -374,28 +471,482 @@ func (c *UserController) Logout(ctx *gin.Context) {
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"message": "Successfully logged out (token deleted)",
+		"message": "Successfully logged out (session ended)",
 	})
 }
 
-func GetVisitorCountByCity(ctx *gin.Context) {
-	db := database.DB
-	city := ctx.Query("city")
-	date := ctx.Query("date")
-	from := ctx.Query("from")
-	to := ctx.Query("to")
+func (c *UserController) GrantRole(ctx *gin.Context) {
+	targetID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
 
-	var count int64
-	if date != "" {
-		db.Raw("SELECT COUNT(*) FROM visitor_logs WHERE city = ? AND DATE(visited_at) = ?", city, date).Scan(&count)
-	} else if from != "" && to != "" {
-		db.Raw("SELECT COUNT(*) FROM visitor_logs WHERE city = ? AND visited_at BETWEEN ? AND ?", city, from, to).Scan(&count)
-	} else {
-		db.Raw("SELECT COUNT(*) FROM visitor_logs WHERE city = ?", city).Scan(&count)
+	var req UpdateRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	user, err := c.userService.GrantRole(audit.ActorFromContext(ctx), uint(targetID), req.Role)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrForbidden) {
+			status = http.StatusForbidden
+		}
+		ctx.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Role granted successfully",
+		"user":    user,
+	})
+}
+
+func (c *UserController) Impersonate(ctx *gin.Context) {
+	targetID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+
+	var req ImpersonateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	token, err := c.userService.Impersonate(audit.ActorFromContext(ctx), uint(targetID), req.Reason)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrCannotImpersonate) {
+			status = http.StatusForbidden
+		}
+		ctx.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, token)
+}
+
+func (c *UserController) RevokeRole(ctx *gin.Context) {
+	targetID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+
+	user, err := c.userService.RevokeRole(audit.ActorFromContext(ctx), uint(targetID))
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrForbidden) {
+			status = http.StatusForbidden
+		}
+		ctx.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Role revoked successfully",
+		"user":    user,
+	})
+}
+
+func (c *UserController) BlockUser(ctx *gin.Context) {
+	targetID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+
+	var req BlockUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	user, err := c.userService.BlockUser(audit.ActorFromContext(ctx), uint(targetID), req.Reason)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrForbidden) {
+			status = http.StatusForbidden
+		}
+		ctx.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "User blocked successfully",
+		"user":    user,
+	})
+}
+
+func (c *UserController) UnblockUser(ctx *gin.Context) {
+	targetID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+
+	user, err := c.userService.UnblockUser(audit.ActorFromContext(ctx), uint(targetID))
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrForbidden) {
+			status = http.StatusForbidden
+		}
+		ctx.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "User unblocked successfully",
+		"user":    user,
+	})
+}
+
+func (c *UserController) RefreshToken(ctx *gin.Context) {
+	var req RefreshTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	tokens, err := c.userService.RefreshTokens(req.RefreshToken, sessionMeta(ctx))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrInvalidRefreshToken), errors.Is(err, ErrRefreshTokenReused):
+			status = http.StatusUnauthorized
+		case errors.Is(err, ErrUserBlocked):
+			status = http.StatusForbidden
+		}
+		ctx.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+// ExchangeLoginCode trades the code a social login redirected back with for
+// an access and refresh token.
+func (c *UserController) ExchangeLoginCode(ctx *gin.Context) {
+	var req LoginCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	tokens, err := c.userService.ExchangeLoginCode(req.Code, sessionMeta(ctx))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrInvalidLoginCode):
+			status = http.StatusUnauthorized
+		case errors.Is(err, ErrUserBlocked):
+			status = http.StatusForbidden
+		}
+		ctx.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+func (c *UserController) GetMFAStatus(ctx *gin.Context) {
+	status, err := c.userService.GetMFAStatus(ctx.GetUint("userID"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, status)
+}
+
+// EnrollTOTP returns a new authenticator secret and its otpauth URI.
+func (c *UserController) EnrollTOTP(ctx *gin.Context) {
+	enrollment, err := c.userService.EnrollTOTP(ctx.GetUint("userID"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrMFAAlreadyEnrolled) {
+			status = http.StatusConflict
+		}
+		ctx.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, enrollment)
+}
+
+func (c *UserController) ConfirmTOTP(ctx *gin.Context) {
+	var req MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	codes, err := c.userService.ConfirmTOTP(ctx.GetUint("userID"), ctx.GetString("sessionID"), req.Code)
+	if err != nil {
+		ctx.JSON(mfaErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled. Store these recovery codes somewhere safe.",
+		"recovery_codes": codes,
+	})
+}
+
+// VerifyMFA is the step-up check. The returned access token carries the mfa
+// claim that RequireMFA looks for.
+func (c *UserController) VerifyMFA(ctx *gin.Context) {
+	var req MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	token, err := c.userService.VerifyMFA(ctx.GetUint("userID"), ctx.GetString("sessionID"), req.Code)
+	if err != nil {
+		ctx.JSON(mfaErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int64(accessTokenTTL.Seconds()),
+	})
+}
+
+func (c *UserController) RegenerateRecoveryCodes(ctx *gin.Context) {
+	var req MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	codes, err := c.userService.RegenerateRecoveryCodes(ctx.GetUint("userID"), req.Code)
+	if err != nil {
+		ctx.JSON(mfaErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"recovery_codes": codes,
+	})
+}
+
+func (c *UserController) DisableTOTP(ctx *gin.Context) {
+	var req MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
 	}
 
-	ctx.JSON(200, gin.H{
-		"city":  city,
-		"count": count,
+	if err := c.userService.DisableTOTP(ctx.GetUint("userID"), req.Code); err != nil {
+		ctx.JSON(mfaErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Two-factor authentication disabled",
 	})
 }
+
+func mfaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidMFACode):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrMFANotEnrolled), errors.Is(err, ErrMFAAlreadyEnrolled):
+		return http.StatusConflict
+	case errors.Is(err, ErrMFALocked):
+		return http.StatusTooManyRequests
+	}
+	return http.StatusBadRequest
+}
+
+// RequestOTP texts a login code to the given phone number.
+func (c *UserController) RequestOTP(ctx *gin.Context) {
+	var req OTPRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := c.userService.RequestOTP(req.Phone, ctx.ClientIP()); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrInvalidPhone):
+			status = http.StatusBadRequest
+		case errors.Is(err, ErrOTPCooldown), errors.Is(err, ErrOTPRateLimited):
+			status = http.StatusTooManyRequests
+		}
+		ctx.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":    "Verification code sent",
+		"expires_in": int64(otpTTL.Seconds()),
+		"resend_in":  int64(otpResendCooldown.Seconds()),
+	})
+}
+
+// VerifyOTP logs in with a phone number and the code sent to it.
+func (c *UserController) VerifyOTP(ctx *gin.Context) {
+	var req OTPVerifyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	user, err := c.userService.VerifyOTP(req.Phone, req.Code)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrInvalidPhone):
+			status = http.StatusBadRequest
+		case errors.Is(err, ErrInvalidOTP):
+			status = http.StatusUnauthorized
+		case errors.Is(err, ErrOTPTooManyAttempts):
+			status = http.StatusTooManyRequests
+		}
+		ctx.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	tokens, err := c.userService.IssueTokens(user.ID, sessionMeta(ctx))
+	if errors.Is(err, ErrUserBlocked) {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error":   err.Error(),
+			"blocked": true,
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate authentication token: " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+func (c *UserController) ListSessions(ctx *gin.Context) {
+	sessions, err := c.userService.ListSessions(ctx.GetUint("userID"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get sessions",
+		})
+		return
+	}
+
+	currentID := ctx.GetString("sessionID")
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"sessions": sessions,
+		"count":    len(sessions),
+	})
+}
+
+func (c *UserController) RevokeSession(ctx *gin.Context) {
+	err := c.userService.RevokeSession(ctx.GetUint("userID"), ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Session signed out successfully",
+	})
+}
+
+func (c *UserController) RevokeAllSessions(ctx *gin.Context) {
+	if err := c.userService.RevokeAllSessions(ctx.GetUint("userID")); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to sign out sessions",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Signed out from all devices",
+	})
+}
+
+// sessionMeta captures the client details stored on a session.
+func sessionMeta(ctx *gin.Context) SessionMeta {
+	return SessionMeta{
+		UserAgent: ctx.Request.UserAgent(),
+		IP:        ctx.ClientIP(),
+	}
+}
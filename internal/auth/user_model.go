@@ -5,61 +5,231 @@ import (
 	"time"
 )
 
+// Roles a user can hold. Everyone starts as a customer; staff and above are
+// granted through the admin role endpoints.
+const (
+	RoleCustomer   = "customer"
+	RoleStaff      = "staff"
+	RoleAdmin      = "admin"
+	RoleSuperAdmin = "super-admin"
+)
+
+// IsValidRole reports whether role is one of the known roles.
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleCustomer, RoleStaff, RoleAdmin, RoleSuperAdmin:
+		return true
+	}
+	return false
+}
+
 type User struct {
 	ID       uint   `gorm:"primaryKey" json:"id"`
 	Name     string `gorm:"not null" json:"name"`
-	GoogleID string `gorm:"not null" json:"google_id"`
-	Email    string `gorm:"not null;uniqueIndex" json:"email"`
+	GoogleID string `gorm:"default:''" json:"google_id,omitempty"` // Deprecated: use Identities
+	Email    string `gorm:"not null;default:''" json:"email"` // Empty for phone-only accounts
+	// EmailVerified is set when a login provider vouched for Email
+	EmailVerified bool `gorm:"not null;default:false" json:"email_verified"`
+	Role     string `gorm:"not null;default:'customer'" json:"role"`
+
+	Phone         string `json:"phone"`
+	PhoneVerified bool   `gorm:"not null;default:false" json:"phone_verified"` // Set by OTP login
+
+	// Two-factor authentication. TOTPSecret is set on enrollment and only
+	// takes effect once TOTPEnabled is confirmed with a first code.
+	TOTPSecret   string `gorm:"column:totp_secret;default:''" json:"-"`
+	TOTPEnabled  bool   `gorm:"column:totp_enabled;not null;default:false" json:"totp_enabled"`
+	TOTPLastStep int64  `gorm:"column:totp_last_step;not null;default:0" json:"-"` // Stops code replay
+
+	// Failed two-factor checks since the last good one; checks are refused
+	// until MFALockedUntil once there are too many
+	MFAFailedAttempts int        `gorm:"column:mfa_failed_attempts;not null;default:0" json:"-"`
+	MFALockedUntil    *time.Time `gorm:"column:mfa_locked_until" json:"-"`
 
-	Phone    string `json:"phone"`
+	// Blocked users cannot sign in, use existing tokens or place orders
+	BlockedAt     *time.Time `json:"blocked_at,omitempty"`
+	BlockedReason string     `gorm:"not null;default:''" json:"blocked_reason,omitempty"`
 	Birthday string `json:"birthday"`
 	Gender   string `json:"gender"`
 
+	Identities []UserIdentity `gorm:"foreignKey:UserID" json:"identities,omitempty"`
+
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
+// UserIdentity links a User to an account at a login provider, so one user
+// can sign in through several providers.
+type UserIdentity struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	UserID   uint   `gorm:"not null;index" json:"user_id"`
+	Provider string `gorm:"not null;uniqueIndex:idx_user_identities_provider_subject" json:"provider"`
+	Subject  string `gorm:"not null;uniqueIndex:idx_user_identities_provider_subject" json:"-"`
+	Email    string `json:"email"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Address is a saved delivery address. New addresses reference the location
+// dataset; City and Zone then hold the district and upazila/thana names.
+// Addresses saved before that have only the free-text fields.
 type Address struct {
 	ID      uint   `gorm:"primaryKey" json:"id"`
 	UserID  uint   `gorm:"not null;index" json:"user_id"`
 	Name    string `json:"name"`
 	Phone   string `json:"phone"`
-	Address string `json:"address"`
+	Address string `json:"address"` // House, road, block
 	City    string `json:"city"`
 	Zone    string `json:"zone"`
 	Label   string `json:"label"` // "Home", "Office"
 
+	DivisionID   *uint  `json:"division_id"`
+	DistrictID   *uint  `json:"district_id"`
+	UpazilaID    *uint  `json:"upazila_id"`
+	AreaID       *uint  `json:"area_id"`
+	Division     string `json:"division"`
+	Area         string `json:"area"`
+	Postcode     string `json:"postcode"`
+	DeliveryZone string `json:"delivery_zone"`
+	IsDefault    bool   `gorm:"not null;default:false" json:"is_default"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type UpdateProfileRequest struct {
 	Name     string `json:"name" binding:"required"`
-	Phone    string `json:"phone"`
+	Phone    string `json:"phone" binding:"omitempty,bdphone"`
 	Birthday string `json:"birthday"` // Format: "1990-01-15"
 	Gender   string `json:"gender"`
 }
 
+// CreateAddressRequest is validated against the location dataset
+// (GET /api/v1/locations/...). AreaID is optional.
 type CreateAddressRequest struct {
-	Name    string `json:"name" binding:"required"`
-	Phone   string `json:"phone" binding:"required"`
-	Address string `json:"address" binding:"required"`
-	City    string `json:"city" binding:"required"`
-	Zone    string `json:"zone" binding:"required"`
-	Label   string `json:"label" binding:"required"`
-}
-type VisitorLogs struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	IP        string    `json:"ip"`
-	Country   string    `json:"country"`
-	Region    string    `json:"region"`
-	City      string    `json:"city"`
+	Name       string `json:"name" binding:"required"`
+	Phone      string `json:"phone" binding:"required,bdphone"`
+	Address    string `json:"address" binding:"required,max=500"`
+	DivisionID uint   `json:"division_id" binding:"required"`
+	DistrictID uint   `json:"district_id" binding:"required"`
+	UpazilaID  uint   `json:"upazila_id" binding:"required"`
+	AreaID     *uint  `json:"area_id"`
+	Postcode   string `json:"postcode" binding:"omitempty,len=4,numeric"`
+	Label      string `json:"label" binding:"required"`
+	IsDefault  bool   `json:"is_default"`
+}
+// Session is one login on one device. Its ID doubles as the refresh token
+// family, and access tokens carry it in the "sid" claim. LastSeenAt moves
+// forward on every refresh, so it is accurate to within one access token
+// lifetime.
+type Session struct {
+	ID         string     `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	Device     string     `json:"device"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt  *time.Time `json:"-"`
+	MFAAt      *time.Time `gorm:"column:mfa_at" json:"mfa_at"` // Last two-factor check in this session
+
+	// Current is set on responses for the session making the request
+	Current bool `gorm:"-" json:"current"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SessionMeta describes the client a session is created or refreshed from.
+type SessionMeta struct {
+	UserAgent string
+	IP        string
+}
+
+// RefreshToken is an opaque, single-use token that can be traded for a new
+// access token. Only its SHA-256 hash is stored. Every rotation stays in the
+// same family so reuse of an old token can revoke the whole chain.
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	TokenHash string     `gorm:"not null;uniqueIndex" json:"-"`
+	FamilyID  string     `gorm:"not null;index" json:"family_id"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+
 	CreatedAt time.Time `json:"created_at"`
 }
 
-type GoogleUserInfo struct {
-	ID    string `json:"id"`
-	Email string `json:"email"`
-	Name  string `json:"name"`
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // Access token lifetime in seconds
 }
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LoginCode is a short-lived, single-use code that a social login redirects
+// back to the frontend with, to be traded for tokens by POST. Keeping tokens
+// out of the redirect URL keeps them out of browser history and logs. Only
+// its SHA-256 hash is stored.
+type LoginCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LoginCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// PhoneOTP is a one-time login code sent by SMS. Only a hash of the code is
+// stored; a newer code for the same phone supersedes older ones.
+type PhoneOTP struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Phone      string     `gorm:"not null;index" json:"phone"`
+	IP         string     `json:"-"` // Client that requested the code, for per-IP limits
+	CodeHash   string     `gorm:"not null" json:"-"`
+	Attempts   int        `gorm:"not null;default:0" json:"attempts"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	ConsumedAt *time.Time `json:"consumed_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type OTPRequest struct {
+	Phone string `json:"phone" binding:"required,bdphone"`
+}
+
+type OTPVerifyRequest struct {
+	Phone string `json:"phone" binding:"required,bdphone"`
+	Code  string `json:"code" binding:"required"`
+}
+
+// MFARecoveryCode is a one-time code that stands in for a TOTP code when the
+// authenticator is lost. Only its hash is stored.
+type MFARecoveryCode struct {
+	ID       uint       `gorm:"primaryKey" json:"id"`
+	UserID   uint       `gorm:"not null;index" json:"user_id"`
+	CodeHash string     `gorm:"not null" json:"-"`
+	UsedAt   *time.Time `json:"used_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
@@ -1,16 +1,72 @@
 package auth
 
 import (
+	"errors"
+	"time"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository interface {
 	Create(user *User) error
 	FindByEmail(email string) (*User, error)
+	FindByVerifiedPhone(phone string) (*User, error)
 
 	// ✅ Add simple profile methods
 	FindByID(id uint) (*User, error)
 	UpdateProfile(user *User) error
+	UpdateRole(userID uint, role string) error
+	SetBlocked(userID uint, blockedAt *time.Time, reason string) error
+	IsBlocked(userID uint) (bool, error)
+
+	// Identity methods
+	FindIdentity(provider, subject string) (*UserIdentity, error)
+	GetUserIdentities(userID uint) ([]UserIdentity, error)
+	CreateIdentity(identity *UserIdentity) error
+	UpdateIdentityEmail(id uint, email string) error
+	DeleteIdentity(id uint, userID uint) error
+	CreateUserWithIdentity(user *User, identity *UserIdentity) error
+
+	// Two-factor methods
+	SetTOTPSecret(userID uint, secret string) error
+	EnableTOTP(userID uint) error
+	DisableTOTP(userID uint) error
+	UseTOTPStep(userID uint, step int64) (bool, error)
+	BeginMFAAttempt(userID uint, maxAttempts int, lockout time.Duration) (bool, error)
+	ResetMFAAttempts(userID uint) error
+	ReplaceRecoveryCodes(userID uint, hashes []string) error
+	UseRecoveryCode(userID uint, hash string) (bool, error)
+	CountRecoveryCodes(userID uint) (int64, error)
+	SetSessionMFA(sessionID string, at time.Time) error
+
+	// Phone OTP methods
+	CreatePhoneOTP(otp *PhoneOTP) error
+	FindLatestPhoneOTP(phone string) (*PhoneOTP, error)
+	CountPhoneOTPsSince(phone string, since time.Time) (int64, error)
+	CountIPPhoneOTPsSince(ip string, since time.Time) (int64, error)
+	CountAllPhoneOTPsSince(since time.Time) (int64, error)
+	IncrementOTPAttempts(id uint, maxAttempts int) (bool, error)
+	ConsumePhoneOTP(id uint) (bool, error)
+
+	// Session methods
+	CreateSession(session *Session) error
+	FindSessionByID(id string) (*Session, error)
+	GetActiveSessions(userID uint) ([]Session, error)
+	TouchSession(id string, meta SessionMeta, expiresAt time.Time) error
+	RevokeSession(id string) error
+	RevokeUserSessions(userID uint) error
+
+	// Refresh token methods
+	CreateRefreshToken(token *RefreshToken) error
+	FindRefreshTokenByHash(hash string) (*RefreshToken, error)
+	MarkRefreshTokenUsed(id uint) (bool, error)
+	RevokeRefreshTokenFamily(familyID string) error
+	RevokeUserRefreshTokens(userID uint) error
+
+	// Login code methods
+	CreateLoginCode(code *LoginCode) error
+	UseLoginCode(hash string) (uint, error)
 
 	// ✅ Add simple address methods
 	CreateAddress(address *Address) error
@@ -42,6 +98,14 @@ func (r *userRepository) FindByEmail(email string) (*User, error) {
 	return &user, nil
 }
 
+func (r *userRepository) FindByVerifiedPhone(phone string) (*User, error) {
+	var user User
+	if err := r.db.Where("phone = ? AND phone_verified = ?", phone, true).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *userRepository) FindByID(id uint) (*User, error) {
 	var user User
 	if err := r.db.First(&user, id).Error; err != nil {
@@ -50,17 +114,326 @@ func (r *userRepository) FindByID(id uint) (*User, error) {
 	return &user, nil
 }
 
+// UpdateProfile saves the user. The two-factor counters are left out, as
+// they change atomically under concurrent checks.
 func (r *userRepository) UpdateProfile(user *User) error {
-	return r.db.Save(user).Error
+	return r.db.Omit("totp_last_step", "mfa_failed_attempts", "mfa_locked_until").Save(user).Error
+}
+
+func (r *userRepository) UpdateRole(userID uint, role string) error {
+	return r.db.Model(&User{}).Where("id = ?", userID).Update("role", role).Error
+}
+
+func (r *userRepository) SetBlocked(userID uint, blockedAt *time.Time, reason string) error {
+	return r.db.Model(&User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{"blocked_at": blockedAt, "blocked_reason": reason}).Error
+}
+
+func (r *userRepository) IsBlocked(userID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&User{}).Where("id = ? AND blocked_at IS NOT NULL", userID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *userRepository) FindIdentity(provider, subject string) (*UserIdentity, error) {
+	var identity UserIdentity
+	err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *userRepository) GetUserIdentities(userID uint) ([]UserIdentity, error) {
+	var identities []UserIdentity
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&identities).Error
+	return identities, err
+}
+
+func (r *userRepository) CreateIdentity(identity *UserIdentity) error {
+	return r.db.Create(identity).Error
+}
+
+func (r *userRepository) UpdateIdentityEmail(id uint, email string) error {
+	return r.db.Model(&UserIdentity{}).Where("id = ?", id).Update("email", email).Error
+}
+
+func (r *userRepository) DeleteIdentity(id uint, userID uint) error {
+	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&UserIdentity{}).Error
+}
+
+// CreateUserWithIdentity creates a new user and its first linked identity
+// atomically.
+func (r *userRepository) CreateUserWithIdentity(user *User, identity *UserIdentity) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+}
+
+// SetTOTPSecret stores a pending secret. It never overwrites an enabled one.
+func (r *userRepository) SetTOTPSecret(userID uint, secret string) error {
+	return r.db.Model(&User{}).
+		Where("id = ? AND totp_enabled = ?", userID, false).
+		Updates(map[string]interface{}{"totp_secret": secret, "totp_last_step": 0}).Error
+}
+
+func (r *userRepository) EnableTOTP(userID uint) error {
+	return r.db.Model(&User{}).Where("id = ?", userID).Update("totp_enabled", true).Error
+}
+
+func (r *userRepository) DisableTOTP(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{"totp_secret": "", "totp_enabled": false, "totp_last_step": 0}
+		if err := tx.Model(&User{}).Where("id = ?", userID).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&MFARecoveryCode{}).Error
+	})
+}
+
+// UseTOTPStep records the time step of an accepted code. It reports false if
+// that step (or a later one) was already used, so a code works only once.
+func (r *userRepository) UseTOTPStep(userID uint, step int64) (bool, error) {
+	result := r.db.Model(&User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	return result.RowsAffected == 1, result.Error
+}
+
+// BeginMFAAttempt counts a two-factor check before it is made, so parallel
+// guesses can't get past the limit. It reports false while checks are
+// locked. The attempt that reaches maxAttempts locks further checks for
+// lockout, which ResetMFAAttempts lifts if that attempt succeeds.
+func (r *userRepository) BeginMFAAttempt(userID uint, maxAttempts int, lockout time.Duration) (bool, error) {
+	now := time.Now()
+	result := r.db.Model(&User{}).
+		Where("id = ? AND (mfa_locked_until IS NULL OR mfa_locked_until <= ?)", userID, now).
+		Updates(map[string]interface{}{
+			"mfa_failed_attempts": gorm.Expr("mfa_failed_attempts + 1"),
+			"mfa_locked_until":    gorm.Expr("CASE WHEN mfa_failed_attempts + 1 >= ? THEN ?::timestamp END", maxAttempts, now.Add(lockout)),
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *userRepository) ResetMFAAttempts(userID uint) error {
+	return r.db.Model(&User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{"mfa_failed_attempts": 0, "mfa_locked_until": nil}).Error
+}
+
+func (r *userRepository) ReplaceRecoveryCodes(userID uint, hashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]MFARecoveryCode, len(hashes))
+		for i, hash := range hashes {
+			codes[i] = MFARecoveryCode{UserID: userID, CodeHash: hash}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *userRepository) UseRecoveryCode(userID uint, hash string) (bool, error) {
+	result := r.db.Model(&MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *userRepository) CountRecoveryCodes(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&MFARecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *userRepository) SetSessionMFA(sessionID string, at time.Time) error {
+	return r.db.Model(&Session{}).Where("id = ?", sessionID).Update("mfa_at", at).Error
 }
 
+func (r *userRepository) CreatePhoneOTP(otp *PhoneOTP) error {
+	return r.db.Create(otp).Error
+}
+
+func (r *userRepository) FindLatestPhoneOTP(phone string) (*PhoneOTP, error) {
+	var otp PhoneOTP
+	err := r.db.Where("phone = ?", phone).Order("created_at DESC, id DESC").First(&otp).Error
+	if err != nil {
+		return nil, err
+	}
+	return &otp, nil
+}
+
+func (r *userRepository) CountPhoneOTPsSince(phone string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&PhoneOTP{}).Where("phone = ? AND created_at > ?", phone, since).Count(&count).Error
+	return count, err
+}
+
+func (r *userRepository) CountIPPhoneOTPsSince(ip string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&PhoneOTP{}).Where("ip = ? AND created_at > ?", ip, since).Count(&count).Error
+	return count, err
+}
+
+func (r *userRepository) CountAllPhoneOTPsSince(since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&PhoneOTP{}).Where("created_at > ?", since).Count(&count).Error
+	return count, err
+}
+
+// IncrementOTPAttempts counts a verification attempt. It reports false once
+// the code has used up its maxAttempts.
+func (r *userRepository) IncrementOTPAttempts(id uint, maxAttempts int) (bool, error) {
+	result := r.db.Model(&PhoneOTP{}).
+		Where("id = ? AND attempts < ?", id, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	return result.RowsAffected == 1, result.Error
+}
+
+// ConsumePhoneOTP marks a code as used. It reports false if it already was,
+// so a code can only log in once.
+func (r *userRepository) ConsumePhoneOTP(id uint) (bool, error) {
+	result := r.db.Model(&PhoneOTP{}).
+		Where("id = ? AND consumed_at IS NULL", id).
+		Update("consumed_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *userRepository) CreateSession(session *Session) error {
+	return r.db.Create(session).Error
+}
+
+func (r *userRepository) FindSessionByID(id string) (*Session, error) {
+	var session Session
+	if err := r.db.Where("id = ?", id).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetActiveSessions returns the user's live sessions, most recently used first.
+func (r *userRepository) GetActiveSessions(userID uint) ([]Session, error) {
+	var sessions []Session
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *userRepository) TouchSession(id string, meta SessionMeta, expiresAt time.Time) error {
+	updates := map[string]interface{}{
+		"ip":           meta.IP,
+		"user_agent":   meta.UserAgent,
+		"device":       describeDevice(meta.UserAgent),
+		"last_seen_at": time.Now(),
+		"expires_at":   expiresAt,
+	}
+	return r.db.Model(&Session{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *userRepository) RevokeSession(id string) error {
+	return r.db.Model(&Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *userRepository) RevokeUserSessions(userID uint) error {
+	return r.db.Model(&Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *userRepository) CreateRefreshToken(token *RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *userRepository) FindRefreshTokenByHash(hash string) (*RefreshToken, error) {
+	var token RefreshToken
+	if err := r.db.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkRefreshTokenUsed flags the token as rotated. It reports false when the
+// token had already been used, which means someone is replaying it.
+func (r *userRepository) MarkRefreshTokenUsed(id uint) (bool, error) {
+	result := r.db.Model(&RefreshToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *userRepository) RevokeRefreshTokenFamily(familyID string) error {
+	return r.db.Model(&RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *userRepository) RevokeUserRefreshTokens(userID uint) error {
+	return r.db.Model(&RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *userRepository) CreateLoginCode(code *LoginCode) error {
+	return r.db.Create(code).Error
+}
+
+// UseLoginCode marks an unexpired code as used and returns its user. It
+// fails with gorm.ErrRecordNotFound if the code is unknown, expired or was
+// already used, so a code logs in only once.
+func (r *userRepository) UseLoginCode(hash string) (uint, error) {
+	var codes []LoginCode
+	result := r.db.Model(&codes).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "user_id"}}}).
+		Where("code_hash = ? AND used_at IS NULL AND expires_at > ?", hash, time.Now()).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if len(codes) == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return codes[0].UserID, nil
+}
+
+// CreateAddress saves a new address. The user's first address, or one marked
+// default, becomes the only default.
 func (r *userRepository) CreateAddress(address *Address) error {
-	return r.db.Create(address).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&Address{}).Where("user_id = ?", address.UserID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			address.IsDefault = true
+		}
+		if err := clearDefaultAddress(tx, address); err != nil {
+			return err
+		}
+		return tx.Create(address).Error
+	})
+}
+
+// clearDefaultAddress unsets the user's other default when address becomes
+// the default.
+func clearDefaultAddress(tx *gorm.DB, address *Address) error {
+	if !address.IsDefault {
+		return nil
+	}
+	return tx.Model(&Address{}).
+		Where("user_id = ? AND is_default = ? AND id <> ?", address.UserID, true, address.ID).
+		Update("is_default", false).Error
 }
 
 func (r *userRepository) GetUserAddresses(userID uint) ([]Address, error) {
 	var addresses []Address
-	err := r.db.Where("user_id = ?", userID).Find(&addresses).Error
+	err := r.db.Where("user_id = ?", userID).Order("is_default DESC, created_at").Find(&addresses).Error
 	return addresses, err
 }
 
@@ -71,10 +444,38 @@ func (r *userRepository) GetAddressByID(id uint, userID uint) (*Address, error)
 }
 
 func (r *userRepository) UpdateAddress(address *Address) error {
-	return r.db.Save(address).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := clearDefaultAddress(tx, address); err != nil {
+			return err
+		}
+		return tx.Save(address).Error
+	})
 }
 
+// DeleteAddress removes an address. If it was the default, the most recently
+// updated remaining address takes over.
 func (r *userRepository) DeleteAddress(id uint, userID uint) error {
-	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&Address{}).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var address Address
+		if err := tx.Where("id = ? AND user_id = ?", id, userID).First(&address).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&address).Error; err != nil {
+			return err
+		}
+		if !address.IsDefault {
+			return nil
+		}
+
+		var next Address
+		err := tx.Where("user_id = ?", userID).Order("updated_at DESC").First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&next).Update("is_default", true).Error
+	})
 }
 
@@ -10,25 +10,55 @@ func SetupAuthRoutes(router *gin.Engine, userController *UserController) {
 	// Public routes
 	auth := v1.Group("/auth")
 	{
-		auth.GET("/google/login",userController.GoogleLogin)
-        auth.GET("/google/callback",userController.GoogleCallBack)
-		router.GET("/admin/visitors/city", GetVisitorCountByCity)
+		auth.GET("/:provider/login", userController.Login)
+		auth.GET("/:provider/callback", userController.Callback)
+		auth.POST("/exchange", userController.ExchangeLoginCode)
+		auth.POST("/refresh", userController.RefreshToken)
+		auth.POST("/otp/request", userController.RequestOTP)
+		auth.POST("/otp/verify", userController.VerifyOTP)
 	}
 
+	// Public keys for verifying our access tokens
+	router.GET("/.well-known/jwks.json", JWKS)
+
 	// Protected routes
 	protected := v1.Group("")
 	protected.Use(JWTAuthMiddleware())
 	{
 		protected.POST("/auth/logout", userController.Logout) // ✅ NEW logout endpoint
+		protected.GET("/auth/:provider/link", BlockImpersonation(), userController.LinkProvider)
+		protected.GET("/auth/mfa", userController.GetMFAStatus)
+		protected.POST("/auth/mfa/totp/enroll", BlockImpersonation(), userController.EnrollTOTP)
+		protected.POST("/auth/mfa/totp/confirm", BlockImpersonation(), userController.ConfirmTOTP)
+		protected.DELETE("/auth/mfa/totp", BlockImpersonation(), userController.DisableTOTP)
+		protected.POST("/auth/mfa/recovery-codes", BlockImpersonation(), userController.RegenerateRecoveryCodes)
+		protected.POST("/auth/mfa/verify", BlockImpersonation(), userController.VerifyMFA)
+		protected.GET("/identities", userController.ListIdentities)
+		protected.DELETE("/identities/:id", BlockImpersonation(), userController.UnlinkIdentity)
+		protected.GET("/sessions", userController.ListSessions)
+		protected.DELETE("/sessions", BlockImpersonation(), userController.RevokeAllSessions) // Sign out everywhere
+		protected.DELETE("/sessions/:id", BlockImpersonation(), userController.RevokeSession)
 		protected.GET("/profile", userController.GetProfile)
-		protected.PUT("/profile", userController.UpdateProfile)
+		protected.PUT("/profile", BlockImpersonation(), userController.UpdateProfile)
 
 		addresses := protected.Group("/addresses")
 		{
 			addresses.GET("", userController.GetAddresses)
-			addresses.POST("", userController.CreateAddress)
-			addresses.PUT("/:id", userController.UpdateAddress)
-			addresses.DELETE("/:id", userController.DeleteAddress)
+			addresses.POST("", BlockImpersonation(), userController.CreateAddress)
+			addresses.PUT("/:id", BlockImpersonation(), userController.UpdateAddress)
+			addresses.PUT("/:id/default", BlockImpersonation(), userController.SetDefaultAddress)
+			addresses.DELETE("/:id", BlockImpersonation(), userController.DeleteAddress)
 		}
 	}
+
+	// Admin routes
+	admin := v1.Group("/admin")
+	admin.Use(JWTAuthMiddleware(), RequireRole(RoleAdmin))
+	{
+		admin.PUT("/users/:id/role", userController.GrantRole)
+		admin.DELETE("/users/:id/role", userController.RevokeRole)
+		admin.POST("/users/:id/impersonate", RequireMFA(), userController.Impersonate)
+		admin.POST("/users/:id/block", userController.BlockUser)
+		admin.DELETE("/users/:id/block", userController.UnblockUser)
+	}
 }
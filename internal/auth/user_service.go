@@ -1,34 +1,112 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"ecommerce/internal/audit"
+	"ecommerce/internal/location"
+	"ecommerce/internal/phone"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt" // ✅ Add for token ID generation
 	"gorm.io/gorm"
 	"log" // ✅ Add for logging
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	//"golang.org/x/crypto/bcrypt"
 )
 
-// ✅ Global token management (secure token deletion system)
-var (
-	// Blacklisted tokens (use Redis in production)
-	blacklistedTokens = make(map[string]bool)
+// ErrForbidden is returned when the caller's role does not allow an action.
+var ErrForbidden = errors.New("you do not have permission to perform this action")
+
+// accessTokenTTL is how long an access token stays valid.
+const accessTokenTTL = 2 * time.Hour
+
+// refreshTokenTTL is how long a refresh token can be traded in. Each rotation
+// starts a new window.
+const refreshTokenTTL = 30 * 24 * time.Hour
+
+// loginCodeTTL is how long the frontend has to trade a social login's code
+// for tokens.
+const loginCodeTTL = time.Minute
 
-	// Active tokens per user (for single device login)
-	activeUserTokens = make(map[uint]string) // userID -> tokenID
+var (
+	ErrIdentityLinked      = errors.New("this login is already linked to another account")
+	ErrLastIdentity        = errors.New("cannot unlink your only login method")
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used")
+	ErrInvalidLoginCode    = errors.New("invalid or expired login code")
 )
 
+// ✅ Token revocation goes through a TokenStore so it survives restarts and is
+// shared between replicas. main swaps in the Postgres store at startup.
+var tokenStore TokenStore = NewMemoryTokenStore()
+
+// SetTokenStore replaces the store used for token tracking and revocation.
+func SetTokenStore(store TokenStore) {
+	tokenStore = store
+}
+
+// StartTokenCleanup purges expired token entries every interval.
+func StartTokenCleanup(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := tokenStore.PurgeExpired(); err != nil {
+				log.Printf("⚠️ Failed to purge expired tokens: %v", err)
+			}
+		}
+	}()
+}
+
 // ✅ Updated interface with logout method
 type UserService interface {
 	//Register(name, email, password string) (*User, string, error)
 	//Login(email, password string) (string, error)
-	Logout(tokenID string) error // ✅ NEW: Proper logout
+	Logout(userID uint, sessionID, tokenID string) error // ✅ NEW: Proper logout
+
+	GenerateToken(userID uint, sessionID string) (string, error)
+	IssueTokens(userID uint, meta SessionMeta) (*TokenPair, error)
+	RefreshTokens(refreshToken string, meta SessionMeta) (*TokenPair, error)
+	IssueLoginCode(userID uint) (string, error)
+	ExchangeLoginCode(code string, meta SessionMeta) (*TokenPair, error)
+
+	// Identity methods
+	LoginWithIdentity(identity *ExternalIdentity) (*User, error)
+	LinkIdentity(userID uint, identity *ExternalIdentity) (*UserIdentity, error)
+	ListIdentities(userID uint) ([]UserIdentity, error)
+	UnlinkIdentity(userID uint, identityID uint) error
+
+	// Two-factor methods
+	EnrollTOTP(userID uint) (*TOTPEnrollment, error)
+	ConfirmTOTP(userID uint, sessionID string, code string) ([]string, error)
+	VerifyMFA(userID uint, sessionID string, code string) (string, error)
+	RegenerateRecoveryCodes(userID uint, code string) ([]string, error)
+	DisableTOTP(userID uint, code string) error
+	GetMFAStatus(userID uint) (*MFAStatus, error)
+
+	// Phone OTP login
+	RequestOTP(phone, ip string) error
+	VerifyOTP(phone, code string) (*User, error)
 
-	GenerateToken(userID uint) (string, error)
+	// Session methods
+	ListSessions(userID uint) ([]Session, error)
+	RevokeSession(userID uint, sessionID string) error
+	RevokeAllSessions(userID uint) error
 
+	// Role management (admin only)
+	GrantRole(actor audit.Actor, userID uint, role string) (*User, error)
+	RevokeRole(actor audit.Actor, userID uint) (*User, error)
+	Impersonate(actor audit.Actor, userID uint, reason string) (*ImpersonationToken, error)
+	BlockUser(actor audit.Actor, userID uint, reason string) (*User, error)
+	UnblockUser(actor audit.Actor, userID uint) (*User, error)
 
 	// Profile methods
 	GetProfile(userID uint) (*User, error)
@@ -39,96 +117,1033 @@ type UserService interface {
 	CreateAddress(userID uint, req CreateAddressRequest) (*Address, error)
 	UpdateAddress(userID uint, addressID uint, req CreateAddressRequest) (*Address, error)
 	DeleteAddress(userID uint, addressID uint) error
+	SetDefaultAddress(userID uint, addressID uint) (*Address, error)
 }
 
 type userService struct {
-	repo UserRepository
+	repo      UserRepository
+	audit     audit.Service
+	locations location.LocationService
 }
 
-func NewUserService(repo UserRepository) UserService {
-	return &userService{repo: repo}
+func NewUserService(repo UserRepository, auditService audit.Service, locations location.LocationService) UserService {
+	return &userService{repo: repo, audit: auditService, locations: locations}
 }
 
 // ✅ SECURE: Complete token generation with all security fixes
-func (s *userService) GenerateToken(userID uint) (string, error) {
+func (s *userService) GenerateToken(userID uint, sessionID string) (string, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return "", errors.New("user not found")
+	}
+
 	// ✅ Generate unique token ID (prevents token reuse attacks)
 	tokenID := fmt.Sprintf("token_%d_%d", userID, time.Now().UnixNano())
 
-	// ✅ Delete old tokens for single device login
-	s.deleteOldUserTokens(userID)
-
 	// ✅ Create secure token with proper claims
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	expiresAt := time.Now().Add(accessTokenTTL)
+	claims := jwt.MapClaims{
 		"user_id": userID,
+		"role":    user.Role,                            // ✅ Role claim for RequireRole
+		"sid":     sessionID,                            // ✅ Session the token belongs to
 		"jti":     tokenID,                              // ✅ Unique token ID
 		"iss":     "ecommerce-api",                      // ✅ Issuer verification
 		"aud":     "ecommerce-app",                      // ✅ Audience restriction
-		"exp":     time.Now().Add(time.Hour * 2).Unix(), // ✅ Short expiry (2 hours)
+		"exp":     expiresAt.Unix(),                     // ✅ Short expiry (2 hours)
 		"iat":     time.Now().Unix(),                    // ✅ Issued at time
 		"nbf":     time.Now().Unix(),                    // ✅ Not valid before
-	})
+	}
 
-	// ✅ SECURE: Strong secret requirement (no fallback)
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		return "", errors.New("JWT_SECRET environment variable is required")
+	// ✅ Carry a recent two-factor check over to tokens for the same session
+	if sessionID != "" {
+		session, err := s.repo.FindSessionByID(sessionID)
+		if err == nil && session.MFAAt != nil && time.Since(*session.MFAAt) < mfaStepUpTTL {
+			claims["mfa"] = session.MFAAt.Unix()
+		}
 	}
 
-	tokenString, err := token.SignedString([]byte(jwtSecret))
+	// ✅ Signed with the configured key (RS256/EdDSA with kid) or HS256
+	tokenString, err := signJWT(claims)
 	if err != nil {
 		return "", err
 	}
 
-	// ✅ Store active token for this user
-	activeUserTokens[userID] = tokenID
+	// ✅ Store active token for this session
+	if err := tokenStore.Track(userID, sessionID, tokenID, expiresAt); err != nil {
+		return "", err
+	}
 
 	// ✅ Log token creation for security monitoring
-	log.Printf("🔑 Token created: UserID=%d, TokenID=%s", userID, tokenID)
+	log.Printf("🔑 Token created: UserID=%d, SessionID=%s, TokenID=%s", userID, sessionID, tokenID)
 
 	return tokenString, nil
 }
 
-// ✅ SECURE: Delete old tokens when user logs in from new device
-func (s *userService) deleteOldUserTokens(userID uint) {
-	if oldTokenID, exists := activeUserTokens[userID]; exists {
-		// Add old token to blacklist (this "deletes" it)
-		blacklistedTokens[oldTokenID] = true
-		log.Printf("🗑️ Old token deleted: UserID=%d, TokenID=%s", userID, oldTokenID)
+// ✅ IssueTokens starts a new session: an access token plus a refresh token in
+// a fresh family. When the user is at the session limit, the least recently
+// used sessions are signed out to make room.
+func (s *userService) IssueTokens(userID uint, meta SessionMeta) (*TokenPair, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.BlockedAt != nil {
+		return nil, ErrUserBlocked
 	}
+
+	if err := s.enforceSessionLimit(userID); err != nil {
+		return nil, err
+	}
+
+	sessionID, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &Session{
+		ID:         sessionID,
+		UserID:     userID,
+		Device:     describeDevice(meta.UserAgent),
+		UserAgent:  meta.UserAgent,
+		IP:         meta.IP,
+		LastSeenAt: time.Now(),
+		ExpiresAt:  time.Now().Add(refreshTokenTTL),
+	}
+	if err := s.repo.CreateSession(session); err != nil {
+		return nil, err
+	}
+	log.Printf("📱 Session created: UserID=%d, SessionID=%s, Device=%s", userID, sessionID, session.Device)
+
+	return s.issueTokenPair(userID, sessionID)
 }
 
-// ✅ SECURE: Proper logout that actually works
-func (s *userService) Logout(tokenID string) error {
-	if tokenID == "" {
-		return errors.New("token ID is required")
+// enforceSessionLimit ends the oldest sessions so a new one fits under the
+// configured per-user limit.
+func (s *userService) enforceSessionLimit(userID uint) error {
+	sessions, err := s.repo.GetActiveSessions(userID)
+	if err != nil {
+		return err
 	}
 
-	// ✅ Delete: Add token to blacklist
-	blacklistedTokens[tokenID] = true
+	limit := sessionLimit()
+	for i := len(sessions) - 1; i >= 0 && i >= limit-1; i-- {
+		if err := s.endSession(sessions[i].ID); err != nil {
+			return err
+		}
+		log.Printf("🗑️ Session evicted (limit %d): UserID=%d, SessionID=%s", limit, userID, sessions[i].ID)
+	}
+	return nil
+}
 
-	// ✅ Delete: Remove from active tokens using Go's built-in delete()
-	for userID, activeTokenID := range activeUserTokens {
-		if activeTokenID == tokenID {
-			delete(activeUserTokens, userID) // ✅ This removes the userID key from map
-			break
+// sessionLimit reads SESSION_LIMIT, the number of devices a user may be
+// signed in on at once. Defaults to 5.
+func sessionLimit() int {
+	limit, err := strconv.Atoi(os.Getenv("SESSION_LIMIT"))
+	if err != nil || limit < 1 {
+		return 5
+	}
+	return limit
+}
+
+// ✅ RefreshTokens rotates a refresh token. Each token works exactly once;
+// presenting a used one signs out the whole session, since either the client
+// or an attacker is holding a stolen copy.
+func (s *userService) RefreshTokens(refreshToken string, meta SessionMeta) (*TokenPair, error) {
+	stored, err := s.repo.FindRefreshTokenByHash(hashToken(refreshToken))
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if stored.RevokedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if stored.UsedAt != nil {
+		s.revokeRefreshFamily(stored)
+		return nil, ErrRefreshTokenReused
+	}
+
+	blocked, err := s.repo.IsBlocked(stored.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, ErrUserBlocked
+	}
+
+	fresh, err := s.repo.MarkRefreshTokenUsed(stored.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !fresh {
+		// Lost a race with another request using the same token
+		s.revokeRefreshFamily(stored)
+		return nil, ErrRefreshTokenReused
+	}
+
+	if err := s.repo.TouchSession(stored.FamilyID, meta, time.Now().Add(refreshTokenTTL)); err != nil {
+		return nil, err
+	}
+
+	return s.issueTokenPair(stored.UserID, stored.FamilyID)
+}
+
+// IssueLoginCode returns a one-time code for userID that ExchangeLoginCode
+// trades for tokens within loginCodeTTL.
+func (s *userService) IssueLoginCode(userID uint) (string, error) {
+	blocked, err := s.repo.IsBlocked(userID)
+	if err != nil {
+		return "", err
+	}
+	if blocked {
+		return "", ErrUserBlocked
+	}
+
+	code, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	if err := s.repo.CreateLoginCode(&LoginCode{
+		UserID:    userID,
+		CodeHash:  hashToken(code),
+		ExpiresAt: time.Now().Add(loginCodeTTL),
+	}); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// ExchangeLoginCode starts a session for the user a login code was issued
+// to. Each code works once.
+func (s *userService) ExchangeLoginCode(code string, meta SessionMeta) (*TokenPair, error) {
+	userID, err := s.repo.UseLoginCode(hashToken(code))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidLoginCode
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.IssueTokens(userID, meta)
+}
+
+func (s *userService) revokeRefreshFamily(stored *RefreshToken) {
+	log.Printf("🚨 Refresh token reuse detected: UserID=%d, SessionID=%s", stored.UserID, stored.FamilyID)
+	// The access token minted from the stolen refresh token is still live, so
+	// end the session rather than just the refresh chain
+	if err := s.endSession(stored.FamilyID); err != nil {
+		log.Printf("⚠️ Failed to revoke session %s: %v", stored.FamilyID, err)
+	}
+}
+
+func (s *userService) issueTokenPair(userID uint, sessionID string) (*TokenPair, error) {
+	accessToken, err := s.GenerateToken(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	rawRefresh, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateRefreshToken(&RefreshToken{
+		UserID:    userID,
+		TokenHash: hashToken(rawRefresh),
+		FamilyID:  sessionID,
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: rawRefresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(accessTokenTTL.Seconds()),
+	}, nil
+}
+
+// randomToken returns n random bytes encoded as unpadded base64url.
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken is how opaque tokens are stored: hex SHA-256 of the raw value.
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// endSession revokes a session together with its refresh tokens and any
+// access tokens still live for it.
+func (s *userService) endSession(sessionID string) error {
+	if err := s.repo.RevokeSession(sessionID); err != nil {
+		return err
+	}
+	if err := s.repo.RevokeRefreshTokenFamily(sessionID); err != nil {
+		return err
+	}
+	return tokenStore.RevokeSession(sessionID)
+}
+
+// ✅ SECURE: Proper logout that actually works
+func (s *userService) Logout(userID uint, sessionID, tokenID string) error {
+	if sessionID == "" {
+		// Tokens issued before sessions existed can only be revoked one by one
+		if tokenID == "" {
+			return errors.New("token ID is required")
 		}
+		return tokenStore.Revoke(tokenID, time.Now().Add(accessTokenTTL))
 	}
-	log.Printf("🗑️ Token deleted (logout): TokenID=%s", tokenID)
+
+	if err := s.RevokeSession(userID, sessionID); err != nil {
+		return err
+	}
+	log.Printf("🗑️ Session ended (logout): UserID=%d, SessionID=%s", userID, sessionID)
+	return nil
+}
+
+func (s *userService) ListSessions(userID uint) ([]Session, error) {
+	return s.repo.GetActiveSessions(userID)
+}
+
+func (s *userService) RevokeSession(userID uint, sessionID string) error {
+	session, err := s.repo.FindSessionByID(sessionID)
+	if err != nil || session.UserID != userID {
+		return errors.New("session not found")
+	}
+	return s.endSession(sessionID)
+}
+
+// RevokeAllSessions signs the user out everywhere, including the current device.
+func (s *userService) RevokeAllSessions(userID uint) error {
+	if err := s.repo.RevokeUserSessions(userID); err != nil {
+		return err
+	}
+	if err := s.repo.RevokeUserRefreshTokens(userID); err != nil {
+		return err
+	}
+	if err := tokenStore.RevokeUser(userID); err != nil {
+		return err
+	}
+	log.Printf("🗑️ All sessions ended: UserID=%d", userID)
 	return nil
 }
 
 // ✅ SECURE: Check if token is deleted/blacklisted (for middleware)
 func IsTokenDeleted(tokenID string) bool {
-	return blacklistedTokens[tokenID]
+	revoked, err := tokenStore.IsRevoked(tokenID)
+	if err != nil {
+		// Fail closed: a token we cannot check is treated as revoked
+		log.Printf("⚠️ Token revocation check failed: TokenID=%s, err=%v", tokenID, err)
+		return true
+	}
+	return revoked
 }
 
 // ✅ SECURE: Force delete all tokens for a user (for password change)
 func (s *userService) DeleteAllUserTokens(userID uint) {
-	if tokenID, exists := activeUserTokens[userID]; exists {
-		blacklistedTokens[tokenID] = true
-		delete(activeUserTokens, userID)
-		log.Printf("🗑️ All tokens deleted for UserID=%d", userID)
+	if err := s.RevokeAllSessions(userID); err != nil {
+		log.Printf("⚠️ Failed to delete tokens for UserID=%d: %v", userID, err)
+	}
+}
+
+// describeDevice turns a User-Agent into a short label for the session list.
+func describeDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	switch {
+	case ua == "":
+		return "Unknown device"
+	case strings.Contains(ua, "iphone"):
+		return "iPhone"
+	case strings.Contains(ua, "ipad"):
+		return "iPad"
+	case strings.Contains(ua, "android"):
+		return "Android"
+	case strings.Contains(ua, "windows"):
+		return "Windows"
+	case strings.Contains(ua, "macintosh"), strings.Contains(ua, "mac os"):
+		return "Mac"
+	case strings.Contains(ua, "linux"):
+		return "Linux"
+	}
+	return "Other"
+}
+
+// LoginWithIdentity finds or creates the user behind an external login. A
+// known identity logs straight in; otherwise a verified email that matches an
+// existing user's verified email links the new identity to that account, and
+// failing that a new user is created. The new user only gets the email if it
+// is verified and no other account has it; otherwise it stays on the identity.
+func (s *userService) LoginWithIdentity(ext *ExternalIdentity) (*User, error) {
+	identity, err := s.repo.FindIdentity(ext.Provider, ext.Subject)
+	if err == nil {
+		user, err := s.repo.FindByID(identity.UserID)
+		if err != nil {
+			return nil, errors.New("user not found")
+		}
+		if ext.Email != "" && identity.Email != ext.Email {
+			if err := s.repo.UpdateIdentityEmail(identity.ID, ext.Email); err != nil {
+				log.Printf("⚠️ Failed to update identity email: %v", err)
+			}
+		}
+		return s.syncLoginProfile(user, ext)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	newIdentity := &UserIdentity{
+		Provider: ext.Provider,
+		Subject:  ext.Subject,
+		Email:    ext.Email,
+	}
+
+	// ✅ Only link by email when both sides vouch for it, otherwise anyone
+	// could claim an account by registering its address elsewhere, or by
+	// signing up with someone else's address before they do
+	emailFree := false
+	if ext.Email != "" && ext.EmailVerified {
+		existing, err := s.repo.FindByEmail(ext.Email)
+		switch {
+		case err == nil && existing.EmailVerified:
+			newIdentity.UserID = existing.ID
+			if err := s.repo.CreateIdentity(newIdentity); err != nil {
+				return nil, err
+			}
+			log.Printf("🔗 Identity linked by email: UserID=%d, Provider=%s", existing.ID, ext.Provider)
+			return s.syncLoginProfile(existing, ext)
+		case err == nil:
+			log.Printf("⚠️ Email belongs to an unverified account, not linking: UserID=%d, Provider=%s", existing.ID, ext.Provider)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			emailFree = true
+		default:
+			return nil, err
+		}
+	}
+
+	user := &User{
+		Name: ext.Name,
+		Role: RoleCustomer,
+	}
+	if emailFree {
+		user.Email = ext.Email
+		user.EmailVerified = true
+	}
+	if user.EmailVerified && isBootstrapSuperAdmin(user.Email) {
+		user.Role = RoleSuperAdmin
+	}
+	if err := s.repo.CreateUserWithIdentity(user, newIdentity); err != nil {
+		return nil, fmt.Errorf("failed to create user: %v", err)
+	}
+
+	log.Printf("✅ New user created: UserID=%d, Provider=%s", user.ID, ext.Provider)
+	return user, nil
+}
+
+// syncLoginProfile fills in a missing name and records a newly verified email
+// on login. Roles are left alone: the bootstrap super-admin promotion only
+// applies when the account is created, so a demoted super-admin stays
+// demoted.
+func (s *userService) syncLoginProfile(user *User, ext *ExternalIdentity) (*User, error) {
+	verified := ext.EmailVerified && strings.EqualFold(user.Email, ext.Email)
+	changed := false
+	if verified && !user.EmailVerified {
+		user.EmailVerified = true
+		changed = true
+	}
+	if user.Name == "" && ext.Name != "" {
+		user.Name = ext.Name
+		changed = true
+	}
+	if !changed {
+		return user, nil
+	}
+	if err := s.repo.UpdateProfile(user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %v", err)
+	}
+	return user, nil
+}
+
+// LinkIdentity attaches an external login to an already signed-in user.
+func (s *userService) LinkIdentity(userID uint, ext *ExternalIdentity) (*UserIdentity, error) {
+	existing, err := s.repo.FindIdentity(ext.Provider, ext.Subject)
+	if err == nil {
+		if existing.UserID != userID {
+			return nil, ErrIdentityLinked
+		}
+		return existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	identity := &UserIdentity{
+		UserID:   userID,
+		Provider: ext.Provider,
+		Subject:  ext.Subject,
+		Email:    ext.Email,
+	}
+	if err := s.repo.CreateIdentity(identity); err != nil {
+		return nil, err
+	}
+
+	log.Printf("🔗 Identity linked: UserID=%d, Provider=%s", userID, ext.Provider)
+	return identity, nil
+}
+
+func (s *userService) ListIdentities(userID uint) ([]UserIdentity, error) {
+	return s.repo.GetUserIdentities(userID)
+}
+
+func (s *userService) UnlinkIdentity(userID uint, identityID uint) error {
+	identities, err := s.repo.GetUserIdentities(userID)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, identity := range identities {
+		if identity.ID == identityID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return errors.New("identity not found")
+	}
+	if len(identities) == 1 {
+		return ErrLastIdentity
+	}
+
+	return s.repo.DeleteIdentity(identityID, userID)
+}
+
+// EnrollTOTP starts authenticator enrollment with a new secret. It only takes
+// effect after ConfirmTOTP, so an abandoned enrollment changes nothing.
+func (s *userService) EnrollTOTP(userID uint) (*TOTPEnrollment, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnrolled
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetTOTPSecret(userID, secret); err != nil {
+		return nil, err
+	}
+
+	account := user.Email
+	if account == "" {
+		account = user.Phone
+	}
+	return &TOTPEnrollment{Secret: secret, URI: totpURI(secret, account)}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication once the user proves the
+// authenticator works, and returns the recovery codes. They are only shown
+// this once. The confirming code also counts as a check for sessionID.
+func (s *userService) ConfirmTOTP(userID uint, sessionID string, code string) ([]string, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnrolled
+	}
+	if user.TOTPSecret == "" {
+		return nil, errors.New("start enrollment first")
+	}
+	if err := s.checkTOTP(user, code); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.newRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.EnableTOTP(userID); err != nil {
+		return nil, err
+	}
+	if sessionID != "" {
+		if err := s.repo.SetSessionMFA(sessionID, time.Now()); err != nil {
+			log.Printf("⚠️ Failed to record MFA for session %s: %v", sessionID, err)
+		}
+	}
+
+	log.Printf("🛡️ Two-factor enabled: UserID=%d", userID)
+	return codes, nil
+}
+
+// VerifyMFA checks a TOTP or recovery code for the current session and returns
+// a new access token carrying the mfa claim. Refreshed tokens keep the claim
+// until the check is older than mfaStepUpTTL.
+func (s *userService) VerifyMFA(userID uint, sessionID string, code string) (string, error) {
+	if sessionID == "" {
+		return "", errors.New("please log in again")
+	}
+
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return "", errors.New("user not found")
+	}
+	if err := s.checkMFACode(user, code); err != nil {
+		log.Printf("⚠️ Failed two-factor check: UserID=%d", userID)
+		return "", err
+	}
+
+	if err := s.repo.SetSessionMFA(sessionID, time.Now()); err != nil {
+		return "", err
+	}
+	return s.GenerateToken(userID, sessionID)
+}
+
+func (s *userService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if err := s.checkMFACode(user, code); err != nil {
+		return nil, err
+	}
+	return s.newRecoveryCodes(userID)
+}
+
+func (s *userService) DisableTOTP(userID uint, code string) error {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if err := s.checkMFACode(user, code); err != nil {
+		return err
+	}
+	if err := s.repo.DisableTOTP(userID); err != nil {
+		return err
+	}
+
+	log.Printf("🛡️ Two-factor disabled: UserID=%d", userID)
+	return nil
+}
+
+func (s *userService) GetMFAStatus(userID uint) (*MFAStatus, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
 	}
+	status := &MFAStatus{Enabled: user.TOTPEnabled}
+	if user.TOTPEnabled {
+		if status.RecoveryCodesLeft, err = s.repo.CountRecoveryCodes(userID); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// checkMFACode accepts either a current TOTP code or an unused recovery code.
+// Failed checks count towards a lockout, so codes can't be brute-forced with
+// a stolen session.
+func (s *userService) checkMFACode(user *User, code string) error {
+	if !user.TOTPEnabled {
+		return ErrMFANotEnrolled
+	}
+	allowed, err := s.repo.BeginMFAAttempt(user.ID, mfaMaxAttempts, mfaLockout)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		log.Printf("🚨 Two-factor check refused while locked: UserID=%d", user.ID)
+		return ErrMFALocked
+	}
+
+	if err := s.checkTOTP(user, code); err != nil {
+		used, err := s.repo.UseRecoveryCode(user.ID, hashRecoveryCode(user.ID, code))
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidMFACode
+		}
+		log.Printf("🛡️ Recovery code used: UserID=%d", user.ID)
+	}
+
+	return s.repo.ResetMFAAttempts(user.ID)
+}
+
+func (s *userService) checkTOTP(user *User, code string) error {
+	step := matchTOTP(user.TOTPSecret, code, time.Now())
+	if step == 0 {
+		return ErrInvalidMFACode
+	}
+	ok, err := s.repo.UseTOTPStep(user.ID, step)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (s *userService) newRecoveryCodes(userID uint) ([]string, error) {
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = hashRecoveryCode(userID, code)
+	}
+	if err := s.repo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func hashRecoveryCode(userID uint, code string) string {
+	return hashToken(fmt.Sprintf("%d:%s", userID, normalizeRecoveryCode(code)))
+}
+
+// RequestOTP sends a login code to phone, subject to the resend cooldown and
+// the hourly caps per phone, per client IP and overall.
+func (s *userService) RequestOTP(number, ip string) error {
+	phone, err := phone.Normalize(number)
+	if err != nil {
+		return err
+	}
+
+	latest, err := s.repo.FindLatestPhoneOTP(phone)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if latest != nil && time.Since(latest.CreatedAt) < otpResendCooldown {
+		return ErrOTPCooldown
+	}
+
+	count, err := s.repo.CountPhoneOTPsSince(phone, time.Now().Add(-time.Hour))
+	if err != nil {
+		return err
+	}
+	if count >= otpHourlyLimit {
+		return ErrOTPRateLimited
+	}
+
+	// ✅ Per-phone limits don't stop one client texting many numbers, so
+	// also cap each IP and the total sent
+	count, err = s.repo.CountIPPhoneOTPsSince(ip, time.Now().Add(-time.Hour))
+	if err != nil {
+		return err
+	}
+	if count >= otpIPHourlyLimit {
+		return ErrOTPRateLimited
+	}
+	count, err = s.repo.CountAllPhoneOTPsSince(time.Now().Add(-time.Hour))
+	if err != nil {
+		return err
+	}
+	if count >= otpGlobalHourlyLimit() {
+		log.Printf("🚨 Global OTP limit reached, refusing code for %s from %s", phone, ip)
+		return ErrOTPRateLimited
+	}
+
+	code, err := generateOTPCode()
+	if err != nil {
+		return err
+	}
+	otp := &PhoneOTP{
+		Phone:     phone,
+		IP:        ip,
+		CodeHash:  hashOTP(phone, code),
+		ExpiresAt: time.Now().Add(otpTTL),
+	}
+	if err := s.repo.CreatePhoneOTP(otp); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Your verification code is %s. It expires in %d minutes.", code, int(otpTTL.Minutes()))
+	if err := smsSender.Send(phone, message); err != nil {
+		return fmt.Errorf("failed to send verification code: %v", err)
+	}
+	return nil
+}
+
+// VerifyOTP checks the latest code sent to phone and returns the user with
+// that verified phone number, creating one on first login.
+func (s *userService) VerifyOTP(number, code string) (*User, error) {
+	phone, err := phone.Normalize(number)
+	if err != nil {
+		return nil, err
+	}
+
+	otp, err := s.repo.FindLatestPhoneOTP(phone)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidOTP
+		}
+		return nil, err
+	}
+	if otp.ConsumedAt != nil || time.Now().After(otp.ExpiresAt) {
+		return nil, ErrInvalidOTP
+	}
+
+	// ✅ Count the attempt before comparing so parallel guesses can't exceed the limit
+	ok, err := s.repo.IncrementOTPAttempts(otp.ID, otpMaxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrOTPTooManyAttempts
+	}
+	if subtle.ConstantTimeCompare([]byte(hashOTP(phone, strings.TrimSpace(code))), []byte(otp.CodeHash)) != 1 {
+		log.Printf("⚠️ Wrong OTP for %s (attempt %d)", phone, otp.Attempts+1)
+		return nil, ErrInvalidOTP
+	}
+
+	consumed, err := s.repo.ConsumePhoneOTP(otp.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidOTP
+	}
+
+	user, err := s.repo.FindByVerifiedPhone(phone)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	user = &User{
+		Phone:         phone,
+		PhoneVerified: true,
+		Role:          RoleCustomer,
+	}
+	if err := s.repo.Create(user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %v", err)
+	}
+
+	log.Printf("✅ New user created: UserID=%d, Provider=phone", user.ID)
+	return user, nil
+}
+
+// isBootstrapSuperAdmin reports whether email is listed in SUPER_ADMIN_EMAILS.
+// This is how the first super-admin gets in, when their account is created;
+// everyone else is granted roles through the admin endpoints.
+func isBootstrapSuperAdmin(email string) bool {
+	if email == "" {
+		return false
+	}
+	for _, e := range strings.Split(os.Getenv("SUPER_ADMIN_EMAILS"), ",") {
+		if strings.EqualFold(strings.TrimSpace(e), email) {
+			return true
+		}
+	}
+	return false
+}
+
+// canManageRole reports whether a user holding actorRole may grant or revoke
+// role. Admins manage staff; only super-admins manage admins and super-admins.
+func canManageRole(actorRole, role string) bool {
+	switch role {
+	case RoleCustomer, RoleStaff:
+		return actorRole == RoleAdmin || actorRole == RoleSuperAdmin
+	case RoleAdmin, RoleSuperAdmin:
+		return actorRole == RoleSuperAdmin
+	}
+	return false
+}
+
+func (s *userService) GrantRole(actor audit.Actor, userID uint, role string) (*User, error) {
+	// ✅ Otherwise the last super-admin could demote themselves and leave
+	// nobody able to manage admins
+	if actor.UserID == userID {
+		return nil, errors.New("you cannot change your own role")
+	}
+	if !IsValidRole(role) {
+		return nil, errors.New("invalid role")
+	}
+	if !canManageRole(actor.Role, role) {
+		return nil, ErrForbidden
+	}
+
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if !canManageRole(actor.Role, user.Role) {
+		return nil, ErrForbidden
+	}
+	if user.Role == role {
+		return user, nil
+	}
+
+	if err := s.repo.UpdateRole(userID, role); err != nil {
+		return nil, err
+	}
+	s.audit.Record(actor, audit.ActionRoleGrant, "user", userID,
+		map[string]interface{}{"role": user.Role}, map[string]interface{}{"role": role})
+	user.Role = role
+
+	// ✅ Existing tokens carry the old role claim, so force a fresh login
+	s.DeleteAllUserTokens(userID)
+	log.Printf("🛡️ Role granted: UserID=%d, Role=%s", userID, role)
+
+	return user, nil
+}
+
+func (s *userService) RevokeRole(actor audit.Actor, userID uint) (*User, error) {
+	if actor.UserID == userID {
+		return nil, errors.New("you cannot revoke your own role")
+	}
+
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if !canManageRole(actor.Role, user.Role) {
+		return nil, ErrForbidden
+	}
+	if user.Role == RoleCustomer {
+		return user, nil
+	}
+
+	if err := s.repo.UpdateRole(userID, RoleCustomer); err != nil {
+		return nil, err
+	}
+	s.audit.Record(actor, audit.ActionRoleRevoke, "user", userID,
+		map[string]interface{}{"role": user.Role}, map[string]interface{}{"role": RoleCustomer})
+	user.Role = RoleCustomer
+
+	s.DeleteAllUserTokens(userID)
+	log.Printf("🛡️ Role revoked: UserID=%d", userID)
+
+	return user, nil
+}
+
+// BlockUser stops a user from signing in or placing orders and signs them
+// out everywhere. Admins can block customers and staff; only super admins can
+// block admins.
+func (s *userService) BlockUser(actor audit.Actor, userID uint, reason string) (*User, error) {
+	if actor.UserID == userID {
+		return nil, errors.New("you cannot block yourself")
+	}
+
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if !canManageRole(actor.Role, user.Role) {
+		return nil, ErrForbidden
+	}
+	if user.BlockedAt != nil {
+		return user, nil
+	}
+
+	now := time.Now()
+	if err := s.repo.SetBlocked(userID, &now, reason); err != nil {
+		return nil, err
+	}
+	s.audit.Record(actor, audit.ActionUserBlock, "user", userID, nil, map[string]interface{}{"reason": reason})
+	user.BlockedAt = &now
+	user.BlockedReason = reason
+
+	forgetBlockStatus(userID)
+	s.DeleteAllUserTokens(userID)
+	log.Printf("🚫 User blocked: UserID=%d, By=%d", userID, actor.UserID)
+
+	return user, nil
+}
+
+func (s *userService) UnblockUser(actor audit.Actor, userID uint) (*User, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if !canManageRole(actor.Role, user.Role) {
+		return nil, ErrForbidden
+	}
+	if user.BlockedAt == nil {
+		return user, nil
+	}
+
+	if err := s.repo.SetBlocked(userID, nil, ""); err != nil {
+		return nil, err
+	}
+	s.audit.Record(actor, audit.ActionUserUnblock, "user", userID,
+		map[string]interface{}{"reason": user.BlockedReason}, nil)
+	user.BlockedAt = nil
+	user.BlockedReason = ""
+
+	forgetBlockStatus(userID)
+	log.Printf("✅ User unblocked: UserID=%d, By=%d", userID, actor.UserID)
+
+	return user, nil
+}
+
+// Impersonate issues a short-lived access token for a customer's account so
+// support can see what they see. The token's act claim names the admin, and
+// it has no session or refresh token.
+func (s *userService) Impersonate(actor audit.Actor, userID uint, reason string) (*ImpersonationToken, error) {
+	if actor.UserID == userID {
+		return nil, errors.New("you cannot impersonate yourself")
+	}
+
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.Role != RoleCustomer {
+		return nil, ErrCannotImpersonate
+	}
+
+	tokenID := fmt.Sprintf("imp_%d_%d_%d", actor.UserID, userID, time.Now().UnixNano())
+	expiresAt := time.Now().Add(impersonationTTL)
+	claims := jwt.MapClaims{
+		"user_id": userID,
+		"role":    user.Role,
+		"act": map[string]interface{}{ // ✅ RFC 8693 actor claim
+			"sub":  actor.UserID,
+			"role": actor.Role,
+		},
+		"jti": tokenID,
+		"iss": "ecommerce-api",
+		"aud": "ecommerce-app",
+		"exp": expiresAt.Unix(),
+		"iat": time.Now().Unix(),
+		"nbf": time.Now().Unix(),
+	}
+
+	tokenString, err := signJWT(claims)
+	if err != nil {
+		return nil, err
+	}
+	// ✅ Tracked under the customer, so signing them out everywhere ends it too
+	if err := tokenStore.Track(userID, "", tokenID, expiresAt); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(actor, audit.ActionImpersonationStart, "user", userID, nil, map[string]interface{}{
+		"reason":     reason,
+		"token_id":   tokenID,
+		"expires_at": expiresAt,
+	})
+	log.Printf("🕵️ Impersonation started: ImpersonatorID=%d, UserID=%d, TokenID=%s", actor.UserID, userID, tokenID)
+
+	return &ImpersonationToken{
+		AccessToken: tokenString,
+		TokenType:   "Bearer",
+		ExpiresAt:   expiresAt,
+		UserID:      userID,
+	}, nil
 }
 
 func (s *userService) GetProfile(userID uint) (*User, error) {
@@ -148,9 +1163,21 @@ func (s *userService) UpdateProfile(userID uint, req UpdateProfileRequest) (*Use
 		return nil, errors.New("user not found")
 	}
 
-	// Update fields
+	// Update fields. The phone is only changed when one is sent.
+	if req.Phone != "" {
+		number, err := phone.Normalize(req.Phone)
+		if err != nil {
+			return nil, err
+		}
+		if number != user.Phone {
+			// ✅ The verified number is how phone-only users log in
+			if user.PhoneVerified {
+				return nil, errors.New("verified phone number cannot be changed")
+			}
+			user.Phone = number
+		}
+	}
 	user.Name = req.Name
-	user.Phone = req.Phone
 	user.Birthday = req.Birthday
 	user.Gender = req.Gender
 	err = s.repo.UpdateProfile(user)
@@ -165,14 +1192,9 @@ func (s *userService) GetAddresses(userID uint) ([]Address, error) {
 }
 
 func (s *userService) CreateAddress(userID uint, req CreateAddressRequest) (*Address, error) {
-	address := &Address{
-		UserID:  userID,
-		Name:    req.Name,
-		Phone:   req.Phone,
-		Address: req.Address,
-		City:    req.City,
-		Zone:    req.Zone,
-		Label:   req.Label,
+	address := &Address{UserID: userID}
+	if err := s.applyAddressRequest(address, req); err != nil {
+		return nil, err
 	}
 	err := s.repo.CreateAddress(address)
 	if err != nil {
@@ -182,19 +1204,52 @@ func (s *userService) CreateAddress(userID uint, req CreateAddressRequest) (*Add
 	return address, nil
 }
 
+// applyAddressRequest validates req against the location dataset and copies
+// it onto address, with the place names filled in.
+func (s *userService) applyAddressRequest(address *Address, req CreateAddressRequest) error {
+	place, err := s.locations.Resolve(req.DivisionID, req.DistrictID, req.UpazilaID, req.AreaID)
+	if err != nil {
+		return err
+	}
+	number, err := phone.Normalize(req.Phone)
+	if err != nil {
+		return err
+	}
+
+	address.Name = strings.TrimSpace(req.Name)
+	address.Phone = number
+	address.Address = strings.TrimSpace(req.Address)
+	address.Label = req.Label
+	address.DivisionID = &place.DivisionID
+	address.DistrictID = &place.DistrictID
+	address.UpazilaID = &place.UpazilaID
+	address.AreaID = place.AreaID
+	address.Division = place.Division
+	address.City = place.District
+	address.Zone = place.Upazila
+	address.Area = place.Area
+	address.Postcode = req.Postcode
+	if address.Postcode == "" {
+		address.Postcode = place.Postcode
+	}
+	address.DeliveryZone = place.DeliveryZone
+	// is_default=false on an update leaves the flag alone; another address
+	// has to be made default instead
+	if req.IsDefault {
+		address.IsDefault = true
+	}
+	return nil
+}
+
 func (s *userService) UpdateAddress(userID uint, addressID uint, req CreateAddressRequest) (*Address, error) {
 	address, err := s.repo.GetAddressByID(addressID, userID)
 	if err != nil {
 		return nil, errors.New("address not found")
 	}
 
-	// Update fields
-	address.Name = req.Name
-	address.Phone = req.Phone
-	address.Address = req.Address
-	address.City = req.City
-	address.Zone = req.Zone
-	address.Label = req.Label
+	if err := s.applyAddressRequest(address, req); err != nil {
+		return nil, err
+	}
 	err = s.repo.UpdateAddress(address)
 	if err != nil {
 		return nil, err
@@ -204,5 +1259,25 @@ func (s *userService) UpdateAddress(userID uint, addressID uint, req CreateAddre
 }
 
 func (s *userService) DeleteAddress(userID uint, addressID uint) error {
-	return s.repo.DeleteAddress(addressID, userID)
+	err := s.repo.DeleteAddress(addressID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New("address not found")
+	}
+	return err
+}
+
+func (s *userService) SetDefaultAddress(userID uint, addressID uint) (*Address, error) {
+	address, err := s.repo.GetAddressByID(addressID, userID)
+	if err != nil {
+		return nil, errors.New("address not found")
+	}
+	if address.IsDefault {
+		return address, nil
+	}
+
+	address.IsDefault = true
+	if err := s.repo.UpdateAddress(address); err != nil {
+		return nil, err
+	}
+	return address, nil
 }
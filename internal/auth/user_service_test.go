@@ -0,0 +1,553 @@
+package auth
+
+import (
+	"ecommerce/internal/audit"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// fakeUserRepo keeps users in memory. Methods a test doesn't set up panic
+// through the nil embedded interface.
+type fakeUserRepo struct {
+	UserRepository
+	users         map[uint]*User
+	identities    []*UserIdentity
+	otps          []*PhoneOTP
+	sessions      []*Session
+	refreshTokens []*RefreshToken
+	loginCodes    []*LoginCode
+	recoveryCodes []*MFARecoveryCode
+}
+
+func newFakeUserRepo(users ...*User) *fakeUserRepo {
+	repo := &fakeUserRepo{users: map[uint]*User{}}
+	for _, user := range users {
+		repo.users[user.ID] = user
+	}
+	return repo
+}
+
+func (r *fakeUserRepo) FindByID(id uint) (*User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	found := *user
+	return &found, nil
+}
+
+func (r *fakeUserRepo) Create(user *User) error {
+	user.ID = uint(len(r.users) + 1)
+	stored := *user
+	r.users[user.ID] = &stored
+	return nil
+}
+
+func (r *fakeUserRepo) FindByVerifiedPhone(phone string) (*User, error) {
+	for _, user := range r.users {
+		if user.Phone == phone && user.PhoneVerified {
+			found := *user
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepo) FindByEmail(email string) (*User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			found := *user
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepo) UpdateProfile(user *User) error {
+	stored := *user
+	r.users[user.ID] = &stored
+	return nil
+}
+
+func (r *fakeUserRepo) UpdateRole(userID uint, role string) error {
+	r.users[userID].Role = role
+	return nil
+}
+
+func (r *fakeUserRepo) SetBlocked(userID uint, blockedAt *time.Time, reason string) error {
+	r.users[userID].BlockedAt = blockedAt
+	r.users[userID].BlockedReason = reason
+	return nil
+}
+
+func (r *fakeUserRepo) IsBlocked(userID uint) (bool, error) {
+	user, ok := r.users[userID]
+	return ok && user.BlockedAt != nil, nil
+}
+
+func (r *fakeUserRepo) FindIdentity(provider, subject string) (*UserIdentity, error) {
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			found := *identity
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepo) GetUserIdentities(userID uint) ([]UserIdentity, error) {
+	var identities []UserIdentity
+	for _, identity := range r.identities {
+		if identity.UserID == userID {
+			identities = append(identities, *identity)
+		}
+	}
+	return identities, nil
+}
+
+func (r *fakeUserRepo) CreateIdentity(identity *UserIdentity) error {
+	identity.ID = uint(len(r.identities) + 1)
+	r.identities = append(r.identities, identity)
+	return nil
+}
+
+func (r *fakeUserRepo) UpdateIdentityEmail(id uint, email string) error {
+	for _, identity := range r.identities {
+		if identity.ID == id {
+			identity.Email = email
+		}
+	}
+	return nil
+}
+
+func (r *fakeUserRepo) DeleteIdentity(id uint, userID uint) error {
+	for i, identity := range r.identities {
+		if identity.ID == id && identity.UserID == userID {
+			r.identities = append(r.identities[:i], r.identities[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// CreateUserWithIdentity enforces the unique index on non-empty users.email.
+func (r *fakeUserRepo) CreateUserWithIdentity(user *User, identity *UserIdentity) error {
+	if _, err := r.FindByEmail(user.Email); user.Email != "" && err == nil {
+		return errors.New(`duplicate key value violates unique constraint "idx_users_email"`)
+	}
+	user.ID = uint(len(r.users) + 1)
+	stored := *user
+	r.users[user.ID] = &stored
+	identity.UserID = user.ID
+	return r.CreateIdentity(identity)
+}
+
+func (r *fakeUserRepo) CreateSession(session *Session) error {
+	r.sessions = append(r.sessions, session)
+	return nil
+}
+
+func (r *fakeUserRepo) FindSessionByID(id string) (*Session, error) {
+	for _, session := range r.sessions {
+		if session.ID == id && session.RevokedAt == nil {
+			found := *session
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// GetActiveSessions returns live sessions, most recently used first.
+func (r *fakeUserRepo) GetActiveSessions(userID uint) ([]Session, error) {
+	var sessions []Session
+	for i := len(r.sessions) - 1; i >= 0; i-- {
+		if session := r.sessions[i]; session.UserID == userID && session.RevokedAt == nil {
+			sessions = append(sessions, *session)
+		}
+	}
+	return sessions, nil
+}
+
+func (r *fakeUserRepo) TouchSession(id string, meta SessionMeta, expiresAt time.Time) error {
+	for _, session := range r.sessions {
+		if session.ID == id {
+			session.LastSeenAt = time.Now()
+			session.ExpiresAt = expiresAt
+		}
+	}
+	return nil
+}
+
+func (r *fakeUserRepo) revokeSessions(match func(*Session) bool) {
+	now := time.Now()
+	for _, session := range r.sessions {
+		if match(session) && session.RevokedAt == nil {
+			session.RevokedAt = &now
+		}
+	}
+}
+
+func (r *fakeUserRepo) RevokeSession(id string) error {
+	r.revokeSessions(func(session *Session) bool { return session.ID == id })
+	return nil
+}
+
+func (r *fakeUserRepo) RevokeUserSessions(userID uint) error {
+	r.revokeSessions(func(session *Session) bool { return session.UserID == userID })
+	return nil
+}
+
+func (r *fakeUserRepo) CreateRefreshToken(token *RefreshToken) error {
+	token.ID = uint(len(r.refreshTokens) + 1)
+	r.refreshTokens = append(r.refreshTokens, token)
+	return nil
+}
+
+func (r *fakeUserRepo) FindRefreshTokenByHash(hash string) (*RefreshToken, error) {
+	for _, token := range r.refreshTokens {
+		if token.TokenHash == hash {
+			found := *token
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepo) MarkRefreshTokenUsed(id uint) (bool, error) {
+	token := r.refreshTokens[id-1]
+	if token.UsedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	token.UsedAt = &now
+	return true, nil
+}
+
+func (r *fakeUserRepo) revokeRefreshTokens(match func(*RefreshToken) bool) {
+	now := time.Now()
+	for _, token := range r.refreshTokens {
+		if match(token) && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+}
+
+func (r *fakeUserRepo) RevokeRefreshTokenFamily(familyID string) error {
+	r.revokeRefreshTokens(func(token *RefreshToken) bool { return token.FamilyID == familyID })
+	return nil
+}
+
+func (r *fakeUserRepo) RevokeUserRefreshTokens(userID uint) error {
+	r.revokeRefreshTokens(func(token *RefreshToken) bool { return token.UserID == userID })
+	return nil
+}
+
+func (r *fakeUserRepo) CreateLoginCode(code *LoginCode) error {
+	r.loginCodes = append(r.loginCodes, code)
+	return nil
+}
+
+func (r *fakeUserRepo) UseLoginCode(hash string) (uint, error) {
+	for _, code := range r.loginCodes {
+		if code.CodeHash == hash && code.UsedAt == nil && time.Now().Before(code.ExpiresAt) {
+			now := time.Now()
+			code.UsedAt = &now
+			return code.UserID, nil
+		}
+	}
+	return 0, gorm.ErrRecordNotFound
+}
+
+// recordingAudit keeps the events services record.
+type recordingAudit struct {
+	audit.Service
+	events []recordedEvent
+}
+
+type recordedEvent struct {
+	actor    audit.Actor
+	action   string
+	targetID interface{}
+}
+
+func (a *recordingAudit) Record(actor audit.Actor, action, targetType string, targetID interface{}, before, after interface{}) {
+	a.events = append(a.events, recordedEvent{actor: actor, action: action, targetID: targetID})
+}
+
+func TestCanManageRole(t *testing.T) {
+	tests := []struct {
+		actor, role string
+		want        bool
+	}{
+		{RoleAdmin, RoleCustomer, true},
+		{RoleAdmin, RoleStaff, true},
+		{RoleAdmin, RoleAdmin, false},
+		{RoleAdmin, RoleSuperAdmin, false},
+		{RoleSuperAdmin, RoleAdmin, true},
+		{RoleSuperAdmin, RoleSuperAdmin, true},
+		{RoleStaff, RoleCustomer, false},
+		{RoleCustomer, RoleStaff, false},
+		{RoleSuperAdmin, "owner", false},
+	}
+	for _, tt := range tests {
+		if got := canManageRole(tt.actor, tt.role); got != tt.want {
+			t.Errorf("canManageRole(%s, %s) = %v, want %v", tt.actor, tt.role, got, tt.want)
+		}
+	}
+}
+
+func TestGrantRole(t *testing.T) {
+	repo := newFakeUserRepo(
+		&User{ID: 1, Role: RoleCustomer},
+		&User{ID: 2, Role: RoleAdmin},
+	)
+	log := &recordingAudit{}
+	s := &userService{repo: repo, audit: log}
+	admin := audit.Actor{UserID: 3, Role: RoleAdmin}
+	superAdmin := audit.Actor{UserID: 4, Role: RoleSuperAdmin}
+
+	user, err := s.GrantRole(admin, 1, RoleStaff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Role != RoleStaff || repo.users[1].Role != RoleStaff {
+		t.Errorf("role = %q, stored %q, want staff", user.Role, repo.users[1].Role)
+	}
+	if len(log.events) != 1 || log.events[0].action != audit.ActionRoleGrant || log.events[0].actor.UserID != 3 {
+		t.Errorf("audit events = %+v", log.events)
+	}
+
+	if _, err := s.GrantRole(admin, 1, RoleAdmin); !errors.Is(err, ErrForbidden) {
+		t.Errorf("admin granting admin: got %v, want ErrForbidden", err)
+	}
+	if _, err := s.GrantRole(admin, 2, RoleStaff); !errors.Is(err, ErrForbidden) {
+		t.Errorf("admin demoting an admin: got %v, want ErrForbidden", err)
+	}
+	if _, err := s.GrantRole(superAdmin, 1, "owner"); err == nil {
+		t.Error("unknown role was granted")
+	}
+	if _, err := s.GrantRole(superAdmin, 1, RoleAdmin); err != nil || repo.users[1].Role != RoleAdmin {
+		t.Errorf("super-admin granting admin: err %v, role %q", err, repo.users[1].Role)
+	}
+	if len(log.events) != 2 {
+		t.Errorf("%d audit events, want 2", len(log.events))
+	}
+
+	// A super-admin can't demote themselves, e.g. leaving nobody to manage
+	// admins
+	repo.users[4] = &User{ID: 4, Role: RoleSuperAdmin}
+	if _, err := s.GrantRole(superAdmin, 4, RoleCustomer); err == nil || repo.users[4].Role != RoleSuperAdmin {
+		t.Errorf("super-admin demoted themselves: err %v, role %q", err, repo.users[4].Role)
+	}
+}
+
+func TestRevokeRole(t *testing.T) {
+	repo := newFakeUserRepo(
+		&User{ID: 1, Role: RoleStaff},
+		&User{ID: 2, Role: RoleAdmin},
+	)
+	log := &recordingAudit{}
+	s := &userService{repo: repo, audit: log}
+
+	if _, err := s.RevokeRole(audit.Actor{UserID: 2, Role: RoleAdmin}, 2); err == nil {
+		t.Error("admin revoked their own role")
+	}
+	if _, err := s.RevokeRole(audit.Actor{UserID: 3, Role: RoleAdmin}, 2); !errors.Is(err, ErrForbidden) {
+		t.Errorf("admin revoking an admin: got %v, want ErrForbidden", err)
+	}
+	user, err := s.RevokeRole(audit.Actor{UserID: 2, Role: RoleAdmin}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Role != RoleCustomer || repo.users[1].Role != RoleCustomer {
+		t.Errorf("role = %q, stored %q, want customer", user.Role, repo.users[1].Role)
+	}
+	if len(log.events) != 1 || log.events[0].action != audit.ActionRoleRevoke {
+		t.Errorf("audit events = %+v", log.events)
+	}
+}
+
+func TestLoginWithIdentity(t *testing.T) {
+	repo := newFakeUserRepo(&User{ID: 1, Email: "jane@example.com", EmailVerified: true, Role: RoleCustomer})
+	s := &userService{repo: repo}
+
+	// A new provider with a verified matching email links to the account
+	google := &ExternalIdentity{Provider: "google", Subject: "g1", Email: "jane@example.com", EmailVerified: true}
+	user, err := s.LoginWithIdentity(google)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != 1 || len(repo.identities) != 1 || repo.identities[0].UserID != 1 {
+		t.Fatalf("verified email not linked: user %d, identities %+v", user.ID, repo.identities)
+	}
+
+	// The linked identity logs straight in, even after its email changes
+	google.Email = "jane@gmail.example"
+	if user, err := s.LoginWithIdentity(google); err != nil || user.ID != 1 {
+		t.Fatalf("known identity: user %+v, err %v", user, err)
+	}
+	if repo.identities[0].Email != "jane@gmail.example" {
+		t.Errorf("identity email = %q, not updated", repo.identities[0].Email)
+	}
+
+	// A brand new email creates a new customer
+	user, err = s.LoginWithIdentity(&ExternalIdentity{Provider: "google", Subject: "g2", Email: "new@example.com", EmailVerified: true, Name: "New"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID == 1 || user.Role != RoleCustomer || user.Name != "New" {
+		t.Errorf("new user = %+v", user)
+	}
+}
+
+func TestLoginWithIdentityUnverifiedEmailDoesNotLink(t *testing.T) {
+	repo := newFakeUserRepo(&User{ID: 1, Email: "jane@example.com", Role: RoleCustomer})
+	s := &userService{repo: repo}
+
+	user, err := s.LoginWithIdentity(&ExternalIdentity{Provider: "oidc", Subject: "attacker", Email: "jane@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID == 1 {
+		t.Fatal("unverified email was linked to an existing account")
+	}
+	for _, identity := range repo.identities {
+		if identity.UserID == 1 {
+			t.Errorf("identity %+v attached to the victim", identity)
+		}
+	}
+}
+
+func TestLoginWithIdentityKeepsUnverifiedEmailsOffUsers(t *testing.T) {
+	// Jane signed up with an address nobody vouched for
+	repo := newFakeUserRepo(&User{ID: 1, Email: "jane@example.com", Role: RoleCustomer})
+	s := &userService{repo: repo}
+
+	// The address's verified owner isn't linked to that account, and gets
+	// one of their own without the address
+	user, err := s.LoginWithIdentity(&ExternalIdentity{Provider: "google", Subject: "g1", Email: "jane@example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID == 1 || user.Email != "" || user.EmailVerified {
+		t.Errorf("user = %+v, want a new user without the email", user)
+	}
+
+	// An unverified email is kept on the identity only
+	user, err = s.LoginWithIdentity(&ExternalIdentity{Provider: "oidc", Subject: "o1", Email: "new@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Email != "" || user.EmailVerified {
+		t.Errorf("user = %+v, want no email", user)
+	}
+	for _, identity := range repo.identities {
+		if identity.UserID == 1 {
+			t.Errorf("identity %+v attached to Jane", identity)
+		}
+	}
+	if len(repo.identities) != 2 || repo.identities[1].Email != "new@example.com" {
+		t.Errorf("identities = %+v, want the emails kept", repo.identities)
+	}
+}
+
+func TestBootstrapSuperAdminOnlyOnSignUp(t *testing.T) {
+	t.Setenv("SUPER_ADMIN_EMAILS", "owner@example.com, boss@example.com")
+	repo := newFakeUserRepo(&User{ID: 1, Email: "boss@example.com", EmailVerified: true, Role: RoleCustomer})
+	s := &userService{repo: repo}
+
+	user, err := s.LoginWithIdentity(&ExternalIdentity{Provider: "google", Subject: "g1", Email: "Owner@example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Role != RoleSuperAdmin {
+		t.Errorf("new listed user role = %q, want super_admin", user.Role)
+	}
+
+	// An existing account, e.g. a demoted super-admin, keeps its role on login
+	user, err = s.LoginWithIdentity(&ExternalIdentity{Provider: "google", Subject: "g2", Email: "boss@example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != 1 || user.Role != RoleCustomer || repo.users[1].Role != RoleCustomer {
+		t.Errorf("existing user %d role = %q, want customer", user.ID, repo.users[1].Role)
+	}
+
+	// ...and an unverified email doesn't make anyone a super-admin
+	user, err = s.LoginWithIdentity(&ExternalIdentity{Provider: "oidc", Subject: "o1", Email: "owner@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Role != RoleCustomer {
+		t.Errorf("unverified listed email role = %q, want customer", user.Role)
+	}
+}
+
+func TestLinkIdentity(t *testing.T) {
+	repo := newFakeUserRepo(&User{ID: 1}, &User{ID: 2})
+	s := &userService{repo: repo}
+	ext := &ExternalIdentity{Provider: "facebook", Subject: "f1"}
+
+	if _, err := s.LinkIdentity(1, ext); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.LinkIdentity(1, ext); err != nil {
+		t.Errorf("relinking to the same user: %v", err)
+	}
+	if _, err := s.LinkIdentity(2, ext); !errors.Is(err, ErrIdentityLinked) {
+		t.Errorf("linking to another user: got %v, want ErrIdentityLinked", err)
+	}
+}
+
+func TestUnlinkIdentity(t *testing.T) {
+	repo := newFakeUserRepo(&User{ID: 1}, &User{ID: 2})
+	s := &userService{repo: repo}
+	google, _ := s.LinkIdentity(1, &ExternalIdentity{Provider: "google", Subject: "g1"})
+	facebook, _ := s.LinkIdentity(1, &ExternalIdentity{Provider: "facebook", Subject: "f1"})
+
+	if err := s.UnlinkIdentity(2, google.ID); err == nil {
+		t.Error("unlinked another user's identity")
+	}
+	if err := s.UnlinkIdentity(1, google.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UnlinkIdentity(1, facebook.ID); !errors.Is(err, ErrLastIdentity) {
+		t.Errorf("unlinking the last identity: got %v, want ErrLastIdentity", err)
+	}
+}
+
+func TestUpdateProfilePhone(t *testing.T) {
+	repo := newFakeUserRepo(
+		&User{ID: 1, Phone: "+8801712345678", PhoneVerified: true},
+		&User{ID: 2, Phone: "+8801712345678"},
+	)
+	s := &userService{repo: repo}
+
+	// Leaving the phone out keeps it, verified or not
+	for _, id := range []uint{1, 2} {
+		if _, err := s.UpdateProfile(id, UpdateProfileRequest{Name: "Rahim"}); err != nil {
+			t.Fatalf("user %d: %v", id, err)
+		}
+		if repo.users[id].Phone != "+8801712345678" || repo.users[id].Name != "Rahim" {
+			t.Errorf("user %d after update: %+v", id, repo.users[id])
+		}
+	}
+
+	// The same number in another format isn't a change
+	if _, err := s.UpdateProfile(1, UpdateProfileRequest{Name: "Rahim", Phone: "01712345678"}); err != nil {
+		t.Errorf("same verified number: %v", err)
+	}
+	if _, err := s.UpdateProfile(1, UpdateProfileRequest{Name: "Rahim", Phone: "01812345678"}); err == nil {
+		t.Error("verified number changed")
+	}
+
+	if _, err := s.UpdateProfile(2, UpdateProfileRequest{Name: "Rahim", Phone: "01812345678"}); err != nil {
+		t.Fatal(err)
+	}
+	if repo.users[2].Phone != "+8801812345678" {
+		t.Errorf("unverified number not changed: %q", repo.users[2].Phone)
+	}
+}
@@ -14,19 +14,51 @@ func NewCartController(cartService CartService) *CartController {
 	return &CartController{cartService: cartService}
 }
 
+// cartOwner is whoever a cart request acts for: a logged-in user, or a guest
+// holding a cart token.
+type cartOwner struct {
+	userID      uint
+	guestCartID uint
+}
+
+// owner resolves the cart owner from the JWT (set by the optional auth
+// middleware) or the X-Cart-Token header. A logged-in user always wins.
+func (c *CartController) owner(ctx *gin.Context) (cartOwner, bool) {
+	if userID := ctx.GetUint("userID"); userID != 0 {
+		return cartOwner{userID: userID}, true
+	}
+	if token := ctx.GetHeader(CartTokenHeader); token != "" {
+		if cartID, err := ParseCartToken(token); err == nil {
+			return cartOwner{guestCartID: cartID}, true
+		}
+	}
+	return cartOwner{}, false
+}
+
 func (c *CartController) GetCart(ctx *gin.Context) {
-	userID, exists := ctx.Get("userID")
-    if !exists {
-    ctx.JSON(http.StatusUnauthorized, gin.H{
-        "error": "User not authenticated",
-    })
-    return
-    }
-
-// Convert to uint (since middleware sets it as uint)
-    userIDUint := userID.(uint)
-
-	cart, err := c.cartService.GetCartByUserID(userIDUint)
+	owner, ok := c.owner(ctx)
+	if !ok {
+		// Nothing added yet; the first AddItemToCart issues a cart token
+		ctx.JSON(http.StatusOK, gin.H{
+			"cart":  &Cart{Items: []CartItem{}},
+			"total": 0,
+		})
+		return
+	}
+
+	var cart *Cart
+	var err error
+	if owner.userID != 0 {
+		cart, err = c.cartService.GetCartByUserID(owner.userID)
+	} else {
+		cart, err = c.cartService.GetGuestCart(owner.guestCartID)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to retrieve cart",
@@ -41,22 +73,12 @@ func (c *CartController) GetCart(ctx *gin.Context) {
 }
 
 type addItemRequest struct {
-	ProductID uint `json:"product_id" binding:"required"`
-	Quantity  int  `json:"quantity" binding:"required,min=1"`
+	ProductID uint  `json:"product_id" binding:"required"`
+	VariantID *uint `json:"variant_id"` // Required for products with variants
+	Quantity  int   `json:"quantity" binding:"required,min=1"`
 }
 
 func (c *CartController) AddItemToCart(ctx *gin.Context) {
-	userID, exists := ctx.Get("userID")
-    if !exists {
-    ctx.JSON(http.StatusUnauthorized, gin.H{
-        "error": "User not authenticated",
-    })
-    return
-    }
-
-// Convert to uint (since middleware sets it as uint)
-    userIDUint := userID.(uint)
-
 	var req addItemRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
@@ -65,7 +87,44 @@ func (c *CartController) AddItemToCart(ctx *gin.Context) {
 		return
 	}
 
-	cart, err := c.cartService.AddItemToCart(userIDUint, req.ProductID, req.Quantity)
+	owner, ok := c.owner(ctx)
+	if owner.userID != 0 {
+		cart, err := c.cartService.AddItemToCart(owner.userID, req.ProductID, req.VariantID, req.Quantity)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message": "Item added to cart",
+			"cart":    cart,
+			"total":   cart.CalculateTotal(),
+		})
+		return
+	}
+
+	// ✅ Guests get a cart on their first item, identified by a signed token
+	if !ok {
+		guestCart, err := c.cartService.CreateGuestCart()
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to create cart",
+			})
+			return
+		}
+		owner.guestCartID = guestCart.ID
+	}
+	token, err := SignCartToken(owner.guestCartID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create cart token",
+		})
+		return
+	}
+
+	cart, err := c.cartService.AddItemToGuestCart(owner.guestCartID, req.ProductID, req.VariantID, req.Quantity)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
@@ -73,10 +132,12 @@ func (c *CartController) AddItemToCart(ctx *gin.Context) {
 		return
 	}
 
+	ctx.Header(CartTokenHeader, token)
 	ctx.JSON(http.StatusOK, gin.H{
-		"message": "Item added to cart",
-		"cart":    cart,
-		"total":   cart.CalculateTotal(),
+		"message":    "Item added to cart",
+		"cart":       cart,
+		"total":      cart.CalculateTotal(),
+		"cart_token": token,
 	})
 }
 
@@ -85,16 +146,13 @@ type updateItemRequest struct {
 }
 
 func (c *CartController) UpdateCartItem(ctx *gin.Context) {
-	userID, exists := ctx.Get("userID")
-    if !exists {
-    ctx.JSON(http.StatusUnauthorized, gin.H{
-        "error": "User not authenticated",
-    })
-    return
-    }
-
-// Convert to uint (since middleware sets it as uint)
-    userIDUint := userID.(uint)
+	owner, ok := c.owner(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Login or a cart token is required",
+		})
+		return
+	}
 
 	itemID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
 	if err != nil {
@@ -112,7 +170,12 @@ func (c *CartController) UpdateCartItem(ctx *gin.Context) {
 		return
 	}
 
-	cart, err := c.cartService.UpdateCartItem(userIDUint, uint(itemID), req.Quantity)
+	var cart *Cart
+	if owner.userID != 0 {
+		cart, err = c.cartService.UpdateCartItem(owner.userID, uint(itemID), req.Quantity)
+	} else {
+		cart, err = c.cartService.UpdateGuestCartItem(owner.guestCartID, uint(itemID), req.Quantity)
+	}
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
@@ -128,16 +191,14 @@ func (c *CartController) UpdateCartItem(ctx *gin.Context) {
 }
 
 func (c *CartController) RemoveCartItem(ctx *gin.Context) {
-	userID, exists := ctx.Get("userID")
-    if !exists {
-    ctx.JSON(http.StatusUnauthorized, gin.H{
-        "error": "User not authenticated",
-    })
-    return
-    }
-
-// Convert to uint (since middleware sets it as uint)
-    userIDUint := userID.(uint)
+	owner, ok := c.owner(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Login or a cart token is required",
+		})
+		return
+	}
+
 	itemID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
@@ -146,7 +207,12 @@ func (c *CartController) RemoveCartItem(ctx *gin.Context) {
 		return
 	}
 
-	cart, err := c.cartService.RemoveCartItem(userIDUint, uint(itemID))
+	var cart *Cart
+	if owner.userID != 0 {
+		cart, err = c.cartService.RemoveCartItem(owner.userID, uint(itemID))
+	} else {
+		cart, err = c.cartService.RemoveGuestCartItem(owner.guestCartID, uint(itemID))
+	}
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
@@ -162,15 +228,20 @@ func (c *CartController) RemoveCartItem(ctx *gin.Context) {
 }
 
 func (c *CartController) ClearCart(ctx *gin.Context) {
-	userID, exists := ctx.Get("userID")
-	if !exists {
+	owner, ok := c.owner(ctx)
+	if !ok {
 		ctx.JSON(http.StatusUnauthorized, gin.H{
 			"error": "You must be logged in to clear your cart",
 		})
 		return
 	}
 
-	err := c.cartService.ClearCart(userID.(uint))
+	var err error
+	if owner.userID != 0 {
+		err = c.cartService.ClearCart(owner.userID)
+	} else {
+		err = c.cartService.ClearGuestCart(owner.guestCartID)
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to clear cart",
@@ -7,7 +7,7 @@ import (
 
 type Cart struct {
 	ID        uint       `gorm:"primaryKey" json:"id"`
-	UserID    uint       `json:"user_id"`
+	UserID    *uint      `json:"user_id"` // nil for guest carts
 	
 	Items     []CartItem `gorm:"foreignKey:CartID" json:"items"`
 	CreatedAt time.Time  `json:"created_at"`
@@ -28,6 +28,9 @@ type CartItem struct {
 	CartID    uint            `json:"cart_id"`
 	ProductID uint            `json:"product_id"`
 	Product   catalog.Product `json:"product" gorm:"foreignKey:ProductID"`
+	VariantID *uint           `json:"variant_id,omitempty"`
+	Variant   *catalog.ProductVariant `json:"variant,omitempty" gorm:"foreignKey:VariantID"`
+	Options   catalog.VariantOptions  `json:"options,omitempty" gorm:"type:jsonb"` // Variant's options at time of adding
 	Quantity  int             `json:"quantity"`
 	Price     float64         `json:"price"` // Price at time of adding
 	CreatedAt time.Time       `json:"created_at"`
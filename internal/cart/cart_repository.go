@@ -2,10 +2,12 @@ package cart
 
 import (
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CartRepository interface {
 	FindByUserID(userID uint) (*Cart, error)
+	FindGuestCartByID(cartID uint) (*Cart, error)
 	Create(cart *Cart) error
 	AddItem(item *CartItem) error
 	UpdateItem(itemID uint, quantity int) error
@@ -25,7 +27,7 @@ func NewCartRepository(db *gorm.DB) CartRepository {
 func (r *cartRepository) FindByUserID(userID uint) (*Cart, error) {
 	var cart Cart
 
-	err := r.db.Where("user_id = ?", userID).Preload("Items.Product").First(&cart).Error
+	err := r.db.Where("user_id = ?", userID).Preload("Items.Product").Preload("Items.Variant").First(&cart).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil // No cart found, but not an error
@@ -35,12 +37,27 @@ func (r *cartRepository) FindByUserID(userID uint) (*Cart, error) {
 	return &cart, nil
 }
 
+// FindGuestCartByID only matches carts without an owner, so a cart token
+// can never reach a user's cart.
+func (r *cartRepository) FindGuestCartByID(cartID uint) (*Cart, error) {
+	var cart Cart
+
+	err := r.db.Where("id = ? AND user_id IS NULL", cartID).Preload("Items.Product").Preload("Items.Variant").First(&cart).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &cart, nil
+}
+
 func (r *cartRepository) Create(cart *Cart) error {
 	return r.db.Create(cart).Error
 }
 
 func (r *cartRepository) AddItem(item *CartItem) error {
-	return r.db.Create(item).Error
+	return r.db.Omit(clause.Associations).Create(item).Error
 }
 
 func (r *cartRepository) UpdateItem(itemID uint, quantity int) error {
@@ -57,7 +74,7 @@ func (r *cartRepository) ClearCart(cartID uint) error {
 
 func (r *cartRepository) FindCartItemByID(itemID uint) (*CartItem, error) {
 	var item CartItem
-	err := r.db.Preload("Product").First(&item, itemID).Error
+	err := r.db.Preload("Product").Preload("Variant").First(&item, itemID).Error
 	if err != nil {
 		return nil, err
 	}
@@ -1,8 +1,9 @@
 package cart
 
 import (
+	"ecommerce/internal/auth"
+
 	"github.com/gin-gonic/gin"
-	//"ecommerce/internal/auth"
 )
 
 func SetupCartRoutes(
@@ -11,9 +12,9 @@ func SetupCartRoutes(
 
 	v1 := router.Group("/api/v1")
 
-	// Cart routes - these should be protected by auth middleware in production
+	// Cart routes work for logged-in users and for guests with a cart token
 	cart := v1.Group("/cart")
-	// cart.Use(auth.AuthMiddleware()) // Add this line
+	cart.Use(auth.OptionalJWTAuthMiddleware())
 	// {
 	cart.GET("", cartController.GetCart)
 	cart.POST("/items", cartController.AddItemToCart)
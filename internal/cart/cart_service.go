@@ -7,10 +7,18 @@ import (
 
 type CartService interface {
 	GetCartByUserID(userID uint) (*Cart, error)
-	AddItemToCart(userID uint, productID uint, quantity int) (*Cart, error)
+	AddItemToCart(userID uint, productID uint, variantID *uint, quantity int) (*Cart, error)
 	UpdateCartItem(userID uint, itemID uint, quantity int) (*Cart, error)
 	RemoveCartItem(userID uint, itemID uint) (*Cart, error)
 	ClearCart(userID uint) error
+
+	// Guest carts are identified by cart ID, taken from a signed cart token
+	CreateGuestCart() (*Cart, error)
+	GetGuestCart(cartID uint) (*Cart, error)
+	AddItemToGuestCart(cartID uint, productID uint, variantID *uint, quantity int) (*Cart, error)
+	UpdateGuestCartItem(cartID uint, itemID uint, quantity int) (*Cart, error)
+	RemoveGuestCartItem(cartID uint, itemID uint) (*Cart, error)
+	ClearGuestCart(cartID uint) error
 }
 
 type cartService struct {
@@ -29,23 +37,17 @@ func (s *cartService) GetCartByUserID(userID uint) (*Cart, error) {
 	}
 
 	if cart == nil {
-		return &Cart{UserID: userID, Items: []CartItem{}}, nil
+		return &Cart{UserID: &userID, Items: []CartItem{}}, nil
 	}
 
 	return cart, nil
 }
 
-func (s *cartService) AddItemToCart(userID uint, productID uint, quantity int) (*Cart, error) {
+func (s *cartService) AddItemToCart(userID uint, productID uint, variantID *uint, quantity int) (*Cart, error) {
 	if quantity <= 0 {
 		return nil, errors.New("quantity must be positive")
 	}
 
-	// Check if product exists
-	product, err := s.productRepo.FindByID(productID)
-	if err != nil {
-		return nil, errors.New("product not found")
-	}
-
 	// Get or create cart
 	cart, err := s.repo.FindByUserID(userID)
 	if err != nil {
@@ -53,35 +55,13 @@ func (s *cartService) AddItemToCart(userID uint, productID uint, quantity int) (
 	}
 
 	if cart == nil {
-		cart = &Cart{UserID: userID}
+		cart = &Cart{UserID: &userID}
 		if err := s.repo.Create(cart); err != nil {
 			return nil, err
 		}
 	}
 
-	// Check if item already exists in cart
-	for i, item := range cart.Items {
-		if item.ProductID == productID {
-			// Update quantity
-			cart.Items[i].Quantity += quantity
-			err := s.repo.UpdateItem(item.ID, cart.Items[i].Quantity)
-			if err != nil {
-				return nil, err
-			}
-			return s.GetCartByUserID(userID)
-		}
-	}
-
-	// Add new item
-	cartItem := &CartItem{
-		CartID:    cart.ID,
-		ProductID: productID,
-		Product:   *product,
-		Quantity:  quantity,
-		Price:     product.Price,
-	}
-
-	if err := s.repo.AddItem(cartItem); err != nil {
+	if err := s.addItem(cart, productID, variantID, quantity); err != nil {
 		return nil, err
 	}
 
@@ -99,51 +79,108 @@ func (s *cartService) UpdateCartItem(userID uint, itemID uint, quantity int) (*C
 		return nil, errors.New("cart not found")
 	}
 
-	// Check if item belongs to user's cart
-	item, err := s.repo.FindCartItemByID(itemID)
-	if err != nil {
-		return nil, errors.New("item not found")
+	if err := s.updateItem(cart, itemID, quantity); err != nil {
+		return nil, err
 	}
 
-	if item.CartID != cart.ID {
-		return nil, errors.New("item does not belong to user's cart")
+	return s.GetCartByUserID(userID)
+}
+
+func (s *cartService) RemoveCartItem(userID uint, itemID uint) (*Cart, error) {
+	// Check if cart exists
+	cart, err := s.repo.FindByUserID(userID)
+	if err != nil || cart == nil {
+		return nil, errors.New("cart not found")
 	}
 
-	// Update item quantity
-	if err := s.repo.UpdateItem(itemID, quantity); err != nil {
+	if err := s.removeItem(cart, itemID); err != nil {
 		return nil, err
 	}
 
 	return s.GetCartByUserID(userID)
 }
 
-func (s *cartService) RemoveCartItem(userID uint, itemID uint) (*Cart, error) {
-	// Check if cart exists
+func (s *cartService) ClearCart(userID uint) error {
 	cart, err := s.repo.FindByUserID(userID)
-	if err != nil || cart == nil {
+	if err != nil {
+		return err
+	}
+
+	if cart == nil {
+		return nil // No cart to clear
+	}
+
+	return s.repo.ClearCart(cart.ID)
+}
+
+func (s *cartService) CreateGuestCart() (*Cart, error) {
+	cart := &Cart{Items: []CartItem{}}
+	if err := s.repo.Create(cart); err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+func (s *cartService) GetGuestCart(cartID uint) (*Cart, error) {
+	cart, err := s.repo.FindGuestCartByID(cartID)
+	if err != nil {
+		return nil, err
+	}
+	if cart == nil {
 		return nil, errors.New("cart not found")
 	}
+	return cart, nil
+}
 
-	// Check if item belongs to user's cart
-	item, err := s.repo.FindCartItemByID(itemID)
+func (s *cartService) AddItemToGuestCart(cartID uint, productID uint, variantID *uint, quantity int) (*Cart, error) {
+	if quantity <= 0 {
+		return nil, errors.New("quantity must be positive")
+	}
+
+	cart, err := s.GetGuestCart(cartID)
 	if err != nil {
-		return nil, errors.New("item not found")
+		return nil, err
 	}
 
-	if item.CartID != cart.ID {
-		return nil, errors.New("item does not belong to user's cart")
+	if err := s.addItem(cart, productID, variantID, quantity); err != nil {
+		return nil, err
 	}
 
-	// Remove item
-	if err := s.repo.RemoveItem(itemID); err != nil {
+	return s.GetGuestCart(cartID)
+}
+
+func (s *cartService) UpdateGuestCartItem(cartID uint, itemID uint, quantity int) (*Cart, error) {
+	if quantity <= 0 {
+		return nil, errors.New("quantity must be positive")
+	}
+
+	cart, err := s.GetGuestCart(cartID)
+	if err != nil {
 		return nil, err
 	}
 
-	return s.GetCartByUserID(userID)
+	if err := s.updateItem(cart, itemID, quantity); err != nil {
+		return nil, err
+	}
+
+	return s.GetGuestCart(cartID)
 }
 
-func (s *cartService) ClearCart(userID uint) error {
-	cart, err := s.repo.FindByUserID(userID)
+func (s *cartService) RemoveGuestCartItem(cartID uint, itemID uint) (*Cart, error) {
+	cart, err := s.GetGuestCart(cartID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.removeItem(cart, itemID); err != nil {
+		return nil, err
+	}
+
+	return s.GetGuestCart(cartID)
+}
+
+func (s *cartService) ClearGuestCart(cartID uint) error {
+	cart, err := s.repo.FindGuestCartByID(cartID)
 	if err != nil {
 		return err
 	}
@@ -154,3 +191,85 @@ func (s *cartService) ClearCart(userID uint) error {
 
 	return s.repo.ClearCart(cart.ID)
 }
+
+func (s *cartService) addItem(cart *Cart, productID uint, variantID *uint, quantity int) error {
+	// Check if product exists
+	product, err := s.productRepo.FindByID(productID)
+	if err != nil {
+		return errors.New("product not found")
+	}
+
+	// ✅ Products with variants are bought as one of them, at its price
+	price := product.Price
+	var options catalog.VariantOptions
+	if variantID != nil || len(product.Variants) > 0 {
+		if variantID == nil {
+			return catalog.ErrVariantRequired
+		}
+		variant := product.FindVariant(*variantID)
+		if variant == nil {
+			return catalog.ErrVariantNotFound
+		}
+		price = variant.EffectivePrice(product)
+		options = variant.Options
+	}
+
+	// Check if item already exists in cart
+	for i, item := range cart.Items {
+		if item.ProductID == productID && sameVariant(item.VariantID, variantID) {
+			// Update quantity
+			cart.Items[i].Quantity += quantity
+			return s.repo.UpdateItem(item.ID, cart.Items[i].Quantity)
+		}
+	}
+
+	// Add new item
+	cartItem := &CartItem{
+		CartID:    cart.ID,
+		ProductID: productID,
+		Product:   *product,
+		VariantID: variantID,
+		Options:   options,
+		Quantity:  quantity,
+		Price:     price,
+	}
+
+	return s.repo.AddItem(cartItem)
+}
+
+func sameVariant(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func (s *cartService) updateItem(cart *Cart, itemID uint, quantity int) error {
+	// Check if item belongs to the cart
+	item, err := s.repo.FindCartItemByID(itemID)
+	if err != nil {
+		return errors.New("item not found")
+	}
+
+	if item.CartID != cart.ID {
+		return errors.New("item does not belong to user's cart")
+	}
+
+	// Update item quantity
+	return s.repo.UpdateItem(itemID, quantity)
+}
+
+func (s *cartService) removeItem(cart *Cart, itemID uint) error {
+	// Check if item belongs to the cart
+	item, err := s.repo.FindCartItemByID(itemID)
+	if err != nil {
+		return errors.New("item not found")
+	}
+
+	if item.CartID != cart.ID {
+		return errors.New("item does not belong to user's cart")
+	}
+
+	// Remove item
+	return s.repo.RemoveItem(itemID)
+}
@@ -0,0 +1,77 @@
+package cart
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CartTokenHeader carries a guest's cart token on cart and checkout requests.
+const CartTokenHeader = "X-Cart-Token"
+
+// cartTokenTTL is how long a guest cart stays reachable from its token.
+const cartTokenTTL = 30 * 24 * time.Hour
+
+var ErrInvalidCartToken = errors.New("invalid or expired cart token")
+
+// cartTokenSecret signs guest cart tokens. CART_TOKEN_SECRET lets it differ
+// from the JWT secret; otherwise JWT_SECRET is used.
+func cartTokenSecret() ([]byte, error) {
+	secret := os.Getenv("CART_TOKEN_SECRET")
+	if secret == "" {
+		secret = os.Getenv("JWT_SECRET")
+	}
+	if secret == "" {
+		return nil, errors.New("CART_TOKEN_SECRET or JWT_SECRET environment variable is required")
+	}
+	return []byte(secret), nil
+}
+
+func signCartPayload(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// SignCartToken returns a token that grants access to guest cart cartID.
+func SignCartToken(cartID uint) (string, error) {
+	secret, err := cartTokenSecret()
+	if err != nil {
+		return "", err
+	}
+	payload := fmt.Sprintf("%d.%d", cartID, time.Now().Add(cartTokenTTL).Unix())
+	return payload + "." + signCartPayload(secret, payload), nil
+}
+
+// ParseCartToken verifies a token from SignCartToken and returns its cart ID.
+func ParseCartToken(token string) (uint, error) {
+	secret, err := cartTokenSecret()
+	if err != nil {
+		return 0, err
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, ErrInvalidCartToken
+	}
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(signCartPayload(secret, payload))) {
+		return 0, ErrInvalidCartToken
+	}
+
+	cartID, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return 0, ErrInvalidCartToken
+	}
+	expiresAt, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return 0, ErrInvalidCartToken
+	}
+	return uint(cartID), nil
+}
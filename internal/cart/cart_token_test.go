@@ -0,0 +1,52 @@
+package cart
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCartTokenRoundTrip(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	token, err := SignCartToken(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cartID, err := ParseCartToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cartID != 42 {
+		t.Errorf("cart ID = %d, want 42", cartID)
+	}
+}
+
+func TestParseCartTokenRejectsForgeries(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	token, err := SignCartToken(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(token, ".")
+	secret, _ := cartTokenSecret()
+	expiredPayload := fmt.Sprintf("42.%d", time.Now().Add(-time.Minute).Unix())
+
+	for name, forged := range map[string]string{
+		"other cart": "43." + parts[1] + "." + parts[2],
+		"extended":   parts[0] + ".99999999999." + parts[2],
+		"unsigned":   parts[0] + "." + parts[1],
+		"expired":    expiredPayload + "." + signCartPayload(secret, expiredPayload),
+		"empty":      "",
+	} {
+		if _, err := ParseCartToken(forged); !errors.Is(err, ErrInvalidCartToken) {
+			t.Errorf("%s: got %v, want ErrInvalidCartToken", name, err)
+		}
+	}
+
+	t.Setenv("CART_TOKEN_SECRET", "other-secret")
+	if _, err := ParseCartToken(token); !errors.Is(err, ErrInvalidCartToken) {
+		t.Errorf("other secret: got %v, want ErrInvalidCartToken", err)
+	}
+}
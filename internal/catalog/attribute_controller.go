@@ -0,0 +1,92 @@
+package catalog
+
+import (
+	"ecommerce/internal/audit"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAttributeSchema returns the attributes products in the given category,
+// subcategory and sub-subcategory have, e.g. to build the product form.
+func (c *ProductController) GetAttributeSchema(ctx *gin.Context) {
+	var query AttributeSchemaQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	schema, err := c.productService.AttributeSchema(query.CategoryID, query.SubCategoryID, query.SubSubCategoryID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve attribute schema",
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"attributes": schema})
+}
+
+func (c *ProductController) CreateAttributeDefinition(ctx *gin.Context) {
+	var req CreateAttributeDefinitionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	definition, err := c.productService.CreateAttributeDefinition(audit.ActorFromContext(ctx), req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrAttributeExists) {
+			status = http.StatusConflict
+		}
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"attribute": definition})
+}
+
+func (c *ProductController) UpdateAttributeDefinition(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid attribute ID format",
+		})
+		return
+	}
+	var req UpdateAttributeDefinitionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	definition, err := c.productService.UpdateAttributeDefinition(audit.ActorFromContext(ctx), uint(id), req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrAttributeDefinitionNotFound) {
+			status = http.StatusNotFound
+		}
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"attribute": definition})
+}
+
+func (c *ProductController) DeleteAttributeDefinition(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid attribute ID format",
+		})
+		return
+	}
+
+	if err := c.productService.DeleteAttributeDefinition(audit.ActorFromContext(ctx), uint(id)); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrAttributeDefinitionNotFound) {
+			status = http.StatusNotFound
+		}
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Attribute deleted successfully"})
+}
@@ -0,0 +1,115 @@
+package catalog
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Attribute types.
+const (
+	AttributeText    = "text"
+	AttributeNumber  = "number"
+	AttributeEnum    = "enum"
+	AttributeBoolean = "boolean"
+)
+
+const (
+	maxAttributeTextLength  = 500
+	maxAttributeFilters     = 10
+	maxAttributeFacetValues = 20
+)
+
+// attributeKeyPattern is the format of attribute keys, e.g. sleeve_length.
+var attributeKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+var (
+	ErrInvalidAttribute            = errors.New("invalid attribute")
+	ErrInvalidAttributeFilter      = errors.New("invalid attribute filter")
+	ErrInvalidAttributeSchema      = errors.New("invalid attribute definition")
+	ErrAttributeExists             = errors.New("attribute is already defined for this category")
+	ErrAttributeDefinitionNotFound = errors.New("attribute definition not found")
+)
+
+// AttributeDefinition is one typed attribute of the products in a category,
+// subcategory or sub-subcategory; exactly one of the three is set. A product
+// has the attributes of every level it is in, and a lower level's definition
+// of a key replaces a higher one's.
+type AttributeDefinition struct {
+	ID       uint           `json:"id" gorm:"primaryKey"`
+	Key      string         `json:"key" gorm:"not null"`
+	Label    string         `json:"label" gorm:"not null"`
+	Type     string         `json:"type" gorm:"not null"`
+	Unit     string         `json:"unit,omitempty"`                       // e.g. cm, for numbers
+	Options  pq.StringArray `json:"options,omitempty" gorm:"type:text[]"` // Enum values
+	Required bool           `json:"required"`
+	Position int            `json:"position"`
+
+	CategoryID       *uint `json:"category_id,omitempty"`
+	SubCategoryID    *uint `json:"sub_category_id,omitempty"`
+	SubSubCategoryID *uint `json:"sub_sub_category_id,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProductAttributes are a product's attribute values by key, stored as
+// jsonb: strings for text and enum, numbers and booleans.
+type ProductAttributes map[string]interface{}
+
+func (a ProductAttributes) Value() (driver.Value, error) {
+	if a == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(a)
+}
+
+func (a *ProductAttributes) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type for product attributes")
+	}
+	return json.Unmarshal(data, a)
+}
+
+type CreateAttributeDefinitionRequest struct {
+	Key              string   `json:"key" binding:"required"`
+	Label            string   `json:"label" binding:"required,max=100"`
+	Type             string   `json:"type" binding:"required,oneof=text number enum boolean"`
+	Unit             string   `json:"unit" binding:"max=20"`
+	Options          []string `json:"options"`
+	Required         bool     `json:"required"`
+	Position         int      `json:"position"`
+	CategoryID       *uint    `json:"category_id"`
+	SubCategoryID    *uint    `json:"sub_category_id"`
+	SubSubCategoryID *uint    `json:"sub_sub_category_id"`
+}
+
+// UpdateAttributeDefinitionRequest changes the given fields. The key, type
+// and category stay, as products already store values for them.
+type UpdateAttributeDefinitionRequest struct {
+	Label    string   `json:"label" binding:"max=100"`
+	Unit     *string  `json:"unit" binding:"omitempty,max=20"`
+	Options  []string `json:"options"`
+	Required *bool    `json:"required"`
+	Position *int     `json:"position"`
+}
+
+// AttributeSchemaQuery picks the category levels to get the schema of.
+type AttributeSchemaQuery struct {
+	CategoryID       uint  `form:"category_id" binding:"required"`
+	SubCategoryID    *uint `form:"sub_category_id"`
+	SubSubCategoryID *uint `form:"sub_sub_category_id"`
+}
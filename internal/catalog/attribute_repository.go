@@ -0,0 +1,44 @@
+package catalog
+
+func (r *productRepository) FindAttributeSchema(categoryID uint, subCategoryID, subSubCategoryID *uint) ([]AttributeDefinition, error) {
+	db := r.db.Where("category_id = ?", categoryID)
+	if subCategoryID != nil {
+		db = db.Or("sub_category_id = ?", *subCategoryID)
+	}
+	if subSubCategoryID != nil {
+		db = db.Or("sub_sub_category_id = ?", *subSubCategoryID)
+	}
+
+	var definitions []AttributeDefinition
+	err := db.Order("sub_sub_category_id IS NOT NULL, sub_category_id IS NOT NULL, position, id").
+		Find(&definitions).Error
+	return definitions, err
+}
+
+// FindEnumAttributeDefinitions returns the enum definitions of the given
+// keys in any category.
+func (r *productRepository) FindEnumAttributeDefinitions(keys []string) ([]AttributeDefinition, error) {
+	var definitions []AttributeDefinition
+	err := r.db.Where("type = ? AND key IN ?", AttributeEnum, keys).Find(&definitions).Error
+	return definitions, err
+}
+
+func (r *productRepository) FindAttributeDefinitionByID(id uint) (*AttributeDefinition, error) {
+	var definition AttributeDefinition
+	if err := r.db.First(&definition, id).Error; err != nil {
+		return nil, err
+	}
+	return &definition, nil
+}
+
+func (r *productRepository) CreateAttributeDefinition(definition *AttributeDefinition) error {
+	return r.db.Create(definition).Error
+}
+
+func (r *productRepository) UpdateAttributeDefinition(definition *AttributeDefinition) error {
+	return r.db.Save(definition).Error
+}
+
+func (r *productRepository) DeleteAttributeDefinition(id uint) error {
+	return r.db.Delete(&AttributeDefinition{}, id).Error
+}
@@ -0,0 +1,316 @@
+package catalog
+
+import (
+	"ecommerce/internal/audit"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+)
+
+// AttributeSchema returns the attributes of products in the given category
+// levels, with lower levels' definitions replacing higher ones' of the same
+// key.
+func (s *productService) AttributeSchema(categoryID uint, subCategoryID, subSubCategoryID *uint) ([]AttributeDefinition, error) {
+	definitions, err := s.repo.FindAttributeSchema(categoryID, subCategoryID, subSubCategoryID)
+	if err != nil {
+		return nil, err
+	}
+	schema := make([]AttributeDefinition, 0, len(definitions))
+	index := make(map[string]int, len(definitions))
+	for _, definition := range definitions {
+		if i, ok := index[definition.Key]; ok {
+			schema[i] = definition
+			continue
+		}
+		index[definition.Key] = len(schema)
+		schema = append(schema, definition)
+	}
+	return schema, nil
+}
+
+func (s *productService) CreateAttributeDefinition(actor audit.Actor, req CreateAttributeDefinitionRequest) (*AttributeDefinition, error) {
+	definition := &AttributeDefinition{
+		Key:              strings.TrimSpace(req.Key),
+		Label:            strings.TrimSpace(req.Label),
+		Type:             req.Type,
+		Unit:             strings.TrimSpace(req.Unit),
+		Required:         req.Required,
+		Position:         req.Position,
+		CategoryID:       req.CategoryID,
+		SubCategoryID:    req.SubCategoryID,
+		SubSubCategoryID: req.SubSubCategoryID,
+	}
+	if !attributeKeyPattern.MatchString(definition.Key) {
+		return nil, fmt.Errorf("%w: key must be lowercase letters, digits and underscores", ErrInvalidAttributeSchema)
+	}
+	options, err := attributeOptions(definition.Type, definition.Unit, req.Options)
+	if err != nil {
+		return nil, err
+	}
+	definition.Options = options
+
+	// ✅ Defined on exactly one level, which must exist
+	var levelDefinitions []AttributeDefinition
+	switch {
+	case req.CategoryID != nil && req.SubCategoryID == nil && req.SubSubCategoryID == nil:
+		if _, err := s.repo.FindCategoryByID(*req.CategoryID); err != nil {
+			return nil, errors.New("category not found")
+		}
+		levelDefinitions, err = s.repo.FindAttributeSchema(*req.CategoryID, nil, nil)
+	case req.CategoryID == nil && req.SubCategoryID != nil && req.SubSubCategoryID == nil:
+		if _, err := s.repo.FindSubCategoryByID(*req.SubCategoryID); err != nil {
+			return nil, errors.New("subcategory not found")
+		}
+		levelDefinitions, err = s.repo.FindAttributeSchema(0, req.SubCategoryID, nil)
+	case req.CategoryID == nil && req.SubCategoryID == nil && req.SubSubCategoryID != nil:
+		if _, err := s.repo.FindSubSubCategoryByID(*req.SubSubCategoryID); err != nil {
+			return nil, errors.New("sub-subcategory not found")
+		}
+		levelDefinitions, err = s.repo.FindAttributeSchema(0, nil, req.SubSubCategoryID)
+	default:
+		return nil, fmt.Errorf("%w: set exactly one of category_id, sub_category_id and sub_sub_category_id", ErrInvalidAttributeSchema)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range levelDefinitions {
+		if existing.Key == definition.Key {
+			return nil, ErrAttributeExists
+		}
+	}
+
+	if err := s.repo.CreateAttributeDefinition(definition); err != nil {
+		return nil, err
+	}
+	s.audit.Record(actor, audit.ActionAttributeCreate, "attribute", definition.ID, nil, definition)
+	return definition, nil
+}
+
+func (s *productService) UpdateAttributeDefinition(actor audit.Actor, id uint, req UpdateAttributeDefinitionRequest) (*AttributeDefinition, error) {
+	definition, err := s.repo.FindAttributeDefinitionByID(id)
+	if err != nil {
+		return nil, ErrAttributeDefinitionNotFound
+	}
+	before := *definition
+
+	if label := strings.TrimSpace(req.Label); label != "" {
+		definition.Label = label
+	}
+	if req.Unit != nil {
+		definition.Unit = strings.TrimSpace(*req.Unit)
+	}
+	if req.Required != nil {
+		definition.Required = *req.Required
+	}
+	if req.Position != nil {
+		definition.Position = *req.Position
+	}
+	if req.Options != nil || req.Unit != nil {
+		options := req.Options
+		if options == nil {
+			options = definition.Options
+		}
+		if definition.Options, err = attributeOptions(definition.Type, definition.Unit, options); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.UpdateAttributeDefinition(definition); err != nil {
+		return nil, err
+	}
+	s.audit.Record(actor, audit.ActionAttributeUpdate, "attribute", id, before, definition)
+	return definition, nil
+}
+
+// DeleteAttributeDefinition removes a definition. Products keep their stored
+// values, but have to drop them the next time their attributes are updated.
+func (s *productService) DeleteAttributeDefinition(actor audit.Actor, id uint) error {
+	definition, err := s.repo.FindAttributeDefinitionByID(id)
+	if err != nil {
+		return ErrAttributeDefinitionNotFound
+	}
+	if err := s.repo.DeleteAttributeDefinition(id); err != nil {
+		return err
+	}
+	s.audit.Record(actor, audit.ActionAttributeDelete, "attribute", id, definition, nil)
+	return nil
+}
+
+// attributeOptions checks the enum values of a definition: enums need at
+// least one, other types none. Units only apply to numbers.
+func attributeOptions(attributeType, unit string, values []string) (pq.StringArray, error) {
+	if unit != "" && attributeType != AttributeNumber {
+		return nil, fmt.Errorf("%w: only number attributes have a unit", ErrInvalidAttributeSchema)
+	}
+	if attributeType != AttributeEnum {
+		if len(values) > 0 {
+			return nil, fmt.Errorf("%w: only enum attributes have options", ErrInvalidAttributeSchema)
+		}
+		return pq.StringArray{}, nil
+	}
+
+	options := pq.StringArray{}
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" || seen[strings.ToLower(value)] {
+			continue
+		}
+		seen[strings.ToLower(value)] = true
+		options = append(options, value)
+	}
+	if len(options) == 0 {
+		return nil, fmt.Errorf("%w: enum attributes need options", ErrInvalidAttributeSchema)
+	}
+	return options, nil
+}
+
+// validateAttributes checks a product's attribute values against the schema
+// of its categories and returns them normalized. Null values are dropped.
+func (s *productService) validateAttributes(categoryID uint, subCategoryID, subSubCategoryID *uint, values map[string]interface{}) (ProductAttributes, error) {
+	schema, err := s.AttributeSchema(categoryID, subCategoryID, subSubCategoryID)
+	if err != nil {
+		return nil, err
+	}
+	definitions := make(map[string]AttributeDefinition, len(schema))
+	for _, definition := range schema {
+		definitions[definition.Key] = definition
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attributes := ProductAttributes{}
+	for _, key := range keys {
+		definition, ok := definitions[key]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not an attribute of this category", ErrInvalidAttribute, key)
+		}
+		if values[key] == nil {
+			continue
+		}
+		value, err := normalizeAttribute(definition, values[key])
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			attributes[key] = value
+		}
+	}
+	for _, definition := range schema {
+		if _, ok := attributes[definition.Key]; definition.Required && !ok {
+			return nil, fmt.Errorf("%w: %s is required", ErrInvalidAttribute, definition.Key)
+		}
+	}
+	return attributes, nil
+}
+
+// normalizeAttribute checks value's type against definition. Empty text is
+// returned as nil, i.e. not set.
+func normalizeAttribute(definition AttributeDefinition, value interface{}) (interface{}, error) {
+	switch definition.Type {
+	case AttributeText:
+		text, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s must be text", ErrInvalidAttribute, definition.Key)
+		}
+		text = strings.TrimSpace(text)
+		if utf8.RuneCountInString(text) > maxAttributeTextLength {
+			return nil, fmt.Errorf("%w: %s must be at most %d characters", ErrInvalidAttribute, definition.Key, maxAttributeTextLength)
+		}
+		if text == "" {
+			return nil, nil
+		}
+		return text, nil
+	case AttributeNumber:
+		number, ok := value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s must be a number", ErrInvalidAttribute, definition.Key)
+		}
+		return number, nil
+	case AttributeEnum:
+		text, _ := value.(string)
+		for _, option := range definition.Options {
+			if strings.EqualFold(option, strings.TrimSpace(text)) {
+				return option, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: %s must be one of %s", ErrInvalidAttribute, definition.Key, strings.Join(definition.Options, ", "))
+	case AttributeBoolean:
+		flag, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s must be true or false", ErrInvalidAttribute, definition.Key)
+		}
+		return flag, nil
+	}
+	return nil, fmt.Errorf("%w: %s has unknown type %s", ErrInvalidAttribute, definition.Key, definition.Type)
+}
+
+// canonicalAttributeFilter rewrites enum values in filter to the options'
+// own spelling, as stored on products, so ?attr[fabric]=cotton matches
+// Cotton. Enum values are validated ignoring case, and a key can be an enum
+// with differently spelt options in several categories.
+func (s *productService) canonicalAttributeFilter(filter *ProductFilter) error {
+	if len(filter.Attributes) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(filter.Attributes))
+	for key := range filter.Attributes {
+		keys = append(keys, key)
+	}
+	definitions, err := s.repo.FindEnumAttributeDefinitions(keys)
+	if err != nil {
+		return err
+	}
+	if len(definitions) == 0 {
+		return nil
+	}
+	options := make(map[string][]string, len(definitions))
+	for _, definition := range definitions {
+		options[definition.Key] = append(options[definition.Key], definition.Options...)
+	}
+
+	canonical := make(map[string]string, len(filter.Attributes))
+	for key, value := range filter.Attributes {
+		if _, _, isRange, _ := parseAttributeRange(value); isRange || len(options[key]) == 0 {
+			canonical[key] = value
+			continue
+		}
+		var values []string
+		seen := make(map[string]bool)
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			matched := false
+			for _, option := range options[key] {
+				if strings.EqualFold(option, part) {
+					matched = true
+					if !seen[option] {
+						seen[option] = true
+						values = append(values, option)
+					}
+				}
+			}
+			if !matched && !seen[part] {
+				seen[part] = true
+				values = append(values, part)
+			}
+		}
+		canonical[key] = strings.Join(values, ",")
+	}
+	filter.Attributes = canonical
+	return nil
+}
+
+func sameCategoryID(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
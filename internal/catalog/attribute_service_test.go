@@ -0,0 +1,273 @@
+package catalog
+
+import (
+	"ecommerce/internal/audit"
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// fakeAttributeRepo returns the definitions of every level it is asked
+// about, category level first.
+type fakeAttributeRepo struct {
+	ProductRepository
+	category, subCategory []AttributeDefinition
+	created               []*AttributeDefinition
+}
+
+func (r *fakeAttributeRepo) FindAttributeSchema(categoryID uint, subCategoryID, subSubCategoryID *uint) ([]AttributeDefinition, error) {
+	var definitions []AttributeDefinition
+	if categoryID != 0 {
+		definitions = append(definitions, r.category...)
+	}
+	if subCategoryID != nil {
+		definitions = append(definitions, r.subCategory...)
+	}
+	return definitions, nil
+}
+
+func (r *fakeAttributeRepo) FindEnumAttributeDefinitions(keys []string) ([]AttributeDefinition, error) {
+	var definitions []AttributeDefinition
+	for _, definition := range append(r.category, r.subCategory...) {
+		for _, key := range keys {
+			if definition.Type == AttributeEnum && definition.Key == key {
+				definitions = append(definitions, definition)
+			}
+		}
+	}
+	return definitions, nil
+}
+
+func (r *fakeAttributeRepo) FindCategoryByID(id uint) (*Category, error) {
+	if id != 1 {
+		return nil, errors.New("record not found")
+	}
+	return &Category{ID: id}, nil
+}
+
+func (r *fakeAttributeRepo) CreateAttributeDefinition(definition *AttributeDefinition) error {
+	r.created = append(r.created, definition)
+	return nil
+}
+
+func newAttributeTestService() (*productService, *fakeAttributeRepo) {
+	repo := &fakeAttributeRepo{
+		category: []AttributeDefinition{
+			{Key: "fabric", Type: AttributeEnum, Options: []string{"Cotton", "Silk"}, Required: true},
+			{Key: "length", Type: AttributeNumber, Unit: "cm"},
+		},
+		subCategory: []AttributeDefinition{
+			{Key: "length", Type: AttributeText},
+			{Key: "handwoven", Type: AttributeBoolean},
+		},
+	}
+	return &productService{repo: repo, audit: nopAudit{}}, repo
+}
+
+func TestAttributeSchemaLowerLevelsReplaceHigher(t *testing.T) {
+	s, _ := newAttributeTestService()
+	subCategoryID := uint(2)
+
+	schema, err := s.AttributeSchema(1, &subCategoryID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schema) != 3 || schema[1].Key != "length" || schema[1].Type != AttributeText {
+		t.Errorf("schema = %+v, want the subcategory's length in the category's place", schema)
+	}
+}
+
+func TestValidateAttributes(t *testing.T) {
+	s, _ := newAttributeTestService()
+
+	attributes, err := s.validateAttributes(1, nil, nil, map[string]interface{}{
+		"fabric": " silk ",
+		"length": 72.5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Enum values are stored as the schema spells them
+	if attributes["fabric"] != "Silk" || attributes["length"] != 72.5 {
+		t.Errorf("attributes = %v", attributes)
+	}
+
+	tests := map[string]map[string]interface{}{
+		"required missing": {"length": 10.0},
+		"required null":    {"fabric": nil},
+		"unknown key":      {"fabric": "Silk", "colour": "red"},
+		"not an option":    {"fabric": "Polyester"},
+		"wrong type":       {"fabric": "Silk", "length": "long"},
+		"enum not text":    {"fabric": 3.0},
+	}
+	for name, values := range tests {
+		if _, err := s.validateAttributes(1, nil, nil, values); !errors.Is(err, ErrInvalidAttribute) {
+			t.Errorf("%s: got %v, want ErrInvalidAttribute", name, err)
+		}
+	}
+}
+
+func TestNormalizeAttribute(t *testing.T) {
+	text := AttributeDefinition{Key: "care", Type: AttributeText}
+	if value, err := normalizeAttribute(text, "  hand wash "); err != nil || value != "hand wash" {
+		t.Errorf("text = %v, %v", value, err)
+	}
+	if value, err := normalizeAttribute(text, "   "); err != nil || value != nil {
+		t.Errorf("blank text = %v, %v; want unset", value, err)
+	}
+	if _, err := normalizeAttribute(text, strings.Repeat("a", maxAttributeTextLength+1)); !errors.Is(err, ErrInvalidAttribute) {
+		t.Errorf("long text: got %v, want ErrInvalidAttribute", err)
+	}
+
+	flag := AttributeDefinition{Key: "handwoven", Type: AttributeBoolean}
+	if value, err := normalizeAttribute(flag, true); err != nil || value != true {
+		t.Errorf("boolean = %v, %v", value, err)
+	}
+	if _, err := normalizeAttribute(flag, "yes"); !errors.Is(err, ErrInvalidAttribute) {
+		t.Errorf("boolean as text: got %v, want ErrInvalidAttribute", err)
+	}
+}
+
+func TestAttributeOptions(t *testing.T) {
+	options, err := attributeOptions(AttributeEnum, "", []string{" Cotton", "cotton", "", "Silk"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(options) != 2 || options[0] != "Cotton" || options[1] != "Silk" {
+		t.Errorf("options = %v, want duplicates and blanks dropped", options)
+	}
+
+	for name, tt := range map[string]struct {
+		attributeType, unit string
+		values              []string
+	}{
+		"enum without options": {AttributeEnum, "", []string{" "}},
+		"options on text":      {AttributeText, "", []string{"a"}},
+		"unit on text":         {AttributeText, "cm", nil},
+	} {
+		if _, err := attributeOptions(tt.attributeType, tt.unit, tt.values); !errors.Is(err, ErrInvalidAttributeSchema) {
+			t.Errorf("%s: got %v, want ErrInvalidAttributeSchema", name, err)
+		}
+	}
+}
+
+func TestCreateAttributeDefinition(t *testing.T) {
+	s, repo := newAttributeTestService()
+	categoryID, subCategoryID := uint(1), uint(2)
+
+	definition, err := s.CreateAttributeDefinition(audit.Actor{}, CreateAttributeDefinitionRequest{
+		Key: "sleeve_length", Label: " Sleeve length ", Type: AttributeText, CategoryID: &categoryID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if definition.Label != "Sleeve length" || len(repo.created) != 1 {
+		t.Errorf("created %+v", definition)
+	}
+
+	tests := map[string]struct {
+		req  CreateAttributeDefinitionRequest
+		want error
+	}{
+		"bad key":     {CreateAttributeDefinitionRequest{Key: "Sleeve Length", Type: AttributeText, CategoryID: &categoryID}, ErrInvalidAttributeSchema},
+		"no level":    {CreateAttributeDefinitionRequest{Key: "care", Type: AttributeText}, ErrInvalidAttributeSchema},
+		"two levels":  {CreateAttributeDefinitionRequest{Key: "care", Type: AttributeText, CategoryID: &categoryID, SubCategoryID: &subCategoryID}, ErrInvalidAttributeSchema},
+		"key taken":   {CreateAttributeDefinitionRequest{Key: "fabric", Type: AttributeText, CategoryID: &categoryID}, ErrAttributeExists},
+		"no such cat": {CreateAttributeDefinitionRequest{Key: "care", Type: AttributeText, CategoryID: &subCategoryID}, nil},
+	}
+	for name, tt := range tests {
+		_, err := s.CreateAttributeDefinition(audit.Actor{}, tt.req)
+		if err == nil || (tt.want != nil && !errors.Is(err, tt.want)) {
+			t.Errorf("%s: got %v, want %v", name, err, tt.want)
+		}
+	}
+	if len(repo.created) != 1 {
+		t.Errorf("created %d definitions, want only the valid one", len(repo.created))
+	}
+}
+
+func TestProductFilterValidateAttributes(t *testing.T) {
+	valid := ProductFilter{Attributes: map[string]string{"fabric": "cotton,silk", "length": "60..", "width": "..40.5"}}
+	if err := valid.validate(); err != nil {
+		t.Errorf("valid filter: %v", err)
+	}
+
+	for name, attributes := range map[string]map[string]string{
+		"bad key":     {"fabric'; --": "cotton"},
+		"bad range":   {"length": "short..long"},
+		"empty range": {"length": ".."},
+	} {
+		filter := ProductFilter{Attributes: attributes}
+		if err := filter.validate(); !errors.Is(err, ErrInvalidAttributeFilter) {
+			t.Errorf("%s: got %v, want ErrInvalidAttributeFilter", name, err)
+		}
+	}
+
+	tooMany := ProductFilter{Attributes: map[string]string{}}
+	for i := 0; i <= maxAttributeFilters; i++ {
+		tooMany.Attributes[string(rune('a'+i))] = "x"
+	}
+	if err := tooMany.validate(); !errors.Is(err, ErrInvalidAttributeFilter) {
+		t.Errorf("too many: got %v, want ErrInvalidAttributeFilter", err)
+	}
+}
+
+func TestApplyAttributeFilter(t *testing.T) {
+	db, _ := newEmptyDB(t)
+	sql := func(key, value string) string {
+		return db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			var products []Product
+			return applyAttributeFilter(tx.Model(&Product{}), key, value).Find(&products)
+		})
+	}
+
+	// A value matches as text and as the number or boolean it could be
+	got := sql("size", "42, xl")
+	for _, want := range []string{`'{"size":"42"}'`, `'{"size":42}'`, `'{"size":"xl"}'`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("SQL %s does not match %s", got, want)
+		}
+	}
+	if got := sql("handwoven", "true"); !strings.Contains(got, `'{"handwoven":true}'`) {
+		t.Errorf("SQL %s does not match the boolean", got)
+	}
+
+	got = sql("length", "60..80")
+	if !strings.Contains(got, ">= 60") || !strings.Contains(got, "<= 80") {
+		t.Errorf("range SQL %s", got)
+	}
+	if got := sql("fabric", " , "); strings.Contains(got, "attributes") {
+		t.Errorf("empty value filtered: %s", got)
+	}
+}
+
+func TestCanonicalAttributeFilter(t *testing.T) {
+	s, repo := newAttributeTestService()
+	filter := ProductFilter{Attributes: map[string]string{
+		"fabric": "cotton, SILK,wool,silk",
+		"length": "short",
+		"color":  "red",
+	}}
+	if err := s.canonicalAttributeFilter(&filter); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"fabric": "Cotton,Silk,wool", "length": "short", "color": "red"}
+	for key, value := range want {
+		if filter.Attributes[key] != value {
+			t.Errorf("%s = %q, want %q", key, filter.Attributes[key], value)
+		}
+	}
+
+	// Another category spells the same option differently, so both match
+	repo.subCategory = append(repo.subCategory,
+		AttributeDefinition{Key: "fabric", Type: AttributeEnum, Options: []string{"COTTON", "Linen"}})
+	filter = ProductFilter{Attributes: map[string]string{"fabric": "cotton,linen"}}
+	if err := s.canonicalAttributeFilter(&filter); err != nil {
+		t.Fatal(err)
+	}
+	if got := filter.Attributes["fabric"]; got != "Cotton,COTTON,Linen" {
+		t.Errorf("fabric = %q, want Cotton,COTTON,Linen", got)
+	}
+}
@@ -2,10 +2,14 @@ package catalog
 
 import (
 	"context"
+	"ecommerce/internal/audit"
+	"errors"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/cloudinary/cloudinary-go/v2"
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
@@ -87,6 +91,7 @@ type createProductRequest struct {
 	CategoryID  uint     `json:"category_id"`
 	SubCategoryID    *uint `json:"sub_category_id,omitempty"`          // Optional
     SubSubCategoryID *uint `json:"sub_sub_category_id,omitempty"` 
+	Attributes       map[string]interface{} `json:"attributes"` // Checked against the categories' attribute schema
 
 }
 
@@ -100,6 +105,7 @@ func (c *ProductController) CreateProduct(ctx *gin.Context) {
 		return
 	}
 	product, err := c.productService.CreateProduct(
+		audit.ActorFromContext(ctx),
 		req.Name,
 		req.Image,
 		req.Description,
@@ -109,6 +115,7 @@ func (c *ProductController) CreateProduct(ctx *gin.Context) {
 		req.CategoryID,
 		req.SubCategoryID,
 		req.SubSubCategoryID,
+		req.Attributes,
 	)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
@@ -153,39 +160,128 @@ func (c *ProductController) GetProductByID(ctx *gin.Context) {
             "price":       product.Price,
             "stock":       product.Stock,
             "category_id": product.CategoryID,
+            "attributes":  product.Attributes,
+            "variants":    product.Variants,
         },
     })
 }
+// ListProducts lists products a page at a time. Pages are numbered (page,
+// page_size) or follow the cursor in next/prev links, which stays stable
+// while products are being added.
 func (c *ProductController) ListProducts(ctx *gin.Context) {
-	products, err := c.productService.ListProducts()
+	var query ProductQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.listProducts(ctx, query)
+}
+
+func (c *ProductController) listProducts(ctx *gin.Context, query ProductQuery) {
+	ids, err := parseProductIDs(ctx.QueryArray("ids"))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve products",
-		})
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ids format"})
 		return
 	}
+	query.IDs = ids
+	query.Attributes = ctx.QueryMap("attr")
 
-	var productsResponse []map[string]interface{}
-    for _, product := range products {
-        productsResponse = append(productsResponse, map[string]interface{}{
-            "id":          product.ID,
-            "name":        product.Name,
-            "images":      []string(product.Image), // ✅ Return original URLs
-            "description": product.Description,
-            "sku":         product.SKU,
-            "price":       product.Price,
-            "stock":       product.Stock,
-            "category_id": product.CategoryID,
-            "sub_category_id": product.SubCategoryID,
-            "sub_sub_category_id": product.SubSubCategoryID,
-            "created_at":  product.CreatedAt,
-            "updated_at":  product.UpdatedAt,
-        })
-    }
+	page, err := c.productService.ListProducts(query)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidCursor), errors.Is(err, ErrInvalidPriceRange), errors.Is(err, ErrTooManyProductIDs),
+			errors.Is(err, ErrInvalidAttributeFilter):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to retrieve products",
+			})
+		}
+		return
+	}
 
-    ctx.JSON(http.StatusOK, gin.H{
-        "products": productsResponse,
-    })
+	productsResponse := make([]map[string]interface{}, 0, len(page.Products))
+	for _, product := range page.Products {
+		productsResponse = append(productsResponse, map[string]interface{}{
+			"id":                  product.ID,
+			"name":                product.Name,
+			"images":              []string(product.Image), // ✅ Return original URLs
+			"description":         product.Description,
+			"sku":                 product.SKU,
+			"price":               product.Price,
+			"stock":               product.Stock,
+			"category_id":         product.CategoryID,
+			"sub_category_id":     product.SubCategoryID,
+			"sub_sub_category_id": product.SubSubCategoryID,
+			"attributes":          product.Attributes,
+			"created_at":          product.CreatedAt,
+			"updated_at":          product.UpdatedAt,
+		})
+	}
+
+	response := gin.H{
+		"products":    productsResponse,
+		"total":       page.Total,
+		"page_size":   page.PageSize,
+		"next_cursor": page.NextCursor,
+		"prev_cursor": page.PrevCursor,
+		"links":       productPageLinks(ctx.Request.URL, page),
+	}
+	if page.Page > 0 {
+		response["page"] = page.Page
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// parseProductIDs accepts ids both repeated (?ids=1&ids=2) and comma
+// separated (?ids=1,2).
+func parseProductIDs(values []string) ([]uint, error) {
+	var ids []uint
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			id, err := strconv.ParseUint(part, 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids, nil
+}
+
+// productPageLinks builds the next/prev links from the request URL, keeping
+// its filters. Numbered pages link to page±1, cursor pages to the cursors.
+func productPageLinks(requestURL *url.URL, page *ProductPage) gin.H {
+	link := func(key, value string) string {
+		u := *requestURL
+		values := u.Query()
+		values.Set(key, value)
+		u.RawQuery = values.Encode()
+		return u.RequestURI()
+	}
+	links := gin.H{"next": nil, "prev": nil}
+
+	if page.Page == 0 {
+		if page.NextCursor != "" {
+			links["next"] = link("cursor", page.NextCursor)
+		}
+		if page.PrevCursor != "" {
+			links["prev"] = link("cursor", page.PrevCursor)
+		}
+		return links
+	}
+
+	if int64(page.Page*page.PageSize) < page.Total {
+		links["next"] = link("page", strconv.Itoa(page.Page+1))
+	}
+	if page.Page > 1 {
+		links["prev"] = link("page", strconv.Itoa(page.Page-1))
+	}
+	return links
 }
 
 type updateProductRequest struct {
@@ -194,10 +290,11 @@ type updateProductRequest struct {
 	Description string  `json:"description"`
 	SKU         string  `json:"sku"`
 	Price       float64 `json:"price" binding:"min=0"`
-	Stock       int     `json:"stock" binding:"min=0"`
+	Stock       *int    `json:"stock" binding:"omitempty,min=0"` // Left as is when omitted
 	CategoryID  uint    `json:"category_id"`
 	SubCategoryID    *uint   `json:"sub_category_id,omitempty"`
     SubSubCategoryID *uint   `json:"sub_sub_category_id,omitempty"`
+	Attributes       map[string]interface{} `json:"attributes"` // Replaces the product's attributes
 }
 
 func (c *ProductController) UpdateProduct(ctx *gin.Context) {
@@ -217,6 +314,7 @@ func (c *ProductController) UpdateProduct(ctx *gin.Context) {
 		return
 	}
 	product, err := c.productService.UpdateProduct(
+		audit.ActorFromContext(ctx),
 		uint(id),
 		req.Name,
         req.Image,
@@ -227,10 +325,15 @@ func (c *ProductController) UpdateProduct(ctx *gin.Context) {
 		req.CategoryID,
 		req.SubCategoryID,
         req.SubSubCategoryID,
+		req.Attributes,
 	)
 
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{
+		status := http.StatusNotFound
+		if errors.Is(err, ErrInvalidAttribute) || errors.Is(err, ErrStockManagedByVariants) {
+			status = http.StatusBadRequest
+		}
+		ctx.JSON(status, gin.H{
 			"error": err.Error(),
 		})
 		return
@@ -252,7 +355,7 @@ func (c *ProductController) DeleteProduct(ctx *gin.Context) {
 		return
 	}
 
-	err = c.productService.DeleteProduct(uint(id))
+	err = c.productService.DeleteProduct(audit.ActorFromContext(ctx), uint(id))
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{
 			"error": err.Error(),
@@ -278,7 +381,7 @@ func (c *ProductController) CreateCategory(ctx *gin.Context) {
 		return
 	}
 
-	category, err := c.productService.CreateCategory(req.Name)
+	category, err := c.productService.CreateCategory(audit.ActorFromContext(ctx), req.Name)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -286,62 +389,68 @@ func (c *ProductController) CreateCategory(ctx *gin.Context) {
 	ctx.JSON(http.StatusCreated, gin.H{"category": category})
 }
 
+// GetProductsByCategory is ListProducts within the category in the path.
 func (c *ProductController) GetProductsByCategory(ctx *gin.Context) {
-	categoryIDStr := ctx.Param("id")
-	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("page_size", "20"))
-
-	var products []Product
-	var total int64
-	var err error
-
-	if categoryIDStr != "" {
-		categoryID, parseErr := strconv.ParseUint(categoryIDStr, 10, 32)
-		if parseErr != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "Invalid category_id format",
-			})
-			return
-		}
-
-		// ✅ Correct: Get products by specific category
-		products, total, err = c.productService.GetProductsByCategory(uint(categoryID), page, pageSize)
-	}
-
+	categoryID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve products",
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid category_id format",
 		})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"products":  products,
-		"total":     total,
-		"page":      page,
-		"page_size": pageSize,
-	})
+	var query ProductQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query.CategoryID = uint(categoryID)
+	c.listProducts(ctx, query)
 }
 
+// SearchProducts searches products by ?q=, best match first, taking the
+// listing's filters. "match" says whether results are full-text matches or
+// fuzzy ones for a misspelt term; "facets" count all results per filter value.
 func (c *ProductController) SearchProducts(ctx *gin.Context) {
-	searchTerm := ctx.Query("q")
-	if searchTerm == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Search Term is Required",
-		})
+	var query SearchQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	products, err := c.productService.SearchProducts(searchTerm)
+	ids, err := parseProductIDs(ctx.QueryArray("ids"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ids format"})
+		return
+	}
+	query.IDs = ids
+	query.Attributes = ctx.QueryMap("attr")
 
+	page, err := c.productService.SearchProducts(query)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		switch {
+		case errors.Is(err, ErrSearchTermRequired), errors.Is(err, ErrSearchTermTooShort), errors.Is(err, ErrSearchTermTooLong),
+			errors.Is(err, ErrInvalidPriceRange), errors.Is(err, ErrTooManyProductIDs), errors.Is(err, ErrInvalidAttributeFilter):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to search products",
+			})
+		}
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"products": products,
+		"products":  page.Hits,
+		"total":     page.Total,
+		"page":      page.Page,
+		"page_size": page.PageSize,
+		"match":     page.Match,
+		"facets":    page.Facets,
+		"links": productPageLinks(ctx.Request.URL, &ProductPage{
+			Total:    page.Total,
+			Page:     page.Page,
+			PageSize: page.PageSize,
+		}),
 	})
 }
 
@@ -363,7 +472,7 @@ func (c *ProductController) CreateSubCategory(ctx *gin.Context) {
         return
     }
 
-    subCategory, err := c.productService.CreateSubCategory(req.Name, req.CategoryID)
+    subCategory, err := c.productService.CreateSubCategory(audit.ActorFromContext(ctx), req.Name, req.CategoryID)
     if err != nil {
         ctx.JSON(500, gin.H{"error": err.Error()})
         return
@@ -379,7 +488,7 @@ func (c *ProductController) CreateSubSubCategory(ctx *gin.Context) {
         return
     }
 
-    subSubCategory, err := c.productService.CreateSubSubCategory(req.Name, req.SubCategoryID)
+    subSubCategory, err := c.productService.CreateSubSubCategory(audit.ActorFromContext(ctx), req.Name, req.SubCategoryID)
     if err != nil {
         ctx.JSON(500, gin.H{"error": err.Error()})
         return
@@ -538,8 +647,7 @@ func (c *ProductController) GetSubSubCategoryByID(ctx *gin.Context) {
 
 // Get products by subcategory
 func (c *ProductController) GetProductsBySubCategory(ctx *gin.Context) {
-    subCategoryIDStr := ctx.Param("id")
-    subCategoryID, err := strconv.ParseUint(subCategoryIDStr, 10, 64)
+    id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
     if err != nil {
         ctx.JSON(http.StatusBadRequest, gin.H{
             "error": "Invalid subcategory ID format",
@@ -547,23 +655,18 @@ func (c *ProductController) GetProductsBySubCategory(ctx *gin.Context) {
         return
     }
 
-    products, err := c.productService.GetProductsBySubCategoryID(uint(subCategoryID))
-    if err != nil {
-        ctx.JSON(http.StatusInternalServerError, gin.H{
-            "error": "Failed to retrieve products",
-        })
+    var query ProductQuery
+    if err := ctx.ShouldBindQuery(&query); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
         return
     }
-
-    ctx.JSON(http.StatusOK, gin.H{
-        "products": products,
-    })
+    query.SubCategoryID = uint(id)
+    c.listProducts(ctx, query)
 }
 
 // Get products by sub-subcategory
 func (c *ProductController) GetProductsBySubSubCategory(ctx *gin.Context) {
-    subSubCategoryIDStr := ctx.Param("id")
-    subSubCategoryID, err := strconv.ParseUint(subSubCategoryIDStr, 10, 64)
+    id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
     if err != nil {
         ctx.JSON(http.StatusBadRequest, gin.H{
             "error": "Invalid sub-subcategory ID format",
@@ -571,17 +674,13 @@ func (c *ProductController) GetProductsBySubSubCategory(ctx *gin.Context) {
         return
     }
 
-    products, err := c.productService.GetProductsBySubSubCategoryID(uint(subSubCategoryID))
-    if err != nil {
-        ctx.JSON(http.StatusInternalServerError, gin.H{
-            "error": "Failed to retrieve products",
-        })
+    var query ProductQuery
+    if err := ctx.ShouldBindQuery(&query); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
         return
     }
-
-    ctx.JSON(http.StatusOK, gin.H{
-        "products": products,
-    })
+    query.SubSubCategoryID = uint(id)
+    c.listProducts(ctx, query)
 }
 
 // Delete category
@@ -595,7 +694,7 @@ func (c *ProductController) DeleteCategory(ctx *gin.Context) {
         return
     }
 
-    err = c.productService.DeleteCategory(uint(id))
+    err = c.productService.DeleteCategory(audit.ActorFromContext(ctx), uint(id))
     if err != nil {
         ctx.JSON(http.StatusInternalServerError, gin.H{
             "error": err.Error(),
@@ -619,7 +718,7 @@ func (c *ProductController) DeleteSubCategory(ctx *gin.Context) {
         return
     }
 
-    err = c.productService.DeleteSubCategory(uint(id))
+    err = c.productService.DeleteSubCategory(audit.ActorFromContext(ctx), uint(id))
     if err != nil {
         ctx.JSON(http.StatusInternalServerError, gin.H{
             "error": err.Error(),
@@ -643,7 +742,7 @@ func (c *ProductController) DeleteSubSubCategory(ctx *gin.Context) {
         return
     }
 
-    err = c.productService.DeleteSubSubCategory(uint(id))
+    err = c.productService.DeleteSubSubCategory(audit.ActorFromContext(ctx), uint(id))
     if err != nil {
         ctx.JSON(http.StatusInternalServerError, gin.H{
             "error": err.Error(),
@@ -678,7 +777,7 @@ func (c *ProductController) UpdateCategory(ctx *gin.Context) {
         return
     }
 
-    category, err := c.productService.UpdateCategory(uint(id), req.Name)
+    category, err := c.productService.UpdateCategory(audit.ActorFromContext(ctx), uint(id), req.Name)
     if err != nil {
         ctx.JSON(http.StatusNotFound, gin.H{
             "error": err.Error(),
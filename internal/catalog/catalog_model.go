@@ -19,6 +19,12 @@ type Product struct {
 	SubCategoryID *uint `json:"sub_category_id,omitempty"`
 	SubSubCategoryID *uint `json:"sub_sub_category_id,omitempty"`
 
+	// Values of the attributes defined for the product's categories
+	Attributes ProductAttributes `json:"attributes" gorm:"type:jsonb"`
+
+	// Stock is the sum of the variants' stock for products with variants
+	Variants []ProductVariant `json:"variants,omitempty" gorm:"foreignKey:ProductID"`
+
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
@@ -1,7 +1,13 @@
 package catalog
 
 import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductRepository interface {
@@ -9,18 +15,23 @@ type ProductRepository interface {
 	//product methods
 	Create(product *Product) error
 	FindByID(id uint) (*Product, error)
-	FindAll() ([]*Product, error)
+	// FindProducts returns the products matching query, with one row more
+	// than the page size when there is another page, and the total count.
+	FindProducts(query ProductQuery) ([]Product, int64, error)
 	Update(product *Product) error
 	Delete(id uint) error
-	FindBySearchTerm(searchTerm string) ([]Product, error)
+	// SearchProducts ranks products by full-text match, or with MatchFuzzy
+	// by trigram similarity of the name, for misspellings
+	SearchProducts(query SearchQuery, match string) ([]SearchHit, int64, error)
+	// SearchFacets counts the products matching query by category, price,
+	// stock, variant options and attributes, each ignoring the query's own
+	// filter on it
+	SearchFacets(query SearchQuery, match string) (*Facets, error)
 
 	//category methods
 	
 	CreateCategory(category *Category) error
 
-	//products by category method
-	FindProductsByCategory(CategoryID uint, limit, offset int) ([]Product, int64, error)
-
 	CreateSubCategory(subCategory *SubCategory) error
     CreateSubSubCategory(subSubCategory *SubSubCategory) error
     FindCategoriesWithHierarchy() ([]Category, error)
@@ -32,15 +43,33 @@ type ProductRepository interface {
     FindSubCategoryByID(id uint) (*SubCategory, error)
     FindSubSubCategoryByID(id uint) (*SubSubCategory, error)
 
-	FindProductsBySubCategoryID(subCategoryID uint) ([]Product, error)
-	FindProductsBySubSubCategoryID(subSubCategoryID uint) ([]Product, error)
-
 	DeleteCategory(id uint) error
     DeleteSubCategory(id uint) error
     DeleteSubSubCategory(id uint) error
 
 	UpdateCategory(category *Category) error
 	FindAllSubCategories() ([]SubCategory, error)
+
+	//variant methods
+	FindOptionTypes() ([]OptionType, error)
+	CreateOptionType(optionType *OptionType) error
+	FindVariantByID(id uint) (*ProductVariant, error)
+	FindVariantSKUsWithPrefix(prefix string) ([]string, error)
+	// CreateVariants, UpdateVariant and DeleteVariant also recompute the
+	// product's stock from its variants
+	CreateVariants(productID uint, variants []ProductVariant) error
+	UpdateVariant(variant *ProductVariant) error
+	DeleteVariant(variant *ProductVariant) error
+
+	//attribute methods
+	// FindAttributeSchema returns the definitions of all the given levels,
+	// from the category level down
+	FindAttributeSchema(categoryID uint, subCategoryID, subSubCategoryID *uint) ([]AttributeDefinition, error)
+	FindEnumAttributeDefinitions(keys []string) ([]AttributeDefinition, error)
+	FindAttributeDefinitionByID(id uint) (*AttributeDefinition, error)
+	CreateAttributeDefinition(definition *AttributeDefinition) error
+	UpdateAttributeDefinition(definition *AttributeDefinition) error
+	DeleteAttributeDefinition(id uint) error
 }
 
 type productRepository struct {
@@ -59,24 +88,138 @@ func (r *productRepository) Create(product *Product) error {
 
 func (r *productRepository) FindByID(id uint) (*Product, error) {
 	var product Product
-	if err := r.db.First(&product, id).Error; err != nil {
+	if err := r.db.Preload("Variants", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).First(&product, id).Error; err != nil {
 		return nil, err
 	}
 	return &product, nil
 
 }
 
-func (r *productRepository) FindAll() ([]*Product, error) {
-	var products []*Product
+func (r *productRepository) FindProducts(query ProductQuery) ([]Product, int64, error) {
+	var total int64
+	if err := r.filterProducts(query).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	sort := productSorts[query.Sort]
+	desc := sort.desc
+	db := r.filterProducts(query)
+	if query.cursor != nil {
+		value, err := query.cursor.value()
+		if err != nil {
+			return nil, 0, ErrInvalidCursor
+		}
+		// ✅ A page before the cursor is read backwards; the service flips it
+		if query.cursor.Before {
+			desc = !desc
+		}
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		db = db.Where("("+sort.column+", id) "+op+" (?, ?)", value, query.cursor.ID)
+	} else {
+		db = db.Offset((query.Page - 1) * query.PageSize)
+	}
 
-	if err := r.db.Find(&products).Error; err != nil {
-		return nil, err
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
 	}
-	return products, nil
+	var products []Product
+	err := db.Order(sort.column + " " + direction + ", id " + direction).
+		Limit(query.PageSize + 1).
+		Find(&products).Error
+	return products, total, err
+}
+
+// filterProducts applies the query's filters, but not its sort or page.
+func (r *productRepository) filterProducts(query ProductQuery) *gorm.DB {
+	return applyProductFilter(r.db.Model(&Product{}), query.ProductFilter)
+}
+
+// applyProductFilter narrows db down to the products matching filter. Columns
+// are qualified so facet queries can join the category tables.
+func applyProductFilter(db *gorm.DB, filter ProductFilter) *gorm.DB {
+	if filter.CategoryID != 0 {
+		db = db.Where("products.category_id = ?", filter.CategoryID)
+	}
+	if filter.SubCategoryID != 0 {
+		db = db.Where("products.sub_category_id = ?", filter.SubCategoryID)
+	}
+	if filter.SubSubCategoryID != 0 {
+		db = db.Where("products.sub_sub_category_id = ?", filter.SubSubCategoryID)
+	}
+	if filter.MinPrice != nil {
+		db = db.Where("products.price >= ?", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		db = db.Where("products.price <= ?", *filter.MaxPrice)
+	}
+	if filter.InStock {
+		db = db.Where("products.stock > 0")
+	}
+	if len(filter.IDs) > 0 {
+		db = db.Where("products.id IN ?", filter.IDs)
+	}
+
+	keys := make([]string, 0, len(filter.Attributes))
+	for key := range filter.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		db = applyAttributeFilter(db, key, filter.Attributes[key])
+	}
+	return db
+}
+
+// applyAttributeFilter matches products whose attribute key has one of the
+// comma-separated values, or is a number in a min..max range. Values match
+// as text, number or boolean, whichever the attribute is.
+func applyAttributeFilter(db *gorm.DB, key, value string) *gorm.DB {
+	if min, max, isRange, err := parseAttributeRange(value); isRange && err == nil {
+		number := "CASE WHEN jsonb_typeof(products.attributes -> ?::text) = 'number' THEN (products.attributes ->> ?::text)::numeric END"
+		if min != nil {
+			db = db.Where(number+" >= ?", key, key, *min)
+		}
+		if max != nil {
+			db = db.Where(number+" <= ?", key, key, *max)
+		}
+		return db
+	}
+
+	// ✅ @> containment uses the GIN index on attributes
+	var conditions []string
+	var args []interface{}
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		candidates := []interface{}{part}
+		if number, err := strconv.ParseFloat(part, 64); err == nil {
+			candidates = append(candidates, number)
+		}
+		if part == "true" || part == "false" {
+			candidates = append(candidates, part == "true")
+		}
+		for _, candidate := range candidates {
+			doc, _ := json.Marshal(map[string]interface{}{key: candidate})
+			conditions = append(conditions, "products.attributes @> ?::jsonb")
+			args = append(args, string(doc))
+		}
+	}
+	if len(conditions) == 0 {
+		return db
+	}
+	return db.Where("("+strings.Join(conditions, " OR ")+")", args...)
 }
 
 func (r *productRepository) Update(product *Product) error {
-	return r.db.Save(product).Error
+	return r.db.Omit(clause.Associations).Save(product).Error
 }
 
 func (r *productRepository) Delete(id uint) error {
@@ -87,27 +230,78 @@ func (r *productRepository) CreateCategory(category *Category) error {
 	return r.db.Create(category).Error
 }
 
-func (r *productRepository) FindProductsByCategory(categoryID uint, limit, offset int) ([]Product, int64, error) {
-	var products []Product
+func (r *productRepository) SearchProducts(query SearchQuery, match string) ([]SearchHit, int64, error) {
+	var hits []SearchHit
 	var total int64
+	err := r.inSearch(match, func(tx *gorm.DB) error {
+		if err := searchProducts(tx, query.Term, match, query.ProductFilter).Count(&total).Error; err != nil {
+			return err
+		}
+		if total == 0 {
+			hits = []SearchHit{}
+			return nil
+		}
+
+		if match == MatchFuzzy {
+			return searchProducts(tx, query.Term, match, query.ProductFilter).
+				Select("products.*, word_similarity(?, products.name) AS rank", query.Term).
+				Order("rank DESC, products.id DESC").
+				Limit(query.PageSize).
+				Offset(query.offset()).
+				Scan(&hits).Error
+		}
+
+		page := searchProducts(tx, query.Term, match, query.ProductFilter).
+			Select("products.*, ts_rank_cd(products.search_vector, websearch_to_tsquery('english', ?)) AS rank", query.Term).
+			Order("rank DESC, products.id DESC").
+			Limit(query.PageSize).
+			Offset(query.offset())
+		// ✅ Only the page is highlighted, ts_headline is slow on long text.
+		// The text is HTML-escaped first so only the <mark> tags are markup.
+		return tx.Table("(?) AS p, websearch_to_tsquery('english', ?) AS q(query)", page, query.Term).
+			Select(`p.*,
+				ts_headline('english', ` + escapeHTMLSQL("p.name") + `, q.query, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') AS name_highlight,
+				ts_headline('english', ` + escapeHTMLSQL("coalesce(p.description, '')") + `, q.query, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5') AS description_highlight`).
+			Order("p.rank DESC, p.id DESC").
+			Scan(&hits).Error
+	})
+	return hits, total, err
+}
 
-	r.db.Model(&Product{}).Where("category_id = ?", categoryID).Count(&total)
-
-	err := r.db.Where("category_id = ?", categoryID).
-		Limit(limit).
-		Offset(offset).
-		Find(&products).Error
-
-	return products, total, err
+func (r *productRepository) SearchFacets(query SearchQuery, match string) (*Facets, error) {
+	facets := &Facets{}
+	err := r.inSearch(match, func(tx *gorm.DB) error {
+		return countFacets(tx, query.ProductFilter, facets, func(filter ProductFilter) *gorm.DB {
+			return searchProducts(tx, query.Term, match, filter)
+		})
+	})
+	return facets, err
 }
 
-func (r *productRepository) FindBySearchTerm(searchTerm string) ([]Product, error) {
-	var products []Product
-	searchPattern := "%" + searchTerm + "%"
-	err := r.db.Where("name ILIKE ? OR description ILIKE ?", searchPattern, searchPattern).
-		Find(&products).Error
+// inSearch runs fn against the database. Fuzzy searches run in a transaction
+// so the similarity threshold they set stays local to them.
+func (r *productRepository) inSearch(match string, fn func(tx *gorm.DB) error) error {
+	if match != MatchFuzzy {
+		return fn(r.db)
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// ✅ <% uses the trigram index, with the threshold set here
+		if err := tx.Exec("SELECT set_config('pg_trgm.word_similarity_threshold', ?, true)",
+			strconv.FormatFloat(searchSimilarityThreshold, 'f', -1, 64)).Error; err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+}
 
-	return products, err
+// searchProducts narrows tx down to the products matching term and filter,
+// by full-text or fuzzy name match.
+func searchProducts(tx *gorm.DB, term, match string, filter ProductFilter) *gorm.DB {
+	db := applyProductFilter(tx.Model(&Product{}), filter)
+	if match == MatchFuzzy {
+		return db.Where("? <% products.name", term)
+	}
+	return db.Where("products.search_vector @@ websearch_to_tsquery('english', ?)", term)
 }
 
 func (r *productRepository) CreateSubCategory(subCategory *SubCategory) error {
@@ -175,18 +369,6 @@ func (r *productRepository) FindSubSubCategoryByID(id uint) (*SubSubCategory, er
     return &subSubCategory, nil
 }
 
-func (r *productRepository) FindProductsBySubCategoryID(subCategoryID uint) ([]Product, error) {
-    var products []Product
-    err := r.db.Where("sub_category_id = ?", subCategoryID).Find(&products).Error
-    return products, err
-}
-
-func (r *productRepository) FindProductsBySubSubCategoryID(subSubCategoryID uint) ([]Product, error) {
-    var products []Product
-    err := r.db.Where("sub_sub_category_id = ?", subSubCategoryID).Find(&products).Error
-    return products, err
-}
-
 func (r *productRepository) DeleteCategory(id uint) error {
     return r.db.Delete(&Category{}, id).Error
 }
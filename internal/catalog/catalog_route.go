@@ -1,6 +1,8 @@
 package catalog
 
 import (
+    "ecommerce/internal/auth"
+
     "github.com/gin-gonic/gin"
 )
 
@@ -11,15 +13,22 @@ func SetupCatalogRoutes(
     
     v1 := router.Group("/api/v1")
 
-    v1.POST("/upload", productController.UploadImage)
+    // Catalog writes are limited to staff and admins, or API keys with the
+    // catalog:write scope
+    requireAuth := auth.JWTOrAPIKeyMiddleware()
+    requireScope := auth.RequireScope(auth.ScopeCatalogWrite)
+    requireStaff := auth.RequireRole(auth.RoleStaff, auth.RoleAdmin)
+    requireMFA := auth.RequireMFA() // Deletes also need a recent two-factor check
+
+    v1.POST("/upload", requireAuth, requireScope, requireStaff, productController.UploadImage)
     
     //category routes
     categories := v1.Group("/categories")
     {
         // CREATE routes
-        categories.POST("", productController.CreateCategory)
-        categories.POST("/subcategory", productController.CreateSubCategory)
-        categories.POST("/sub-subcategory", productController.CreateSubSubCategory)
+        categories.POST("", requireAuth, requireScope, requireStaff, productController.CreateCategory)
+        categories.POST("/subcategory", requireAuth, requireScope, requireStaff, productController.CreateSubCategory)
+        categories.POST("/sub-subcategory", requireAuth, requireScope, requireStaff, productController.CreateSubSubCategory)
         
         // GET routes - ✅ FIXED: Use consistent parameter names
         categories.GET("", productController.ListCategories)                    
@@ -27,8 +36,8 @@ func SetupCatalogRoutes(
         categories.GET("/:id", productController.GetCategoryByID)              
         categories.GET("/:id/products", productController.GetProductsByCategory) 
         categories.GET("/:id/subcategories", productController.GetSubCategoriesByCategory) // ✅ Changed :category_id to :id
-        categories.PUT("/:id", productController.UpdateCategory)
-		categories.DELETE("/:id", productController.DeleteCategory)
+        categories.PUT("/:id", requireAuth, requireScope, requireStaff, productController.UpdateCategory)
+		categories.DELETE("/:id", requireAuth, requireScope, requireStaff, requireMFA, productController.DeleteCategory)
 
 }
     // SubCategory routes
@@ -39,7 +48,7 @@ func SetupCatalogRoutes(
         subcategories.GET("/:id", productController.GetSubCategoryByID)                    
         subcategories.GET("/:id/sub-subcategories", productController.GetSubSubCategoriesBySubCategory) // ✅ Changed :subcategory_id to :id
         subcategories.GET("/:id/products", productController.GetProductsBySubCategory)
-        subcategories.DELETE("/:id", productController.DeleteSubCategory)
+        subcategories.DELETE("/:id", requireAuth, requireScope, requireStaff, requireMFA, productController.DeleteSubCategory)
     }
 
     // SubSubCategory routes
@@ -47,17 +56,41 @@ func SetupCatalogRoutes(
     {
         subSubcategories.GET("/:id", productController.GetSubSubCategoryByID)            
         subSubcategories.GET("/:id/products", productController.GetProductsBySubSubCategory)
-        subSubcategories.DELETE("/:id", productController.DeleteSubSubCategory)
+        subSubcategories.DELETE("/:id", requireAuth, requireScope, requireStaff, requireMFA, productController.DeleteSubSubCategory)
     }
 
     // Product routes
     products := v1.Group("/products")
     {
-        products.POST("", productController.CreateProduct)
+        products.POST("", requireAuth, requireScope, requireStaff, productController.CreateProduct)
         products.GET("/:id", productController.GetProductByID)
         products.GET("", productController.ListProducts)
-        products.PUT("/:id", productController.UpdateProduct)
-        products.DELETE("/:id", productController.DeleteProduct)
+        products.PUT("/:id", requireAuth, requireScope, requireStaff, productController.UpdateProduct)
+        products.DELETE("/:id", requireAuth, requireScope, requireStaff, requireMFA, productController.DeleteProduct)
         products.GET("/search", productController.SearchProducts)
+        products.POST("/:id/variants/generate", requireAuth, requireScope, requireStaff, productController.GenerateVariants)
+    }
+
+    // Variant routes
+    variants := v1.Group("/variants")
+    {
+        variants.PUT("/:id", requireAuth, requireScope, requireStaff, productController.UpdateVariant)
+        variants.DELETE("/:id", requireAuth, requireScope, requireStaff, requireMFA, productController.DeleteVariant)
+    }
+
+    // Option types (Size, Color, ...) variants are made of
+    optionTypes := v1.Group("/option-types")
+    {
+        optionTypes.GET("", productController.ListOptionTypes)
+        optionTypes.POST("", requireAuth, requireScope, requireStaff, productController.CreateOptionType)
+    }
+
+    // Attribute schemas of category levels
+    v1.GET("/attribute-schema", productController.GetAttributeSchema)
+    attributes := v1.Group("/attributes")
+    {
+        attributes.POST("", requireAuth, requireScope, requireStaff, productController.CreateAttributeDefinition)
+        attributes.PUT("/:id", requireAuth, requireScope, requireStaff, productController.UpdateAttributeDefinition)
+        attributes.DELETE("/:id", requireAuth, requireScope, requireStaff, requireMFA, productController.DeleteAttributeDefinition)
     }
 }
\ No newline at end of file
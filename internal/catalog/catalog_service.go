@@ -1,6 +1,7 @@
 package catalog
 
 import (
+	"ecommerce/internal/audit"
 	"errors"
 	"log"
 
@@ -10,22 +11,20 @@ import (
 type ProductService interface {
 
 	//products method
-	CreateProduct(name string, images []string, description, sku string, price float64, stock int, categoryId uint, subCategoryID, subSubCategoryID *uint) (*Product, error)
+	CreateProduct(actor audit.Actor, name string, images []string, description, sku string, price float64, stock int, categoryId uint, subCategoryID, subSubCategoryID *uint, attributes map[string]interface{}) (*Product, error)
 	GetProductByID(id uint) (*Product, error)
-	ListProducts() ([]*Product, error)
-	UpdateProduct(id uint,name string,images [] string, description, sku string, price float64, stock int, categoryId uint,subCategoryID, subSubCategoryID *uint) (*Product, error)
-	DeleteProduct(id uint) error
-	SearchProducts(searchTerm string) ([]Product, error)
+	ListProducts(query ProductQuery) (*ProductPage, error)
+	UpdateProduct(actor audit.Actor, id uint,name string,images [] string, description, sku string, price float64, stock *int, categoryId uint,subCategoryID, subSubCategoryID *uint, attributes map[string]interface{}) (*Product, error)
+	DeleteProduct(actor audit.Actor, id uint) error
+	SearchProducts(query SearchQuery) (*SearchPage, error)
 
 	//category methods
 
-	CreateCategory(name string) (*Category, error)
+	CreateCategory(actor audit.Actor, name string) (*Category, error)
 
-	GetProductsByCategory(categoryID uint, page, pageSize int) ([]Product, int64, error)
 
-
-	CreateSubCategory(name string, categoryID uint) (*SubCategory, error)
-    CreateSubSubCategory(name string, subCategoryID uint) (*SubSubCategory, error)
+	CreateSubCategory(actor audit.Actor, name string, categoryID uint) (*SubCategory, error)
+    CreateSubSubCategory(actor audit.Actor, name string, subCategoryID uint) (*SubSubCategory, error)
     GetCategoryHierarchy() ([]Category, error)
     GetSubCategoriesByCategoryID(categoryID uint) ([]SubCategory, error)
     GetSubSubCategoriesBySubCategoryID(subCategoryID uint) ([]SubSubCategory, error)
@@ -35,26 +34,37 @@ type ProductService interface {
     GetSubCategoryByID(id uint) (*SubCategory, error)
     GetSubSubCategoryByID(id uint) (*SubSubCategory, error)
 
-	 GetProductsBySubCategoryID(subCategoryID uint) ([]Product, error)
-    GetProductsBySubSubCategoryID(subSubCategoryID uint) ([]Product, error)
-
-	 DeleteCategory(id uint) error
-    DeleteSubCategory(id uint) error
-    DeleteSubSubCategory(id uint) error
+	 DeleteCategory(actor audit.Actor, id uint) error
+    DeleteSubCategory(actor audit.Actor, id uint) error
+    DeleteSubSubCategory(actor audit.Actor, id uint) error
 
-	UpdateCategory(id uint, name string) (*Category, error)
+	UpdateCategory(actor audit.Actor, id uint, name string) (*Category, error)
 
 	ListSubCategories() ([]SubCategory, error)
+
+	//variant methods
+	ListOptionTypes() ([]OptionType, error)
+	CreateOptionType(actor audit.Actor, name string) (*OptionType, error)
+	GenerateVariants(actor audit.Actor, productID uint, req GenerateVariantsRequest) ([]ProductVariant, error)
+	UpdateVariant(actor audit.Actor, id uint, req UpdateVariantRequest) (*ProductVariant, error)
+	DeleteVariant(actor audit.Actor, id uint) error
+
+	//attribute methods
+	AttributeSchema(categoryID uint, subCategoryID, subSubCategoryID *uint) ([]AttributeDefinition, error)
+	CreateAttributeDefinition(actor audit.Actor, req CreateAttributeDefinitionRequest) (*AttributeDefinition, error)
+	UpdateAttributeDefinition(actor audit.Actor, id uint, req UpdateAttributeDefinitionRequest) (*AttributeDefinition, error)
+	DeleteAttributeDefinition(actor audit.Actor, id uint) error
 }
 type productService struct {
-	repo ProductRepository
+	repo  ProductRepository
+	audit audit.Service
 }
 
-func NewProductService(repo ProductRepository) ProductService {
-	return &productService{repo: repo}
+func NewProductService(repo ProductRepository, auditService audit.Service) ProductService {
+	return &productService{repo: repo, audit: auditService}
 }
 
-func (s *productService) CreateProduct(name string, images []string, description, sku string, price float64, stock int, categoryId uint , subCategoryID, subSubCategoryID *uint) (*Product, error) {
+func (s *productService) CreateProduct(actor audit.Actor, name string, images []string, description, sku string, price float64, stock int, categoryId uint , subCategoryID, subSubCategoryID *uint, attributes map[string]interface{}) (*Product, error) {
 	if name == "" {
 		return nil, errors.New("product name is required")
 	}
@@ -67,6 +77,10 @@ func (s *productService) CreateProduct(name string, images []string, description
 	if stock < 0 {
 		return nil, errors.New("stock cannot be negative")
 	}
+	validated, err := s.validateAttributes(categoryId, subCategoryID, subSubCategoryID, attributes)
+	if err != nil {
+		return nil, err
+	}
 	product := &Product{
 		Name:        name,
 		Image:       pq.StringArray(images),
@@ -77,11 +91,13 @@ func (s *productService) CreateProduct(name string, images []string, description
 		CategoryID:  categoryId,
 		SubCategoryID: subCategoryID,
 		SubSubCategoryID: subSubCategoryID,
+		Attributes:       validated,
 	}
 	// Save to database
 	if err := s.repo.Create(product); err != nil {
 		return nil, err
 	}
+	s.audit.Record(actor, audit.ActionProductCreate, "product", product.ID, nil, product)
 	return product, nil
 }
 
@@ -96,15 +112,67 @@ func (s *productService) GetProductByID(id uint) (*Product, error) {
 	return product, nil
 }
 
-func (s *productService) ListProducts() ([]*Product, error) {
-	products, err := s.repo.FindAll()
+// ListProducts returns a page of products. Cursors point at the first and
+// last product of the page and are only set when there is a page that way.
+func (s *productService) ListProducts(query ProductQuery) (*ProductPage, error) {
+	if err := query.normalize(); err != nil {
+		return nil, err
+	}
+	if err := s.canonicalAttributeFilter(&query.ProductFilter); err != nil {
+		return nil, err
+	}
+	products, total, err := s.repo.FindProducts(query)
 	if err != nil {
 		return nil, err
 	}
-	return products, nil
+
+	more := len(products) > query.PageSize
+	if more {
+		products = products[:query.PageSize]
+	}
+	hasNext, hasPrev := more, query.Page > 1
+	if query.cursor != nil {
+		if query.cursor.Before {
+			// Read backwards from the cursor, so flip the page around
+			for i, j := 0, len(products)-1; i < j; i, j = i+1, j-1 {
+				products[i], products[j] = products[j], products[i]
+			}
+			hasNext, hasPrev = true, more
+		} else {
+			hasPrev = true
+		}
+	}
+
+	page := &ProductPage{
+		Products: products,
+		Total:    total,
+		Page:     query.Page,
+		PageSize: query.PageSize,
+	}
+	if len(products) == 0 {
+		page.Products = []Product{}
+		return page, nil
+	}
+	if hasNext {
+		page.NextCursor = newProductCursor(query.Sort, products[len(products)-1], false)
+	}
+	if hasPrev {
+		page.PrevCursor = newProductCursor(query.Sort, products[0], true)
+	}
+	return page, nil
+}
+
+// hasProducts reports whether any product matches query, e.g. before a
+// category is deleted.
+func (s *productService) hasProducts(filter ProductFilter) (bool, error) {
+	query := ProductQuery{ProductFilter: filter, Sort: SortNewest, Page: 1, PageSize: 1}
+	_, total, err := s.repo.FindProducts(query)
+	return total > 0, err
 }
 
-func (s *productService) UpdateProduct(id uint, name string ,images []string , description, sku string, price float64, stock int, categoryId uint, subCategoryID, subSubCategoryID *uint) (*Product, error) {
+// UpdateProduct changes the given fields. Attributes, when given, replace the
+// product's; they are also checked again when the product changes category.
+func (s *productService) UpdateProduct(actor audit.Actor, id uint, name string ,images []string , description, sku string, price float64, stock *int, categoryId uint, subCategoryID, subSubCategoryID *uint, attributes map[string]interface{}) (*Product, error) {
 	if id == 0 {
 		return nil, errors.New("product id is required")
 	}
@@ -112,6 +180,7 @@ func (s *productService) UpdateProduct(id uint, name string ,images []string , d
 	if err != nil {
 		return nil, errors.New("product not found")
 	}
+	before := *product
 
 	// Update fields if provided
 	if name != "" {
@@ -132,8 +201,12 @@ func (s *productService) UpdateProduct(id uint, name string ,images []string , d
 		product.Price = price
 	}
 
-	if stock >= 0 {
-		product.Stock = stock
+	// ✅ A product with variants has the sum of their stock
+	if stock != nil && *stock != product.Stock {
+		if len(product.Variants) > 0 {
+			return nil, ErrStockManagedByVariants
+		}
+		product.Stock = *stock
 	}
 
 	if categoryId != 0 {
@@ -146,14 +219,30 @@ func (s *productService) UpdateProduct(id uint, name string ,images []string , d
         product.SubSubCategoryID = subSubCategoryID
     }
 
+	moved := product.CategoryID != before.CategoryID ||
+		!sameCategoryID(product.SubCategoryID, before.SubCategoryID) ||
+		!sameCategoryID(product.SubSubCategoryID, before.SubSubCategoryID)
+	if attributes != nil || moved {
+		values := attributes
+		if values == nil {
+			values = product.Attributes
+		}
+		validated, err := s.validateAttributes(product.CategoryID, product.SubCategoryID, product.SubSubCategoryID, values)
+		if err != nil {
+			return nil, err
+		}
+		product.Attributes = validated
+	}
+
 	// Save updated product
 	if err := s.repo.Update(product); err != nil {
 		return nil, err
 	}
+	s.audit.Record(actor, audit.ActionProductUpdate, "product", id, before, product)
 	return product, nil
 }
 
-func (s *productService) DeleteProduct(id uint) error {
+func (s *productService) DeleteProduct(actor audit.Actor, id uint) error {
 	// Verify product exists
 	product, err := s.repo.FindByID(id)
 	if err != nil {
@@ -161,41 +250,71 @@ func (s *productService) DeleteProduct(id uint) error {
 	}
 
 	// Delete the product
-	return s.repo.Delete(product.ID)
+	if err := s.repo.Delete(product.ID); err != nil {
+		return err
+	}
+	s.audit.Record(actor, audit.ActionProductDelete, "product", id, product, nil)
+	return nil
 }
 
-func (s *productService) CreateCategory(name string) (*Category, error) {
+func (s *productService) CreateCategory(actor audit.Actor, name string) (*Category, error) {
 	if name == "" {
 		return nil, errors.New("category name is required")
 	}
 	category := &Category{Name: name}
-	err := s.repo.CreateCategory(category)
-	return category, err
-
-}
+	if err := s.repo.CreateCategory(category); err != nil {
+		return category, err
+	}
+	s.audit.Record(actor, audit.ActionCategoryCreate, "category", category.ID, nil, map[string]interface{}{"name": name})
+	return category, nil
 
-func (s *productService) GetProductsByCategory(categoryID uint, page, pageSize int) ([]Product, int64, error) {
-	offset := (page - 1) * pageSize
-	return s.repo.FindProductsByCategory(categoryID, pageSize, offset)
 }
 
-func (s *productService) SearchProducts(searchTerm string) ([]Product, error) {
-	if searchTerm == "" {
-		return nil, errors.New("search term is required")
+// SearchProducts ranks products by full-text match on name, SKU and
+// description, falling back to fuzzy name matches when the term matches
+// nothing, and counts the facets of the results.
+func (s *productService) SearchProducts(query SearchQuery) (*SearchPage, error) {
+	if err := query.normalize(); err != nil {
+		return nil, err
 	}
-	if len(searchTerm) < 2 {
-		return nil, errors.New("search term must be at least 2 characters")
+	if err := s.canonicalAttributeFilter(&query.ProductFilter); err != nil {
+		return nil, err
 	}
-	products, err := s.repo.FindBySearchTerm(searchTerm)
+	page := &SearchPage{Page: query.Page, PageSize: query.PageSize, Match: MatchFullText}
+
+	hits, total, err := s.repo.SearchProducts(query, page.Match)
 	if err != nil {
 		return nil, err
 	}
-	return products, nil
+	if total == 0 {
+		// ✅ Only a misspelt term falls back, not filters that rule out
+		// every match
+		unfiltered := SearchQuery{Term: query.Term, Page: 1, PageSize: 1}
+		if !query.empty() {
+			if _, total, err = s.repo.SearchProducts(unfiltered, page.Match); err != nil {
+				return nil, err
+			}
+		}
+		if total == 0 {
+			page.Match = MatchFuzzy
+			if hits, total, err = s.repo.SearchProducts(query, page.Match); err != nil {
+				return nil, err
+			}
+		} else {
+			total = 0
+		}
+	}
+	page.Hits, page.Total = hits, total
+
+	if page.Facets, err = s.repo.SearchFacets(query, page.Match); err != nil {
+		return nil, err
+	}
+	return page, nil
 }
 
 
 // 👈 NEW: Add these service implementations
-func (s *productService) CreateSubCategory(name string, categoryID uint) (*SubCategory, error) {
+func (s *productService) CreateSubCategory(actor audit.Actor, name string, categoryID uint) (*SubCategory, error) {
     if name == "" {
 		log.Println("name needed")
         return nil, errors.New("subcategory name is required")
@@ -218,11 +337,13 @@ func (s *productService) CreateSubCategory(name string, categoryID uint) (*SubCa
 		log.Println("subcategory is not created , db error")
         return nil, err
     }
+    s.audit.Record(actor, audit.ActionSubCategoryCreate, "subcategory", subCategory.ID, nil,
+        map[string]interface{}{"name": name, "category_id": categoryID})
 
     return subCategory, nil
 }
 
-func (s *productService) CreateSubSubCategory(name string, subCategoryID uint) (*SubSubCategory, error) {
+func (s *productService) CreateSubSubCategory(actor audit.Actor, name string, subCategoryID uint) (*SubSubCategory, error) {
     if name == "" {
         return nil, errors.New("sub-subcategory name is required")
     }
@@ -237,6 +358,8 @@ func (s *productService) CreateSubSubCategory(name string, subCategoryID uint) (
     if err != nil {
         return nil, errors.New("failed to create sub-subcategory")
     }
+    s.audit.Record(actor, audit.ActionSubSubCategoryCreate, "sub_subcategory", subSubCategory.ID, nil,
+        map[string]interface{}{"name": name, "sub_category_id": subCategoryID})
 
     return subSubCategory, nil
 }
@@ -285,21 +408,7 @@ func (s *productService) GetSubSubCategoryByID(id uint) (*SubSubCategory, error)
     return s.repo.FindSubSubCategoryByID(id)
 }
 
-func (s *productService) GetProductsBySubCategoryID(subCategoryID uint) ([]Product, error) {
-    if subCategoryID == 0 {
-        return nil, errors.New("subcategory ID is required")
-    }
-    return s.repo.FindProductsBySubCategoryID(subCategoryID)
-}
-
-func (s *productService) GetProductsBySubSubCategoryID(subSubCategoryID uint) ([]Product, error) {
-    if subSubCategoryID == 0 {
-        return nil, errors.New("sub-subcategory ID is required")
-    }
-    return s.repo.FindProductsBySubSubCategoryID(subSubCategoryID)
-}
-
-func (s *productService) DeleteCategory(id uint) error {
+func (s *productService) DeleteCategory(actor audit.Actor, id uint) error {
     if id == 0 {
         log.Println("id is zero")
         return errors.New("category ID is required")
@@ -313,45 +422,71 @@ func (s *productService) DeleteCategory(id uint) error {
     }
 
     // Check if category has products
-    products, _, err := s.repo.FindProductsByCategory(id, 1, 0)
-    if err == nil && len(products) > 0 {
+    found, err := s.hasProducts(ProductFilter{CategoryID: id})
+    if err == nil && found {
         log.Println("it has products")
         return errors.New("cannot delete category: it has products")
     }
 
-    return s.repo.DeleteCategory(id)
+    category, err := s.repo.FindCategoryByID(id)
+    if err != nil {
+        return errors.New("category not found")
+    }
+    if err := s.repo.DeleteCategory(id); err != nil {
+        return err
+    }
+    s.audit.Record(actor, audit.ActionCategoryDelete, "category", id, map[string]interface{}{"name": category.Name}, nil)
+    return nil
 }
 
-func (s *productService) DeleteSubCategory(id uint) error {
+func (s *productService) DeleteSubCategory(actor audit.Actor, id uint) error {
     if id == 0 {
         log.Println("id is zero")
         return errors.New("subcategory ID is required")
     }
     // Check if subcategory has products
-    products, err := s.repo.FindProductsBySubCategoryID(id)
-    if err == nil && len(products) > 0 {
+    found, err := s.hasProducts(ProductFilter{SubCategoryID: id})
+    if err == nil && found {
         log.Println("it has products")
         return errors.New("cannot delete subcategory: it has products")
     }
 
-    return s.repo.DeleteSubCategory(id)
+    subCategory, err := s.repo.FindSubCategoryByID(id)
+    if err != nil {
+        return errors.New("subcategory not found")
+    }
+    if err := s.repo.DeleteSubCategory(id); err != nil {
+        return err
+    }
+    s.audit.Record(actor, audit.ActionSubCategoryDelete, "subcategory", id,
+        map[string]interface{}{"name": subCategory.Name, "category_id": subCategory.CategoryID}, nil)
+    return nil
 }
 
-func (s *productService) DeleteSubSubCategory(id uint) error {
+func (s *productService) DeleteSubSubCategory(actor audit.Actor, id uint) error {
     if id == 0 {
         return errors.New("sub-subcategory ID is required")
     }
 
     // Check if sub-subcategory has products
-    products, err := s.repo.FindProductsBySubSubCategoryID(id)
-    if err == nil && len(products) > 0 {
+    found, err := s.hasProducts(ProductFilter{SubSubCategoryID: id})
+    if err == nil && found {
         return errors.New("cannot delete sub-subcategory: it has products")
     }
 
-    return s.repo.DeleteSubSubCategory(id)
+    subSubCategory, err := s.repo.FindSubSubCategoryByID(id)
+    if err != nil {
+        return errors.New("sub-subcategory not found")
+    }
+    if err := s.repo.DeleteSubSubCategory(id); err != nil {
+        return err
+    }
+    s.audit.Record(actor, audit.ActionSubSubCategoryDelete, "sub_subcategory", id,
+        map[string]interface{}{"name": subSubCategory.Name, "sub_category_id": subSubCategory.SubCategoryID}, nil)
+    return nil
 }
 
-func (s *productService) UpdateCategory(id uint, name string) (*Category, error) {
+func (s *productService) UpdateCategory(actor audit.Actor, id uint, name string) (*Category, error) {
     if id == 0 {
         return nil, errors.New("category ID is required")
     }
@@ -364,11 +499,14 @@ func (s *productService) UpdateCategory(id uint, name string) (*Category, error)
         return nil, errors.New("category not found")
     }
 
+    oldName := category.Name
     category.Name = name
     err = s.repo.UpdateCategory(category)
     if err != nil {
         return nil, err
     }
+    s.audit.Record(actor, audit.ActionCategoryUpdate, "category", id,
+        map[string]interface{}{"name": oldName}, map[string]interface{}{"name": name})
 
     return category, nil
 }
@@ -0,0 +1,229 @@
+package catalog
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// priceBucketBounds split prices into the facet's ranges: under the first
+// bound, between each pair, and the last bound and over.
+var priceBucketBounds = []float64{500, 1000, 2000, 5000, 10000}
+
+// Facets count the products matching a search by the values of each filter.
+// A facet's counts ignore the search's own filter on that facet, so the
+// sidebar shows what choosing another value would give.
+type Facets struct {
+	Categories       []FacetValue  `json:"categories"`
+	SubCategories    []FacetValue  `json:"sub_categories"`
+	SubSubCategories []FacetValue  `json:"sub_sub_categories"`
+	Prices           []PriceBucket `json:"prices"`
+	Stock            StockFacet    `json:"stock"`
+
+	// Options counts products by their variants' option values, e.g.
+	// {"Size": [{"value": "M", "count": 4}]}
+	Options map[string][]OptionFacetValue `json:"options"`
+
+	// Attributes counts products by their text, enum and boolean attribute
+	// values, the most common ones per attribute
+	Attributes map[string][]OptionFacetValue `json:"attributes"`
+}
+
+type FacetValue struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// PriceBucket is a price range, Min inclusive and Max exclusive. The last
+// bucket has no Max.
+type PriceBucket struct {
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max"`
+	Count int64    `json:"count"`
+}
+
+type OptionFacetValue struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+type StockFacet struct {
+	InStock    int64 `json:"in_stock"`
+	OutOfStock int64 `json:"out_of_stock"`
+}
+
+// countFacets fills facets with counts grouped in SQL. matching narrows a
+// query down to the products a filter (and e.g. a search term) selects.
+func countFacets(tx *gorm.DB, filter ProductFilter, facets *Facets, matching func(ProductFilter) *gorm.DB) error {
+	// ✅ Choosing a category resets the levels below it, so each level
+	// ignores its own filter and the ones under it
+	categories := filter
+	categories.CategoryID, categories.SubCategoryID, categories.SubSubCategoryID = 0, 0, 0
+	if err := countCategoryFacet(tx, matching(categories), "category_id", "categories", &facets.Categories); err != nil {
+		return err
+	}
+	subCategories := filter
+	subCategories.SubCategoryID, subCategories.SubSubCategoryID = 0, 0
+	if err := countCategoryFacet(tx, matching(subCategories), "sub_category_id", "sub_categories", &facets.SubCategories); err != nil {
+		return err
+	}
+	subSubCategories := filter
+	subSubCategories.SubSubCategoryID = 0
+	if err := countCategoryFacet(tx, matching(subSubCategories), "sub_sub_category_id", "sub_sub_categories", &facets.SubSubCategories); err != nil {
+		return err
+	}
+
+	prices := filter
+	prices.MinPrice, prices.MaxPrice = nil, nil
+	if err := countPriceFacet(matching(prices), &facets.Prices); err != nil {
+		return err
+	}
+
+	stock := filter
+	stock.InStock = false
+	if err := matching(stock).
+		Select("COUNT(*) FILTER (WHERE products.stock > 0) AS in_stock, " +
+			"COUNT(*) FILTER (WHERE COALESCE(products.stock, 0) <= 0) AS out_of_stock").
+		Scan(&facets.Stock).Error; err != nil {
+		return err
+	}
+
+	if err := countOptionFacets(tx, matching(filter), &facets.Options); err != nil {
+		return err
+	}
+	return countAttributeFacets(tx, filter, matching, &facets.Attributes)
+}
+
+// countOptionFacets counts the products having a variant with each option
+// value.
+func countOptionFacets(tx, products *gorm.DB, options *map[string][]OptionFacetValue) error {
+	var counts []struct {
+		Name  string
+		Value string
+		Count int64
+	}
+	if err := tx.Table("product_variants AS v, jsonb_each_text(v.options) AS o(name, value)").
+		Select("o.name, o.value, COUNT(DISTINCT v.product_id) AS count").
+		Where("v.deleted_at IS NULL AND v.product_id IN (?)", products.Select("products.id")).
+		Group("o.name, o.value").
+		Order("o.name, count DESC, o.value").
+		Scan(&counts).Error; err != nil {
+		return err
+	}
+
+	*options = make(map[string][]OptionFacetValue)
+	for _, c := range counts {
+		(*options)[c.Name] = append((*options)[c.Name], OptionFacetValue{Value: c.Value, Count: c.Count})
+	}
+	return nil
+}
+
+// countCategoryFacet counts products by one category column and names the
+// values from table.
+func countCategoryFacet(tx, products *gorm.DB, column, table string, values *[]FacetValue) error {
+	counts := products.
+		Select("products." + column + " AS id, COUNT(*) AS count").
+		Where("products." + column + " IS NOT NULL").
+		Group("products." + column)
+	*values = []FacetValue{}
+	return tx.Table("(?) AS f", counts).
+		Select("f.id, " + table + ".name, f.count").
+		Joins("JOIN " + table + " ON " + table + ".id = f.id AND " + table + ".deleted_at IS NULL").
+		Order("f.count DESC, " + table + ".name").
+		Scan(values).Error
+}
+
+func countPriceFacet(products *gorm.DB, buckets *[]PriceBucket) error {
+	bounds := make([]string, len(priceBucketBounds))
+	for i, bound := range priceBucketBounds {
+		bounds[i] = strconv.FormatFloat(bound, 'f', -1, 64)
+	}
+	var counts []struct {
+		Bucket int
+		Count  int64
+	}
+	// width_bucket gives 0 under the first bound, len(bounds) from the last
+	if err := products.
+		Select("width_bucket(products.price, ARRAY[" + strings.Join(bounds, ",") + "]::numeric[]) AS bucket, COUNT(*) AS count").
+		Group("bucket").
+		Scan(&counts).Error; err != nil {
+		return err
+	}
+
+	*buckets = make([]PriceBucket, len(priceBucketBounds)+1)
+	for i := range *buckets {
+		if i > 0 {
+			(*buckets)[i].Min = priceBucketBounds[i-1]
+		}
+		if i < len(priceBucketBounds) {
+			max := priceBucketBounds[i]
+			(*buckets)[i].Max = &max
+		}
+	}
+	for _, c := range counts {
+		if c.Bucket >= 0 && c.Bucket < len(*buckets) {
+			(*buckets)[c.Bucket].Count = c.Count
+		}
+	}
+	return nil
+}
+
+// countAttributeFacets counts products by attribute value. Attributes that
+// are filtered on are counted without their own filter, one query each.
+func countAttributeFacets(tx *gorm.DB, filter ProductFilter, matching func(ProductFilter) *gorm.DB, attributes *map[string][]OptionFacetValue) error {
+	*attributes = make(map[string][]OptionFacetValue)
+	count := func(products *gorm.DB) error {
+		ranked := products.
+			Joins("CROSS JOIN LATERAL jsonb_each(products.attributes) AS a(key, value)").
+			Where("jsonb_typeof(a.value) IN ('string', 'boolean')").
+			Select("a.key AS name, a.value #>> '{}' AS value, COUNT(*) AS count, " +
+				"ROW_NUMBER() OVER (PARTITION BY a.key ORDER BY COUNT(*) DESC, a.value #>> '{}') AS rank").
+			Group("a.key, a.value #>> '{}'")
+		var counts []struct {
+			Name  string
+			Value string
+			Count int64
+		}
+		if err := tx.Table("(?) AS f", ranked).
+			Select("f.name, f.value, f.count").
+			Where("f.rank <= ?", maxAttributeFacetValues).
+			Order("f.name, f.count DESC, f.value").
+			Scan(&counts).Error; err != nil {
+			return err
+		}
+		for _, c := range counts {
+			(*attributes)[c.Name] = append((*attributes)[c.Name], OptionFacetValue{Value: c.Value, Count: c.Count})
+		}
+		return nil
+	}
+
+	filtered := make([]string, 0, len(filter.Attributes))
+	for key := range filter.Attributes {
+		filtered = append(filtered, key)
+	}
+	sort.Strings(filtered)
+
+	others := matching(filter)
+	if len(filtered) > 0 {
+		others = others.Where("a.key NOT IN ?", filtered)
+	}
+	if err := count(others); err != nil {
+		return err
+	}
+	for _, key := range filtered {
+		without := filter
+		without.Attributes = make(map[string]string, len(filter.Attributes)-1)
+		for k, v := range filter.Attributes {
+			if k != key {
+				without.Attributes[k] = v
+			}
+		}
+		if err := count(matching(without).Where("a.key = ?", key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
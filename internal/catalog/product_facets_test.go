@@ -0,0 +1,164 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// emptyDB is a database/sql driver whose every query returns no rows, so
+// query builders can run without Postgres. It records the SQL it was sent.
+type emptyDB struct {
+	queries []string
+}
+
+func (d *emptyDB) Connect(ctx context.Context) (driver.Conn, error) { return emptyConn{d}, nil }
+func (d *emptyDB) Driver() driver.Driver                            { return nil }
+
+type emptyConn struct{ db *emptyDB }
+
+func (c emptyConn) Prepare(query string) (driver.Stmt, error) { return emptyStmt{c.db, query}, nil }
+func (c emptyConn) Close() error                              { return nil }
+func (c emptyConn) Begin() (driver.Tx, error)                 { return emptyTx{}, nil }
+
+type emptyStmt struct {
+	db    *emptyDB
+	query string
+}
+
+func (s emptyStmt) Close() error  { return nil }
+func (s emptyStmt) NumInput() int { return -1 }
+func (s emptyStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.queries = append(s.db.queries, s.query)
+	return driver.RowsAffected(0), nil
+}
+func (s emptyStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.queries = append(s.db.queries, s.query)
+	return emptyRows{}, nil
+}
+
+type emptyTx struct{}
+
+func (emptyTx) Commit() error   { return nil }
+func (emptyTx) Rollback() error { return nil }
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string              { return nil }
+func (emptyRows) Close() error                   { return nil }
+func (emptyRows) Next(dest []driver.Value) error { return io.EOF }
+
+func newEmptyDB(t *testing.T) (*gorm.DB, *emptyDB) {
+	t.Helper()
+	fake := &emptyDB{}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(fake)}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, fake
+}
+
+func TestCountFacetsIgnoresOwnFilter(t *testing.T) {
+	db, _ := newEmptyDB(t)
+	min, max := 500.0, 1000.0
+	filter := ProductFilter{
+		CategoryID:       1,
+		SubCategoryID:    2,
+		SubSubCategoryID: 3,
+		MinPrice:         &min,
+		MaxPrice:         &max,
+		InStock:          true,
+	}
+
+	var filters []ProductFilter
+	facets := &Facets{}
+	err := countFacets(db, filter, facets, func(f ProductFilter) *gorm.DB {
+		filters = append(filters, f)
+		return db.Model(&Product{})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Attributes have no filter here, so they are counted in one query
+	if len(filters) != 7 {
+		t.Fatalf("counted %d facets, want 7", len(filters))
+	}
+
+	categories, subCategories, subSubCategories, prices, stock := filters[0], filters[1], filters[2], filters[3], filters[4]
+	if categories.CategoryID != 0 || categories.SubCategoryID != 0 || categories.SubSubCategoryID != 0 || !categories.InStock {
+		t.Errorf("category facet filter = %+v, want no category levels", categories)
+	}
+	if subCategories.CategoryID != 1 || subCategories.SubCategoryID != 0 || subCategories.SubSubCategoryID != 0 {
+		t.Errorf("sub-category facet filter = %+v", subCategories)
+	}
+	if subSubCategories.SubCategoryID != 2 || subSubCategories.SubSubCategoryID != 0 {
+		t.Errorf("sub-sub-category facet filter = %+v", subSubCategories)
+	}
+	if prices.MinPrice != nil || prices.MaxPrice != nil || prices.CategoryID != 1 {
+		t.Errorf("price facet filter = %+v, want no price range", prices)
+	}
+	if stock.InStock || stock.MinPrice == nil {
+		t.Errorf("stock facet filter = %+v, want no stock filter", stock)
+	}
+	// Options have no filter yet, so they count the search's own matches
+	if options := filters[5]; options.CategoryID != 1 || !options.InStock {
+		t.Errorf("option facet filter = %+v, want the whole filter", options)
+	}
+	if facets.Options == nil || facets.Attributes == nil {
+		t.Error("option or attribute facets are null, want empty objects")
+	}
+
+	// Every price bucket is listed, even with no products in it
+	if len(facets.Prices) != len(priceBucketBounds)+1 {
+		t.Fatalf("%d price buckets, want %d", len(facets.Prices), len(priceBucketBounds)+1)
+	}
+	first, last := facets.Prices[0], facets.Prices[len(facets.Prices)-1]
+	if first.Min != 0 || first.Max == nil || *first.Max != priceBucketBounds[0] {
+		t.Errorf("first bucket = %+v", first)
+	}
+	if last.Min != priceBucketBounds[len(priceBucketBounds)-1] || last.Max != nil {
+		t.Errorf("last bucket = %+v, want no upper bound", last)
+	}
+}
+
+func TestCountAttributeFacetsIgnoresOwnFilter(t *testing.T) {
+	db, _ := newEmptyDB(t)
+	filter := ProductFilter{CategoryID: 1, Attributes: map[string]string{"fabric": "cotton", "length": "60..80"}}
+
+	var filters []ProductFilter
+	attributes := map[string][]OptionFacetValue{}
+	err := countAttributeFacets(db, filter, func(f ProductFilter) *gorm.DB {
+		filters = append(filters, f)
+		return db.Model(&Product{})
+	}, &attributes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The unfiltered attributes, then each filtered one without its filter
+	if len(filters) != 3 {
+		t.Fatalf("%d queries, want 3", len(filters))
+	}
+	if len(filters[0].Attributes) != 2 {
+		t.Errorf("other attributes counted with %v, want every filter", filters[0].Attributes)
+	}
+	if _, ok := filters[1].Attributes["fabric"]; ok || filters[1].Attributes["length"] != "60..80" {
+		t.Errorf("fabric counted with %v, want only the length filter", filters[1].Attributes)
+	}
+	if _, ok := filters[2].Attributes["length"]; ok || filters[2].CategoryID != 1 {
+		t.Errorf("length counted with %+v, want no length filter", filters[2])
+	}
+	// The caller's filter is left alone
+	if len(filter.Attributes) != 2 {
+		t.Errorf("filter changed to %v", filter.Attributes)
+	}
+}
@@ -0,0 +1,216 @@
+package catalog
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultProductPageSize = 20
+	maxProductPageSize     = 100
+	maxProductIDs          = 100
+)
+
+// Sort orders for product listings.
+const (
+	SortNewest    = "newest"
+	SortPriceAsc  = "price_asc"
+	SortPriceDesc = "price_desc"
+	SortNameAsc   = "name_asc"
+	SortNameDesc  = "name_desc"
+)
+
+var (
+	ErrInvalidCursor     = errors.New("invalid or expired cursor")
+	ErrInvalidPriceRange = errors.New("min_price cannot be more than max_price")
+	ErrTooManyProductIDs = errors.New("too many product ids")
+)
+
+// ProductFilter narrows listings and searches down. Zero values match
+// everything.
+type ProductFilter struct {
+	CategoryID       uint     `form:"category_id"`
+	SubCategoryID    uint     `form:"sub_category_id"`
+	SubSubCategoryID uint     `form:"sub_sub_category_id"`
+	MinPrice         *float64 `form:"min_price" binding:"omitempty,min=0"`
+	MaxPrice         *float64 `form:"max_price" binding:"omitempty,min=0"`
+	InStock          bool     `form:"in_stock"`
+	IDs              []uint   `form:"-"` // ?ids=1,2,3
+
+	// Attributes filter by attribute value, ?attr[fabric]=cotton,linen for
+	// any of several values or ?attr[length]=60..80 for a number range
+	Attributes map[string]string `form:"-"`
+}
+
+func (f ProductFilter) empty() bool {
+	return f.CategoryID == 0 && f.SubCategoryID == 0 && f.SubSubCategoryID == 0 &&
+		f.MinPrice == nil && f.MaxPrice == nil && !f.InStock && len(f.IDs) == 0 &&
+		len(f.Attributes) == 0
+}
+
+func (f ProductFilter) validate() error {
+	if f.MinPrice != nil && f.MaxPrice != nil && *f.MinPrice > *f.MaxPrice {
+		return ErrInvalidPriceRange
+	}
+	if len(f.IDs) > maxProductIDs {
+		return ErrTooManyProductIDs
+	}
+	if len(f.Attributes) > maxAttributeFilters {
+		return fmt.Errorf("%w: at most %d attributes", ErrInvalidAttributeFilter, maxAttributeFilters)
+	}
+	for key, value := range f.Attributes {
+		if !attributeKeyPattern.MatchString(key) {
+			return fmt.Errorf("%w: %s", ErrInvalidAttributeFilter, key)
+		}
+		if _, _, isRange, err := parseAttributeRange(value); isRange && err != nil {
+			return fmt.Errorf("%w: %s must be a range like 10..20", ErrInvalidAttributeFilter, key)
+		}
+	}
+	return nil
+}
+
+// parseAttributeRange parses a number range filter, "min..max" with either
+// end optional.
+func parseAttributeRange(value string) (min, max *float64, isRange bool, err error) {
+	from, to, found := strings.Cut(value, "..")
+	if !found {
+		return nil, nil, false, nil
+	}
+	parse := func(s string) (*float64, error) {
+		if s = strings.TrimSpace(s); s == "" {
+			return nil, nil
+		}
+		n, err := strconv.ParseFloat(s, 64)
+		return &n, err
+	}
+	if min, err = parse(from); err != nil {
+		return nil, nil, true, err
+	}
+	if max, err = parse(to); err != nil {
+		return nil, nil, true, err
+	}
+	if min == nil && max == nil {
+		return nil, nil, true, errors.New("empty range")
+	}
+	return min, max, true, nil
+}
+
+// ProductQuery is the spec the repository lists products by. Pages are
+// either numbered (Page) or, with Cursor, start after the product a previous
+// page's cursor points at.
+type ProductQuery struct {
+	ProductFilter
+	Sort     string `form:"sort" binding:"omitempty,oneof=newest price_asc price_desc name_asc name_desc"`
+	Page     int    `form:"page"`
+	PageSize int    `form:"page_size"`
+	Cursor   string `form:"cursor"`
+
+	cursor *productCursor // Decoded Cursor, set by the service
+}
+
+func (q *ProductQuery) normalize() error {
+	if q.Sort == "" {
+		q.Sort = SortNewest
+	}
+	if q.PageSize < 1 {
+		q.PageSize = defaultProductPageSize
+	}
+	if q.PageSize > maxProductPageSize {
+		q.PageSize = maxProductPageSize
+	}
+	if err := q.validate(); err != nil {
+		return err
+	}
+
+	if q.Cursor != "" {
+		cursor, err := decodeProductCursor(q.Cursor)
+		if err != nil || cursor.Sort != q.Sort {
+			return ErrInvalidCursor
+		}
+		q.cursor = cursor
+		q.Page = 0
+	} else if q.Page < 1 {
+		q.Page = 1
+	}
+	return nil
+}
+
+// ProductPage is one page of a product listing. Page is set for numbered
+// pages; the cursors work in either mode.
+type ProductPage struct {
+	Products   []Product `json:"products"`
+	Total      int64     `json:"total"`
+	Page       int       `json:"page,omitempty"`
+	PageSize   int       `json:"page_size"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	PrevCursor string    `json:"prev_cursor,omitempty"`
+}
+
+// productSort is the column a sort order uses. The product ID breaks ties,
+// in the same direction, so the order is total and cursors are stable.
+type productSort struct {
+	column string
+	desc   bool
+}
+
+var productSorts = map[string]productSort{
+	SortNewest:    {column: "created_at", desc: true},
+	SortPriceAsc:  {column: "price"},
+	SortPriceDesc: {column: "price", desc: true},
+	SortNameAsc:   {column: "name"},
+	SortNameDesc:  {column: "name", desc: true},
+}
+
+// productCursor points at a product in a listing. Before means the page
+// wanted is the one before it rather than after.
+type productCursor struct {
+	Sort   string `json:"s"`
+	Value  string `json:"v"`
+	ID     uint   `json:"id"`
+	Before bool   `json:"b,omitempty"`
+}
+
+func newProductCursor(sort string, product Product, before bool) string {
+	cursor := productCursor{Sort: sort, ID: product.ID, Before: before}
+	switch productSorts[sort].column {
+	case "created_at":
+		cursor.Value = product.CreatedAt.Format(time.RFC3339Nano)
+	case "price":
+		cursor.Value = strconv.FormatFloat(product.Price, 'f', -1, 64)
+	case "name":
+		cursor.Value = product.Name
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeProductCursor(raw string) (*productCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+	var cursor productCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	if _, ok := productSorts[cursor.Sort]; !ok {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// value converts the cursor's value back to the type of its sort column.
+func (c *productCursor) value() (interface{}, error) {
+	switch productSorts[c.Sort].column {
+	case "created_at":
+		return time.Parse(time.RFC3339Nano, c.Value)
+	case "price":
+		return strconv.ParseFloat(c.Value, 64)
+	}
+	return c.Value, nil
+}
@@ -0,0 +1,100 @@
+package catalog
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProductCursorRoundTrip(t *testing.T) {
+	product := Product{
+		ID:        9,
+		Name:      "Panjabi",
+		Price:     1250.5,
+		CreatedAt: time.Date(2024, 3, 1, 10, 0, 0, 123456789, time.UTC),
+	}
+	tests := []struct {
+		sort string
+		want interface{}
+	}{
+		{SortNewest, product.CreatedAt},
+		{SortPriceAsc, product.Price},
+		{SortNameDesc, product.Name},
+	}
+	for _, tt := range tests {
+		cursor, err := decodeProductCursor(newProductCursor(tt.sort, product, true))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.sort, err)
+		}
+		if cursor.Sort != tt.sort || cursor.ID != product.ID || !cursor.Before {
+			t.Errorf("%s: decoded %+v", tt.sort, cursor)
+		}
+		value, err := cursor.value()
+		if err != nil {
+			t.Fatalf("%s: value: %v", tt.sort, err)
+		}
+		if at, ok := value.(time.Time); ok {
+			if !at.Equal(product.CreatedAt) {
+				t.Errorf("%s: value = %v, want %v", tt.sort, at, product.CreatedAt)
+			}
+		} else if value != tt.want {
+			t.Errorf("%s: value = %v, want %v", tt.sort, value, tt.want)
+		}
+	}
+}
+
+func TestDecodeProductCursorRejectsInvalid(t *testing.T) {
+	encode := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+	for name, raw := range map[string]string{
+		"not base64":   "%%%",
+		"not json":     encode("nope"),
+		"unknown sort": encode(`{"s":"rating","v":"5","id":1}`),
+		"no sort":      encode(`{"v":"5","id":1}`),
+	} {
+		if _, err := decodeProductCursor(raw); err == nil {
+			t.Errorf("%s: decodeProductCursor succeeded", name)
+		}
+	}
+}
+
+func TestProductQueryNormalize(t *testing.T) {
+	q := ProductQuery{PageSize: 1000}
+	if err := q.normalize(); err != nil {
+		t.Fatal(err)
+	}
+	if q.Sort != SortNewest || q.Page != 1 || q.PageSize != maxProductPageSize {
+		t.Errorf("normalized to %+v", q)
+	}
+
+	min, max := 500.0, 100.0
+	q = ProductQuery{ProductFilter: ProductFilter{MinPrice: &min, MaxPrice: &max}}
+	if err := q.normalize(); !errors.Is(err, ErrInvalidPriceRange) {
+		t.Errorf("min above max: got %v, want ErrInvalidPriceRange", err)
+	}
+
+	q = ProductQuery{ProductFilter: ProductFilter{IDs: make([]uint, maxProductIDs+1)}}
+	if err := q.normalize(); !errors.Is(err, ErrTooManyProductIDs) {
+		t.Errorf("too many ids: got %v, want ErrTooManyProductIDs", err)
+	}
+}
+
+func TestProductQueryNormalizeCursor(t *testing.T) {
+	cursor := newProductCursor(SortPriceAsc, Product{ID: 3, Price: 99}, false)
+
+	q := ProductQuery{Sort: SortPriceAsc, Cursor: cursor, Page: 4}
+	if err := q.normalize(); err != nil {
+		t.Fatal(err)
+	}
+	if q.cursor == nil || q.cursor.ID != 3 || q.Page != 0 {
+		t.Errorf("normalized to %+v, want the cursor decoded and no page number", q)
+	}
+
+	// A cursor only works with the sort order it came from
+	q = ProductQuery{Sort: SortNameAsc, Cursor: cursor}
+	if err := q.normalize(); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("other sort: got %v, want ErrInvalidCursor", err)
+	}
+}
@@ -0,0 +1,129 @@
+package catalog
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	minSearchTermLength = 2
+	maxSearchTermLength = 100
+
+	// Fuzzy matches need at least this word similarity to the name
+	searchSimilarityThreshold = 0.3
+
+	searchReindexBatchSize = 1000
+)
+
+var (
+	ErrSearchTermRequired = errors.New("search term is required")
+	ErrSearchTermTooShort = errors.New("search term must be at least 2 characters")
+	ErrSearchTermTooLong  = errors.New("search term must be at most 100 characters")
+)
+
+// Search match kinds. Fuzzy results come from trigram similarity when the
+// full-text search found nothing, e.g. for a misspelt name.
+const (
+	MatchFullText = "fulltext"
+	MatchFuzzy    = "fuzzy"
+)
+
+// SearchQuery is a product search, filtered like a listing. Pages are
+// numbered like ProductQuery's.
+type SearchQuery struct {
+	ProductFilter
+	Term     string `form:"q"`
+	Page     int    `form:"page"`
+	PageSize int    `form:"page_size"`
+}
+
+func (q *SearchQuery) normalize() error {
+	q.Term = strings.Join(strings.Fields(q.Term), " ")
+	switch n := len([]rune(q.Term)); {
+	case n == 0:
+		return ErrSearchTermRequired
+	case n < minSearchTermLength:
+		return ErrSearchTermTooShort
+	case n > maxSearchTermLength:
+		return ErrSearchTermTooLong
+	}
+	if err := q.validate(); err != nil {
+		return err
+	}
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize < 1 {
+		q.PageSize = defaultProductPageSize
+	}
+	if q.PageSize > maxProductPageSize {
+		q.PageSize = maxProductPageSize
+	}
+	return nil
+}
+
+func (q SearchQuery) offset() int {
+	return (q.Page - 1) * q.PageSize
+}
+
+// SearchHit is a product found by a search. The highlights are HTML-escaped
+// text with matched words in <mark> tags, and are only set for full-text
+// matches.
+type SearchHit struct {
+	Product
+	Rank                 float64 `json:"rank"`
+	NameHighlight        string  `json:"name_highlight,omitempty"`
+	DescriptionHighlight string  `json:"description_highlight,omitempty"`
+}
+
+// SearchPage is one page of search results, best match first, with the
+// facets of all the results.
+type SearchPage struct {
+	Hits     []SearchHit `json:"hits"`
+	Total    int64       `json:"total"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+	Match    string      `json:"match"`
+	Facets   *Facets     `json:"facets"`
+}
+
+// escapeHTMLSQL wraps a SQL text expression so it is HTML-escaped, like
+// html.EscapeString.
+func escapeHTMLSQL(expr string) string {
+	return "replace(replace(replace(replace(replace(" + expr +
+		`, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&#34;'), '''', '&#39;')`
+}
+
+// ReindexSearch recomputes the search vector of every product, in batches,
+// and rebuilds the search indexes. The vector is a generated column, so this
+// is only needed after the text search configuration changes.
+func ReindexSearch(db *gorm.DB) (int64, error) {
+	var lastID uint
+	var total int64
+	for {
+		var ids []uint
+		if err := db.Raw("SELECT id FROM products WHERE id > ? ORDER BY id LIMIT ?", lastID, searchReindexBatchSize).
+			Scan(&ids).Error; err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		// ✅ Any update recomputes the generated column
+		result := db.Exec("UPDATE products SET name = name WHERE id IN ?", ids)
+		if result.Error != nil {
+			return total, result.Error
+		}
+		total += result.RowsAffected
+		lastID = ids[len(ids)-1]
+	}
+
+	for _, index := range []string{"idx_products_search_vector", "idx_products_name_trgm"} {
+		if err := db.Exec("REINDEX INDEX CONCURRENTLY " + index).Error; err != nil {
+			return total, err
+		}
+	}
+	return total, db.Exec("ANALYZE products").Error
+}
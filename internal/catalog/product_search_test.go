@@ -0,0 +1,135 @@
+package catalog
+
+import (
+	"errors"
+	"html"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// fakeSearchRepo embeds ProductRepository so methods a test doesn't set up
+// panic.
+type fakeSearchRepo struct {
+	ProductRepository
+	fullText, fuzzy []SearchHit
+	// filtered are the full-text hits left once a filter is applied
+	filtered []SearchHit
+	searched []SearchQuery
+}
+
+func (r *fakeSearchRepo) SearchProducts(query SearchQuery, match string) ([]SearchHit, int64, error) {
+	r.searched = append(r.searched, query)
+	hits := r.fullText
+	switch {
+	case match == MatchFuzzy:
+		hits = r.fuzzy
+	case !query.empty():
+		hits = r.filtered
+	}
+	return hits, int64(len(hits)), nil
+}
+
+func (r *fakeSearchRepo) SearchFacets(query SearchQuery, match string) (*Facets, error) {
+	return &Facets{}, nil
+}
+
+func TestSearchQueryNormalize(t *testing.T) {
+	q := SearchQuery{Term: "  red \t saree ", PageSize: 1000}
+	if err := q.normalize(); err != nil {
+		t.Fatal(err)
+	}
+	if q.Term != "red saree" || q.Page != 1 || q.PageSize != maxProductPageSize {
+		t.Errorf("normalized to %+v", q)
+	}
+
+	for term, want := range map[string]error{
+		"   ":                    ErrSearchTermRequired,
+		"a":                      ErrSearchTermTooShort,
+		strings.Repeat("a", 101): ErrSearchTermTooLong,
+	} {
+		q := SearchQuery{Term: term}
+		if err := q.normalize(); !errors.Is(err, want) {
+			t.Errorf("%q: got %v, want %v", term, err, want)
+		}
+	}
+	// Lengths count characters, not bytes
+	q = SearchQuery{Term: "শাড়ি"}
+	if err := q.normalize(); err != nil {
+		t.Errorf("Bangla term: %v", err)
+	}
+}
+
+func TestSearchProductsFallsBackToFuzzy(t *testing.T) {
+	repo := &fakeSearchRepo{fullText: []SearchHit{{Product: Product{ID: 1}}}}
+	s := &productService{repo: repo}
+
+	page, err := s.SearchProducts(SearchQuery{Term: "saree"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Match != MatchFullText || page.Total != 1 {
+		t.Errorf("page = %+v, want the full-text hit", page)
+	}
+
+	repo.fullText = nil
+	repo.fuzzy = []SearchHit{{Product: Product{ID: 2}}, {Product: Product{ID: 3}}}
+	page, err = s.SearchProducts(SearchQuery{Term: "sarre"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Match != MatchFuzzy || page.Total != 2 || page.Hits[0].ID != 2 {
+		t.Errorf("page = %+v, want the fuzzy hits", page)
+	}
+
+	if _, err := s.SearchProducts(SearchQuery{Term: "x"}); !errors.Is(err, ErrSearchTermTooShort) {
+		t.Errorf("short term: got %v, want ErrSearchTermTooShort", err)
+	}
+	if len(repo.searched) != 3 {
+		t.Errorf("searched %d times, want invalid terms rejected before the query", len(repo.searched))
+	}
+}
+
+func TestSearchProductsKeepsFullTextWhenFiltersRuleOutMatches(t *testing.T) {
+	repo := &fakeSearchRepo{
+		fullText: []SearchHit{{Product: Product{ID: 1}}},
+		fuzzy:    []SearchHit{{Product: Product{ID: 2}}},
+	}
+	s := &productService{repo: repo}
+
+	page, err := s.SearchProducts(SearchQuery{Term: "saree", ProductFilter: ProductFilter{InStock: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The term matches, just not in stock: no fuzzy results for a correct term
+	if page.Match != MatchFullText || page.Total != 0 || len(page.Hits) != 0 {
+		t.Errorf("page = %+v, want no hits from the full-text search", page)
+	}
+	if page.Facets == nil {
+		t.Error("no facets")
+	}
+}
+
+// TestEscapeHTMLSQL applies the replacements in the generated SQL, in order,
+// and checks they escape like html.EscapeString.
+func TestEscapeHTMLSQL(t *testing.T) {
+	expr := escapeHTMLSQL("p.name")
+	if !strings.Contains(expr, "(p.name,") {
+		t.Fatalf("expression %q does not wrap p.name", expr)
+	}
+
+	unquote := func(s string) string { return strings.ReplaceAll(s, "''", "'") }
+	pairs := regexp.MustCompile(`'((?:[^']|'')*)', '((?:[^']|'')*)'\)`).FindAllStringSubmatch(expr, -1)
+	if len(pairs) != 5 {
+		t.Fatalf("found %d replacements in %q, want 5", len(pairs), expr)
+	}
+
+	text := `Tom & Jerry's <script>alert("x")</script> &amp;`
+	got := text
+	for _, pair := range pairs {
+		got = strings.ReplaceAll(got, unquote(pair[1]), unquote(pair[2]))
+	}
+	if want := html.EscapeString(text); got != want {
+		t.Errorf("escaped to %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,119 @@
+package catalog
+
+import (
+	"ecommerce/internal/audit"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (c *ProductController) ListOptionTypes(ctx *gin.Context) {
+	optionTypes, err := c.productService.ListOptionTypes()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve option types",
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"option_types": optionTypes})
+}
+
+func (c *ProductController) CreateOptionType(ctx *gin.Context) {
+	var req CreateOptionTypeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	optionType, err := c.productService.CreateOptionType(audit.ActorFromContext(ctx), req.Name)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrOptionTypeExists) {
+			status = http.StatusConflict
+		}
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"option_type": optionType})
+}
+
+// GenerateVariants creates the product's variant matrix from option values
+// in one call, e.g. {"options": {"Size": ["S", "M"], "Color": ["Red"]}}.
+func (c *ProductController) GenerateVariants(ctx *gin.Context) {
+	productID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID format",
+		})
+		return
+	}
+	var req GenerateVariantsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	variants, err := c.productService.GenerateVariants(audit.ActorFromContext(ctx), uint(productID), req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrVariantSKUExists) {
+			status = http.StatusConflict
+		}
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message":  strconv.Itoa(len(variants)) + " variants created",
+		"variants": variants,
+	})
+}
+
+func (c *ProductController) UpdateVariant(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid variant ID format",
+		})
+		return
+	}
+	var req UpdateVariantRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	variant, err := c.productService.UpdateVariant(audit.ActorFromContext(ctx), uint(id), req)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, ErrVariantNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, ErrVariantSKUExists):
+			status = http.StatusConflict
+		}
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"variant": variant})
+}
+
+func (c *ProductController) DeleteVariant(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid variant ID format",
+		})
+		return
+	}
+
+	if err := c.productService.DeleteVariant(audit.ActorFromContext(ctx), uint(id)); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrVariantNotFound) {
+			status = http.StatusNotFound
+		}
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Variant deleted successfully"})
+}
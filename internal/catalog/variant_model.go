@@ -0,0 +1,135 @@
+package catalog
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// maxVariantsPerProduct caps the matrix one generate call can create.
+const maxVariantsPerProduct = 200
+
+var (
+	ErrVariantNotFound        = errors.New("variant not found")
+	ErrVariantRequired        = errors.New("product has variants, variant_id is required")
+	ErrUnknownOptionType      = errors.New("unknown option type")
+	ErrInvalidOptionValues    = errors.New("every option needs at least one value")
+	ErrTooManyVariants        = errors.New("too many variants")
+	ErrVariantOptionsMismatch = errors.New("variants must use the same options as the product's other variants")
+	ErrOptionTypeExists       = errors.New("option type already exists")
+	ErrStockManagedByVariants = errors.New("product has variants, set stock on its variants")
+	ErrVariantSKUExists       = errors.New("a variant with this SKU already exists")
+)
+
+// OptionType is something products vary by, e.g. Size or Color.
+type OptionType struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// VariantOptions maps option type names to a variant's values, e.g.
+// {"Size": "M", "Color": "Red"}. Stored as jsonb.
+type VariantOptions map[string]string
+
+func (o VariantOptions) Value() (driver.Value, error) {
+	if o == nil {
+		return nil, nil
+	}
+	return json.Marshal(o)
+}
+
+func (o *VariantOptions) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*o = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type for variant options")
+	}
+	return json.Unmarshal(data, o)
+}
+
+// Label is the options as shown to customers, e.g. "Color: Red, Size: M".
+func (o VariantOptions) Label() string {
+	names := make([]string, 0, len(o))
+	for name := range o {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + ": " + o[name]
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ProductVariant is one combination of option values of a product, with its
+// own SKU and stock. Price overrides the product's when set.
+type ProductVariant struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	ProductID uint           `json:"product_id" gorm:"not null;index"`
+	SKU       string         `json:"sku" gorm:"not null"`
+	Price     *float64       `json:"price,omitempty"`
+	Stock     int            `json:"stock" gorm:"not null;default:0"`
+	Images    pq.StringArray `json:"images" gorm:"type:text[]"`
+	Options   VariantOptions `json:"options" gorm:"type:jsonb;not null"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// EffectivePrice is the variant's price, or its product's without an override.
+func (v *ProductVariant) EffectivePrice(product *Product) float64 {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return product.Price
+}
+
+// FindVariant returns the product's variant with the given ID, or nil.
+// Variants must have been loaded with the product.
+func (p *Product) FindVariant(id uint) *ProductVariant {
+	for i := range p.Variants {
+		if p.Variants[i].ID == id {
+			return &p.Variants[i]
+		}
+	}
+	return nil
+}
+
+// GenerateVariantsRequest creates a variant for every combination of the
+// option values, e.g. {"Size": ["S", "M"], "Color": ["Red"]} gives two.
+// Combinations the product already has are skipped.
+type GenerateVariantsRequest struct {
+	Options map[string][]string `json:"options" binding:"required,min=1"`
+	Price   *float64            `json:"price" binding:"omitempty,min=0"`
+	Stock   int                 `json:"stock" binding:"min=0"`
+}
+
+// UpdateVariantRequest changes the given fields of a variant. ClearPrice
+// drops the price override.
+type UpdateVariantRequest struct {
+	SKU        string   `json:"sku"`
+	Price      *float64 `json:"price" binding:"omitempty,min=0"`
+	ClearPrice bool     `json:"clear_price"`
+	Stock      *int     `json:"stock" binding:"omitempty,min=0"`
+	Images     []string `json:"images"`
+}
+
+type CreateOptionTypeRequest struct {
+	Name string `json:"name" binding:"required,max=50"`
+}
@@ -0,0 +1,81 @@
+package catalog
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+func (r *productRepository) FindOptionTypes() ([]OptionType, error) {
+	var optionTypes []OptionType
+	err := r.db.Order("id").Find(&optionTypes).Error
+	return optionTypes, err
+}
+
+func (r *productRepository) CreateOptionType(optionType *OptionType) error {
+	return r.db.Create(optionType).Error
+}
+
+func (r *productRepository) FindVariantByID(id uint) (*ProductVariant, error) {
+	var variant ProductVariant
+	if err := r.db.First(&variant, id).Error; err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+// FindVariantSKUsWithPrefix returns the SKUs of live variants, of any
+// product, that start with prefix.
+func (r *productRepository) FindVariantSKUsWithPrefix(prefix string) ([]string, error) {
+	var skus []string
+	err := r.db.Model(&ProductVariant{}).Where("starts_with(sku, ?)", prefix).Pluck("sku", &skus).Error
+	return skus, err
+}
+
+func (r *productRepository) CreateVariants(productID uint, variants []ProductVariant) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&variants).Error; err != nil {
+			return skuConflict(err)
+		}
+		return syncVariantStock(tx, productID)
+	})
+}
+
+func (r *productRepository) UpdateVariant(variant *ProductVariant) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(variant).Error; err != nil {
+			return skuConflict(err)
+		}
+		return syncVariantStock(tx, variant.ProductID)
+	})
+}
+
+func (r *productRepository) DeleteVariant(variant *ProductVariant) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(variant).Error; err != nil {
+			return err
+		}
+		return syncVariantStock(tx, variant.ProductID)
+	})
+}
+
+// skuConflict turns a violation of the unique variant SKU index into
+// ErrVariantSKUExists.
+func skuConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_product_variants_sku" {
+		return ErrVariantSKUExists
+	}
+	return err
+}
+
+// syncVariantStock sets a product's stock to the sum of its variants', so
+// listings and the in-stock filter keep working on products.stock. A product
+// whose last variant is deleted keeps its stock.
+func syncVariantStock(tx *gorm.DB, productID uint) error {
+	return tx.Exec(`UPDATE products SET stock = v.stock, updated_at = NOW()
+		FROM (SELECT SUM(stock) AS stock FROM product_variants
+			WHERE product_id = ? AND deleted_at IS NULL) v
+		WHERE products.id = ? AND v.stock IS NOT NULL`, productID, productID).Error
+}
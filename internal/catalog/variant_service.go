@@ -0,0 +1,253 @@
+package catalog
+
+import (
+	"ecommerce/internal/audit"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/lib/pq"
+)
+
+func (s *productService) ListOptionTypes() ([]OptionType, error) {
+	return s.repo.FindOptionTypes()
+}
+
+func (s *productService) CreateOptionType(actor audit.Actor, name string) (*OptionType, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("option type name is required")
+	}
+	existing, err := s.repo.FindOptionTypes()
+	if err != nil {
+		return nil, err
+	}
+	for _, optionType := range existing {
+		if strings.EqualFold(optionType.Name, name) {
+			return nil, ErrOptionTypeExists
+		}
+	}
+
+	optionType := &OptionType{Name: name}
+	if err := s.repo.CreateOptionType(optionType); err != nil {
+		return nil, err
+	}
+	s.audit.Record(actor, audit.ActionOptionTypeCreate, "option_type", optionType.ID, nil, optionType)
+	return optionType, nil
+}
+
+// variantOption is one option of a variant matrix with its values.
+type variantOption struct {
+	name   string
+	values []string
+}
+
+// GenerateVariants creates the variants for every combination of the
+// request's option values that the product doesn't have yet, and returns the
+// new ones.
+func (s *productService) GenerateVariants(actor audit.Actor, productID uint, req GenerateVariantsRequest) ([]ProductVariant, error) {
+	product, err := s.repo.FindByID(productID)
+	if err != nil {
+		return nil, errors.New("product not found")
+	}
+	options, err := s.resolveOptions(req.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	// ✅ Every variant of a product varies by the same options
+	if len(product.Variants) > 0 {
+		existing := product.Variants[0].Options
+		if len(existing) != len(options) {
+			return nil, ErrVariantOptionsMismatch
+		}
+		for _, option := range options {
+			if _, ok := existing[option.name]; !ok {
+				return nil, ErrVariantOptionsMismatch
+			}
+		}
+	}
+
+	combinations := 1
+	for _, option := range options {
+		combinations *= len(option.values)
+		if combinations > maxVariantsPerProduct {
+			return nil, fmt.Errorf("%w: at most %d per product", ErrTooManyVariants, maxVariantsPerProduct)
+		}
+	}
+
+	have := make(map[string]bool, len(product.Variants))
+	for _, variant := range product.Variants {
+		have[variant.Options.Label()] = true
+	}
+	// ✅ Different values can give the same SKU, e.g. X-L and XL, so SKUs
+	// already in use get a numeric suffix
+	existing, err := s.repo.FindVariantSKUsWithPrefix(variantSKU(product, nil) + "-")
+	if err != nil {
+		return nil, err
+	}
+	takenSKUs := make(map[string]bool, len(existing))
+	for _, sku := range existing {
+		takenSKUs[sku] = true
+	}
+	var variants []ProductVariant
+	for _, values := range combine(options) {
+		variantOptions := make(VariantOptions, len(options))
+		for i, option := range options {
+			variantOptions[option.name] = values[i]
+		}
+		if have[variantOptions.Label()] {
+			continue
+		}
+		variants = append(variants, ProductVariant{
+			ProductID: product.ID,
+			SKU:       uniqueSKU(variantSKU(product, values), takenSKUs),
+			Price:     req.Price,
+			Stock:     req.Stock,
+			Images:    pq.StringArray{},
+			Options:   variantOptions,
+		})
+	}
+	if len(variants) == 0 {
+		return []ProductVariant{}, nil
+	}
+
+	if err := s.repo.CreateVariants(product.ID, variants); err != nil {
+		return nil, err
+	}
+	s.audit.Record(actor, audit.ActionVariantCreate, "product", product.ID, nil, map[string]interface{}{"variants": variants})
+	return variants, nil
+}
+
+// resolveOptions matches the requested option names to option types,
+// ignoring case, and orders them like the option types.
+func (s *productService) resolveOptions(requested map[string][]string) ([]variantOption, error) {
+	optionTypes, err := s.repo.FindOptionTypes()
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string][]string, len(requested))
+	for name, values := range requested {
+		key := strings.ToLower(strings.TrimSpace(name))
+		byName[key] = append(byName[key], values...)
+	}
+
+	var options []variantOption
+	for _, optionType := range optionTypes {
+		values, ok := byName[strings.ToLower(optionType.Name)]
+		if !ok {
+			continue
+		}
+		delete(byName, strings.ToLower(optionType.Name))
+
+		option := variantOption{name: optionType.Name}
+		seen := make(map[string]bool, len(values))
+		for _, value := range values {
+			value = strings.TrimSpace(value)
+			if value == "" || seen[strings.ToLower(value)] {
+				continue
+			}
+			seen[strings.ToLower(value)] = true
+			option.values = append(option.values, value)
+		}
+		if len(option.values) == 0 {
+			return nil, ErrInvalidOptionValues
+		}
+		options = append(options, option)
+	}
+	for name := range byName {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownOptionType, name)
+	}
+	return options, nil
+}
+
+// combine returns every combination of the options' values, the first
+// option varying slowest.
+func combine(options []variantOption) [][]string {
+	combinations := [][]string{{}}
+	for _, option := range options {
+		var next [][]string
+		for _, combination := range combinations {
+			for _, value := range option.values {
+				values := append(append([]string{}, combination...), value)
+				next = append(next, values)
+			}
+		}
+		combinations = next
+	}
+	return combinations
+}
+
+// variantSKU is the product's SKU with the option values appended, e.g.
+// TSHIRT-01-M-RED.
+func variantSKU(product *Product, values []string) string {
+	parts := []string{product.SKU}
+	if product.SKU == "" {
+		parts[0] = fmt.Sprintf("P%d", product.ID)
+	}
+	for _, value := range values {
+		code := strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return unicode.ToUpper(r)
+			}
+			return -1
+		}, value)
+		if code != "" {
+			parts = append(parts, code)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// uniqueSKU returns sku, or sku with the lowest suffix -2, -3... that isn't
+// taken, and marks it taken.
+func uniqueSKU(sku string, taken map[string]bool) string {
+	unique := sku
+	for n := 2; taken[unique]; n++ {
+		unique = fmt.Sprintf("%s-%d", sku, n)
+	}
+	taken[unique] = true
+	return unique
+}
+
+func (s *productService) UpdateVariant(actor audit.Actor, id uint, req UpdateVariantRequest) (*ProductVariant, error) {
+	variant, err := s.repo.FindVariantByID(id)
+	if err != nil {
+		return nil, ErrVariantNotFound
+	}
+	before := *variant
+
+	if sku := strings.TrimSpace(req.SKU); sku != "" {
+		variant.SKU = sku
+	}
+	if req.ClearPrice {
+		variant.Price = nil
+	} else if req.Price != nil {
+		variant.Price = req.Price
+	}
+	if req.Stock != nil {
+		variant.Stock = *req.Stock
+	}
+	if len(req.Images) > 0 {
+		variant.Images = pq.StringArray(req.Images)
+	}
+
+	if err := s.repo.UpdateVariant(variant); err != nil {
+		return nil, err
+	}
+	s.audit.Record(actor, audit.ActionVariantUpdate, "variant", id, before, variant)
+	return variant, nil
+}
+
+func (s *productService) DeleteVariant(actor audit.Actor, id uint) error {
+	variant, err := s.repo.FindVariantByID(id)
+	if err != nil {
+		return ErrVariantNotFound
+	}
+	if err := s.repo.DeleteVariant(variant); err != nil {
+		return err
+	}
+	s.audit.Record(actor, audit.ActionVariantDelete, "variant", id, variant, nil)
+	return nil
+}
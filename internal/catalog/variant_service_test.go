@@ -0,0 +1,259 @@
+package catalog
+
+import (
+	"ecommerce/internal/audit"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestVariantSKU(t *testing.T) {
+	tests := []struct {
+		name    string
+		product Product
+		values  []string
+		want    string
+	}{
+		{"product SKU", Product{ID: 3, SKU: "TSHIRT-01"}, []string{"M", "Red"}, "TSHIRT-01-M-RED"},
+		{"no product SKU", Product{ID: 3}, []string{"m"}, "P3-M"},
+		{"punctuation dropped", Product{SKU: "TS"}, []string{"X-L", "Navy Blue"}, "TS-XL-NAVYBLUE"},
+		{"empty code dropped", Product{SKU: "TS"}, []string{"--", "L"}, "TS-L"},
+		{"no values", Product{SKU: "TS"}, nil, "TS"},
+	}
+	for _, tt := range tests {
+		if got := variantSKU(&tt.product, tt.values); got != tt.want {
+			t.Errorf("%s: variantSKU = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUniqueSKU(t *testing.T) {
+	// X-L and XL make the same code, so the second gets a suffix
+	product := &Product{SKU: "TS"}
+	taken := map[string]bool{"TS-S": true}
+
+	got := []string{
+		uniqueSKU(variantSKU(product, []string{"X-L"}), taken),
+		uniqueSKU(variantSKU(product, []string{"XL"}), taken),
+		uniqueSKU(variantSKU(product, []string{"xl"}), taken),
+		uniqueSKU(variantSKU(product, []string{"S"}), taken),
+	}
+	want := []string{"TS-XL", "TS-XL-2", "TS-XL-3", "TS-S-2"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("uniqueSKU #%d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+// fakeVariantRepo embeds ProductRepository so methods a test doesn't set up
+// panic.
+type fakeVariantRepo struct {
+	ProductRepository
+	product     *Product
+	optionTypes []OptionType
+	created     []ProductVariant
+	updated     *Product
+
+	// otherSKUs are variant SKUs of other products
+	otherSKUs []string
+}
+
+func (r *fakeVariantRepo) FindByID(id uint) (*Product, error) {
+	if r.product == nil || r.product.ID != id {
+		return nil, errors.New("record not found")
+	}
+	return r.product, nil
+}
+
+func (r *fakeVariantRepo) FindOptionTypes() ([]OptionType, error) {
+	return r.optionTypes, nil
+}
+
+func (r *fakeVariantRepo) FindVariantSKUsWithPrefix(prefix string) ([]string, error) {
+	var skus []string
+	for _, sku := range r.otherSKUs {
+		if strings.HasPrefix(sku, prefix) {
+			skus = append(skus, sku)
+		}
+	}
+	for _, variant := range r.product.Variants {
+		if strings.HasPrefix(variant.SKU, prefix) {
+			skus = append(skus, variant.SKU)
+		}
+	}
+	return skus, nil
+}
+
+func (r *fakeVariantRepo) Update(product *Product) error {
+	r.updated = product
+	return nil
+}
+
+func (r *fakeVariantRepo) CreateVariants(productID uint, variants []ProductVariant) error {
+	r.created = append(r.created, variants...)
+	return nil
+}
+
+type nopAudit struct {
+	audit.Service
+}
+
+func (nopAudit) Record(actor audit.Actor, action, targetType string, targetID interface{}, before, after interface{}) {
+}
+
+func newVariantTestService(product *Product) (*productService, *fakeVariantRepo) {
+	repo := &fakeVariantRepo{
+		product:     product,
+		optionTypes: []OptionType{{ID: 1, Name: "Size"}, {ID: 2, Name: "Color"}},
+	}
+	return &productService{repo: repo, audit: nopAudit{}}, repo
+}
+
+func TestGenerateVariants(t *testing.T) {
+	s, repo := newVariantTestService(&Product{ID: 1, SKU: "TS", Price: 500})
+	price := 650.0
+
+	variants, err := s.GenerateVariants(audit.Actor{}, 1, GenerateVariantsRequest{
+		Options: map[string][]string{"color": {"Red", " red ", "Blue"}, "SIZE": {"S", "M", ""}},
+		Price:   &price,
+		Stock:   3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Options follow the option types' order, so Size varies slowest
+	want := []string{"TS-S-RED", "TS-S-BLUE", "TS-M-RED", "TS-M-BLUE"}
+	if len(variants) != len(want) || len(repo.created) != len(want) {
+		t.Fatalf("created %d variants, want %d", len(variants), len(want))
+	}
+	for i, variant := range variants {
+		if variant.SKU != want[i] {
+			t.Errorf("variant %d SKU = %q, want %q", i, variant.SKU, want[i])
+		}
+		if variant.Stock != 3 || variant.EffectivePrice(repo.product) != 650 {
+			t.Errorf("variant %d = %+v", i, variant)
+		}
+	}
+	if variants[0].Options["Size"] != "S" || variants[0].Options["Color"] != "Red" {
+		t.Errorf("options = %v, want the option types' names", variants[0].Options)
+	}
+
+	// Generating again only adds the missing combinations
+	repo.product.Variants = variants
+	repo.created = nil
+	added, err := s.GenerateVariants(audit.Actor{}, 1, GenerateVariantsRequest{
+		Options: map[string][]string{"Size": {"M", "L"}, "Color": {"Red"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 1 || added[0].SKU != "TS-L-RED" {
+		t.Errorf("added %+v, want only L/Red", added)
+	}
+}
+
+func TestGenerateVariantsAvoidsTakenSKUs(t *testing.T) {
+	// Another product's SKU is a prefix of this one's variant SKUs
+	s, repo := newVariantTestService(&Product{ID: 1, SKU: "TS"})
+	repo.otherSKUs = []string{"TS-S-RED", "TSX-S-BLUE"}
+
+	variants, err := s.GenerateVariants(audit.Actor{}, 1, GenerateVariantsRequest{
+		Options: map[string][]string{"Size": {"S"}, "Color": {"Red", "Blue"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(variants) != 2 || variants[0].SKU != "TS-S-RED-2" || variants[1].SKU != "TS-S-BLUE" {
+		t.Errorf("variants = %+v, want TS-S-RED-2 and TS-S-BLUE", variants)
+	}
+}
+
+func TestUpdateProductStockWithVariants(t *testing.T) {
+	stock := func(n int) *int { return &n }
+	s, repo := newVariantTestService(&Product{ID: 1, Stock: 5, Variants: []ProductVariant{{ID: 1, Stock: 5}}})
+
+	// Edits that leave the stock out, or send it unchanged, go through
+	for _, sent := range []*int{nil, stock(5)} {
+		if _, err := s.UpdateProduct(audit.Actor{}, 1, "Shirt", nil, "", "", 0, sent, 0, nil, nil, nil); err != nil {
+			t.Errorf("stock %v: %v", sent, err)
+		}
+	}
+	repo.updated = nil
+	if _, err := s.UpdateProduct(audit.Actor{}, 1, "", nil, "", "", 0, stock(7), 0, nil, nil, nil); !errors.Is(err, ErrStockManagedByVariants) {
+		t.Errorf("changing stock: got %v, want ErrStockManagedByVariants", err)
+	}
+	if repo.updated != nil {
+		t.Error("product saved after a refused stock change")
+	}
+
+	repo.product.Variants = nil
+	if _, err := s.UpdateProduct(audit.Actor{}, 1, "", nil, "", "", 0, stock(7), 0, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if repo.updated.Stock != 7 {
+		t.Errorf("stock = %d, want 7", repo.updated.Stock)
+	}
+}
+
+func TestGenerateVariantsRejects(t *testing.T) {
+	many := make([]string, 15)
+	for i := range many {
+		many[i] = fmt.Sprint(i)
+	}
+	tests := map[string]struct {
+		options map[string][]string
+		want    error
+	}{
+		"other options":  {map[string][]string{"Color": {"Red"}}, ErrVariantOptionsMismatch},
+		"more options":   {map[string][]string{"Size": {"M"}, "Color": {"Red"}}, ErrVariantOptionsMismatch},
+		"unknown option": {map[string][]string{"Size": {"M"}, "Material": {"Cotton"}}, ErrUnknownOptionType},
+		"no values":      {map[string][]string{"Size": {" ", ""}}, ErrInvalidOptionValues},
+	}
+	for name, tt := range tests {
+		s, repo := newVariantTestService(&Product{ID: 1, SKU: "TS", Variants: []ProductVariant{
+			{ID: 1, Options: VariantOptions{"Size": "S"}},
+		}})
+		if _, err := s.GenerateVariants(audit.Actor{}, 1, GenerateVariantsRequest{Options: tt.options}); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", name, err, tt.want)
+		}
+		if len(repo.created) != 0 {
+			t.Errorf("%s: created %d variants", name, len(repo.created))
+		}
+	}
+
+	// 15 x 15 is over the limit of 200
+	s, repo := newVariantTestService(&Product{ID: 1, SKU: "TS"})
+	_, err := s.GenerateVariants(audit.Actor{}, 1, GenerateVariantsRequest{
+		Options: map[string][]string{"Size": many, "Color": many},
+	})
+	if !errors.Is(err, ErrTooManyVariants) || len(repo.created) != 0 {
+		t.Errorf("big matrix: got %v, created %d", err, len(repo.created))
+	}
+}
+
+func TestVariantOptions(t *testing.T) {
+	options := VariantOptions{"Size": "M", "Color": "Red"}
+	if got := options.Label(); got != "Color: Red, Size: M" {
+		t.Errorf("Label = %q", got)
+	}
+
+	value, err := options.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var scanned VariantOptions
+	if err := scanned.Scan(value); err != nil {
+		t.Fatal(err)
+	}
+	if scanned.Label() != options.Label() {
+		t.Errorf("round trip gave %v", scanned)
+	}
+	if err := scanned.Scan(nil); err != nil || scanned != nil {
+		t.Errorf("Scan(nil) = %v, %v", scanned, err)
+	}
+	if err := scanned.Scan(42); err == nil {
+		t.Error("scanned an int")
+	}
+}
@@ -0,0 +1,69 @@
+package customer
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CustomerController struct {
+	customerService CustomerService
+}
+
+func NewCustomerController(customerService CustomerService) *CustomerController {
+	return &CustomerController{customerService: customerService}
+}
+
+func (c *CustomerController) ListCustomers(ctx *gin.Context) {
+	var filter Filter
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	filter.normalize()
+	users, total, err := c.customerService.List(filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve users",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"users":     users,
+		"total":     total,
+		"page":      filter.Page,
+		"page_size": filter.PageSize,
+	})
+}
+
+func (c *CustomerController) GetCustomer(ctx *gin.Context) {
+	userID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+
+	detail, err := c.customerService.Get(uint(userID))
+	if err != nil {
+		if errors.Is(err, ErrCustomerNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve user",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, detail)
+}
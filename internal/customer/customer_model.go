@@ -0,0 +1,48 @@
+package customer
+
+import (
+	"ecommerce/internal/auth"
+	"time"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// Filter narrows down GET /api/v1/admin/users. Query matches name, email or
+// phone; a phone number matches in any format.
+type Filter struct {
+	Query    string `form:"q"`
+	Role     string `form:"role"`
+	Blocked  *bool  `form:"blocked"`
+	Page     int    `form:"page"`
+	PageSize int    `form:"page_size"`
+}
+
+func (f *Filter) normalize() {
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.PageSize < 1 {
+		f.PageSize = defaultPageSize
+	}
+	if f.PageSize > maxPageSize {
+		f.PageSize = maxPageSize
+	}
+}
+
+// OrderStats summarizes a customer's orders. TotalSpent counts orders that
+// were paid or delivered (cash on delivery), leaving out cancelled ones.
+type OrderStats struct {
+	OrderCount  int64      `json:"order_count"`
+	TotalSpent  float64    `json:"total_spent"`
+	LastOrderAt *time.Time `json:"last_order_at"`
+}
+
+// Detail is the customer view for the admin console.
+type Detail struct {
+	User      auth.User      `json:"user"`
+	Stats     OrderStats     `json:"stats"`
+	Addresses []auth.Address `json:"addresses"`
+}
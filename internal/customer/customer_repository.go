@@ -0,0 +1,108 @@
+package customer
+
+import (
+	"ecommerce/internal/auth"
+	"ecommerce/internal/order"
+	"ecommerce/internal/phone"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type CustomerRepository interface {
+	List(filter Filter) ([]auth.User, int64, error)
+	FindUser(userID uint) (*auth.User, error)
+	GetAddresses(userID uint) ([]auth.Address, error)
+	GetOrderStats(userID uint) (OrderStats, error)
+}
+
+type customerRepository struct {
+	db *gorm.DB
+}
+
+func NewCustomerRepository(db *gorm.DB) CustomerRepository {
+	return &customerRepository{db: db}
+}
+
+func (r *customerRepository) List(filter Filter) ([]auth.User, int64, error) {
+	query := r.db.Model(&auth.User{})
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		like := "%" + likeEscaper.Replace(strings.ToLower(q)) + "%"
+		search := r.db.Where("LOWER(name) LIKE ? OR LOWER(email) LIKE ?", like, like)
+		// Phones are stored as +8801..., so a partial 01... still matches
+		digits := strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(q, "+"), "88"), "0")
+		if isDigits(digits) {
+			search = search.Or("phone LIKE ?", "%"+digits+"%")
+		}
+		if number, err := phone.Normalize(q); err == nil {
+			search = search.Or("phone = ?", number)
+		}
+		query = query.Where(search)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if filter.Blocked != nil {
+		if *filter.Blocked {
+			query = query.Where("blocked_at IS NOT NULL")
+		} else {
+			query = query.Where("blocked_at IS NULL")
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []auth.User
+	offset := (filter.Page - 1) * filter.PageSize
+	err := query.Order("created_at DESC, id DESC").
+		Offset(offset).Limit(filter.PageSize).
+		Find(&users).Error
+	return users, total, err
+}
+
+// likeEscaper escapes LIKE wildcards in search input, so they match
+// literally. Backslash is Postgres's default LIKE escape character.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// isDigits reports whether s is a non-empty run of ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *customerRepository) FindUser(userID uint) (*auth.User, error) {
+	var user auth.User
+	if err := r.db.Preload("Identities").First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *customerRepository) GetAddresses(userID uint) ([]auth.Address, error) {
+	var addresses []auth.Address
+	err := r.db.Where("user_id = ?", userID).
+		Order("is_default DESC, created_at").
+		Find(&addresses).Error
+	return addresses, err
+}
+
+func (r *customerRepository) GetOrderStats(userID uint) (OrderStats, error) {
+	var stats OrderStats
+	err := r.db.Model(&order.Order{}).
+		Select(`COUNT(*) AS order_count,
+			COALESCE(SUM(total) FILTER (WHERE status <> 'cancelled' AND (payment_status = 'paid' OR status = 'delivered')), 0) AS total_spent,
+			MAX(created_at) AS last_order_at`).
+		Where("user_id = ?", userID).
+		Scan(&stats).Error
+	return stats, err
+}
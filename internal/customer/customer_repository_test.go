@@ -0,0 +1,34 @@
+package customer
+
+import "testing"
+
+func TestLikeEscaper(t *testing.T) {
+	tests := map[string]string{
+		"rahim":   "rahim",
+		"100%":    `100\%`,
+		"a_b":     `a\_b`,
+		`back\sl`: `back\\sl`,
+		`%_\`:     `\%\_\\`,
+	}
+	for in, want := range tests {
+		if got := likeEscaper.Replace(in); got != want {
+			t.Errorf("likeEscaper(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestIsDigits(t *testing.T) {
+	tests := map[string]bool{
+		"1712345678": true,
+		"0":          true,
+		"":           false,
+		"17 12":      false,
+		"+880":       false,
+		"١٢٣":        false, // Arabic-Indic digits aren't in stored phones
+	}
+	for in, want := range tests {
+		if got := isDigits(in); got != want {
+			t.Errorf("isDigits(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package customer
+
+import (
+	"ecommerce/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupCustomerRoutes registers the admin customer console. Blocking lives
+// with the other account controls under /api/v1/admin/users/:id in auth.
+func SetupCustomerRoutes(router *gin.Engine, customerController *CustomerController) {
+	admin := router.Group("/api/v1/admin")
+	admin.Use(auth.JWTAuthMiddleware(), auth.RequireRole(auth.RoleAdmin))
+	{
+		admin.GET("/users", customerController.ListCustomers)
+		admin.GET("/users/:id", customerController.GetCustomer)
+	}
+}
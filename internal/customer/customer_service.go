@@ -0,0 +1,53 @@
+package customer
+
+import (
+	"ecommerce/internal/auth"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var ErrCustomerNotFound = errors.New("user not found")
+
+type CustomerService interface {
+	List(filter Filter) ([]auth.User, int64, error)
+	Get(userID uint) (*Detail, error)
+}
+
+type customerService struct {
+	repo CustomerRepository
+}
+
+func NewCustomerService(repo CustomerRepository) CustomerService {
+	return &customerService{repo: repo}
+}
+
+func (s *customerService) List(filter Filter) ([]auth.User, int64, error) {
+	filter.normalize()
+	return s.repo.List(filter)
+}
+
+func (s *customerService) Get(userID uint) (*Detail, error) {
+	user, err := s.repo.FindUser(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCustomerNotFound
+		}
+		return nil, err
+	}
+
+	stats, err := s.repo.GetOrderStats(userID)
+	if err != nil {
+		return nil, err
+	}
+	addresses, err := s.repo.GetAddresses(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Detail{
+		User:      *user,
+		Stats:     stats,
+		Addresses: addresses,
+	}, nil
+}
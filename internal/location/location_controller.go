@@ -0,0 +1,131 @@
+package location
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LocationController struct {
+	locationService LocationService
+}
+
+func NewLocationController(locationService LocationService) *LocationController {
+	return &LocationController{locationService: locationService}
+}
+
+// The dataset rarely changes, so clients may cache lookups for a day
+const lookupCacheControl = "public, max-age=86400"
+
+func (c *LocationController) ListDivisions(ctx *gin.Context) {
+	divisions, err := c.locationService.ListDivisions()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get divisions",
+		})
+		return
+	}
+
+	ctx.Header("Cache-Control", lookupCacheControl)
+	ctx.JSON(http.StatusOK, gin.H{
+		"divisions": divisions,
+	})
+}
+
+func (c *LocationController) ListDistricts(ctx *gin.Context) {
+	divisionID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid division ID",
+		})
+		return
+	}
+
+	districts, err := c.locationService.ListDistricts(uint(divisionID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get districts",
+		})
+		return
+	}
+
+	ctx.Header("Cache-Control", lookupCacheControl)
+	ctx.JSON(http.StatusOK, gin.H{
+		"districts": districts,
+	})
+}
+
+func (c *LocationController) ListUpazilas(ctx *gin.Context) {
+	districtID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid district ID",
+		})
+		return
+	}
+
+	upazilas, err := c.locationService.ListUpazilas(uint(districtID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get upazilas",
+		})
+		return
+	}
+
+	ctx.Header("Cache-Control", lookupCacheControl)
+	ctx.JSON(http.StatusOK, gin.H{
+		"upazilas": upazilas,
+	})
+}
+
+func (c *LocationController) ListAreas(ctx *gin.Context) {
+	upazilaID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid upazila ID",
+		})
+		return
+	}
+
+	areas, err := c.locationService.ListAreas(uint(upazilaID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get areas",
+		})
+		return
+	}
+
+	ctx.Header("Cache-Control", lookupCacheControl)
+	ctx.JSON(http.StatusOK, gin.H{
+		"areas": areas,
+	})
+}
+
+func (c *LocationController) CreateArea(ctx *gin.Context) {
+	var req CreateAreaRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	area, err := c.locationService.CreateArea(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUnknownUpazila):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrAreaExists):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create area"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"area": area,
+	})
+}
@@ -0,0 +1,57 @@
+package location
+
+// Courier pricing bands, stored on each upazila.
+const (
+	ZoneInsideDhaka  = "inside_dhaka"
+	ZoneDhakaSuburbs = "dhaka_suburbs"
+	ZoneOutsideDhaka = "outside_dhaka"
+)
+
+type Division struct {
+	ID   uint   `gorm:"primaryKey" json:"id"`
+	Name string `gorm:"not null;unique" json:"name"`
+}
+
+type District struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	DivisionID uint   `gorm:"not null;index" json:"division_id"`
+	Name       string `gorm:"not null" json:"name"`
+}
+
+// Upazila is an upazila, or a thana of a city corporation when IsMetro is set.
+type Upazila struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	DistrictID   uint   `gorm:"not null;index" json:"district_id"`
+	Name         string `gorm:"not null" json:"name"`
+	IsMetro      bool   `gorm:"not null;default:false" json:"is_metro"`
+	DeliveryZone string `gorm:"not null;default:'outside_dhaka'" json:"delivery_zone"`
+}
+
+// Area is a neighbourhood within an upazila or thana that couriers deliver to.
+type Area struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	UpazilaID uint   `gorm:"not null;index" json:"upazila_id"`
+	Name      string `gorm:"not null" json:"name"`
+	Postcode  string `json:"postcode"`
+}
+
+type CreateAreaRequest struct {
+	UpazilaID uint   `json:"upazila_id" binding:"required"`
+	Name      string `json:"name" binding:"required,max=100"`
+	Postcode  string `json:"postcode" binding:"omitempty,len=4,numeric"`
+}
+
+// Place is a validated division/district/upazila/area combination with its
+// names, ready to be copied onto an address or order.
+type Place struct {
+	DivisionID   uint   `json:"division_id"`
+	Division     string `json:"division"`
+	DistrictID   uint   `json:"district_id"`
+	District     string `json:"district"`
+	UpazilaID    uint   `json:"upazila_id"`
+	Upazila      string `json:"upazila"`
+	AreaID       *uint  `json:"area_id,omitempty"`
+	Area         string `json:"area,omitempty"`
+	Postcode     string `json:"postcode,omitempty"`
+	DeliveryZone string `json:"delivery_zone"`
+}
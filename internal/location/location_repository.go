@@ -0,0 +1,98 @@
+package location
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+type LocationRepository interface {
+	ListDivisions() ([]Division, error)
+	ListDistricts(divisionID uint) ([]District, error)
+	ListUpazilas(districtID uint) ([]Upazila, error)
+	ListAreas(upazilaID uint) ([]Area, error)
+	FindDivision(id uint) (*Division, error)
+	FindDistrict(id uint) (*District, error)
+	FindUpazila(id uint) (*Upazila, error)
+	FindArea(id uint) (*Area, error)
+	CreateArea(area *Area) error
+}
+
+type locationRepository struct {
+	db *gorm.DB
+}
+
+func NewLocationRepository(db *gorm.DB) LocationRepository {
+	return &locationRepository{db: db}
+}
+
+func (r *locationRepository) ListDivisions() ([]Division, error) {
+	var divisions []Division
+	err := r.db.Order("name").Find(&divisions).Error
+	return divisions, err
+}
+
+func (r *locationRepository) ListDistricts(divisionID uint) ([]District, error) {
+	var districts []District
+	err := r.db.Where("division_id = ?", divisionID).Order("name").Find(&districts).Error
+	return districts, err
+}
+
+func (r *locationRepository) ListUpazilas(districtID uint) ([]Upazila, error) {
+	var upazilas []Upazila
+	err := r.db.Where("district_id = ?", districtID).Order("is_metro DESC, name").Find(&upazilas).Error
+	return upazilas, err
+}
+
+func (r *locationRepository) ListAreas(upazilaID uint) ([]Area, error) {
+	var areas []Area
+	err := r.db.Where("upazila_id = ?", upazilaID).Order("name").Find(&areas).Error
+	return areas, err
+}
+
+func (r *locationRepository) FindDivision(id uint) (*Division, error) {
+	var division Division
+	if err := r.db.First(&division, id).Error; err != nil {
+		return nil, err
+	}
+	return &division, nil
+}
+
+func (r *locationRepository) FindDistrict(id uint) (*District, error) {
+	var district District
+	if err := r.db.First(&district, id).Error; err != nil {
+		return nil, err
+	}
+	return &district, nil
+}
+
+func (r *locationRepository) FindUpazila(id uint) (*Upazila, error) {
+	var upazila Upazila
+	if err := r.db.First(&upazila, id).Error; err != nil {
+		return nil, err
+	}
+	return &upazila, nil
+}
+
+func (r *locationRepository) FindArea(id uint) (*Area, error) {
+	var area Area
+	if err := r.db.First(&area, id).Error; err != nil {
+		return nil, err
+	}
+	return &area, nil
+}
+
+func (r *locationRepository) CreateArea(area *Area) error {
+	return areaConflict(r.db.Create(area).Error)
+}
+
+// areaConflict turns a violation of the unique (upazila_id, name) constraint
+// into ErrAreaExists.
+func areaConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "areas_upazila_id_name_key" {
+		return ErrAreaExists
+	}
+	return err
+}
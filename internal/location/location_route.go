@@ -0,0 +1,26 @@
+package location
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// SetupLocationRoutes registers the public lookups and, behind adminOnly, the
+// endpoint for adding delivery areas. main passes the auth checks in, since
+// auth depends on this package to validate addresses.
+func SetupLocationRoutes(router *gin.Engine, locationController *LocationController, adminOnly ...gin.HandlerFunc) {
+	v1 := router.Group("/api/v1")
+
+	locations := v1.Group("/locations")
+	{
+		locations.GET("/divisions", locationController.ListDivisions)
+		locations.GET("/divisions/:id/districts", locationController.ListDistricts)
+		locations.GET("/districts/:id/upazilas", locationController.ListUpazilas)
+		locations.GET("/upazilas/:id/areas", locationController.ListAreas)
+	}
+
+	admin := v1.Group("/admin/locations")
+	admin.Use(adminOnly...)
+	{
+		admin.POST("/areas", locationController.CreateArea)
+	}
+}
@@ -0,0 +1,106 @@
+package location
+
+import (
+	"errors"
+	"log"
+	"strings"
+)
+
+var (
+	ErrUnknownDivision = errors.New("unknown division")
+	ErrUnknownDistrict = errors.New("district is not in the selected division")
+	ErrUnknownUpazila  = errors.New("upazila/thana is not in the selected district")
+	ErrUnknownArea     = errors.New("area is not in the selected upazila/thana")
+	ErrAreaExists      = errors.New("area already exists")
+)
+
+type LocationService interface {
+	ListDivisions() ([]Division, error)
+	ListDistricts(divisionID uint) ([]District, error)
+	ListUpazilas(districtID uint) ([]Upazila, error)
+	ListAreas(upazilaID uint) ([]Area, error)
+	CreateArea(req CreateAreaRequest) (*Area, error)
+
+	// Resolve checks that each level belongs to the one above it and returns
+	// the names. areaID is optional.
+	Resolve(divisionID, districtID, upazilaID uint, areaID *uint) (*Place, error)
+}
+
+type locationService struct {
+	repo LocationRepository
+}
+
+func NewLocationService(repo LocationRepository) LocationService {
+	return &locationService{repo: repo}
+}
+
+func (s *locationService) ListDivisions() ([]Division, error) {
+	return s.repo.ListDivisions()
+}
+
+func (s *locationService) ListDistricts(divisionID uint) ([]District, error) {
+	return s.repo.ListDistricts(divisionID)
+}
+
+func (s *locationService) ListUpazilas(districtID uint) ([]Upazila, error) {
+	return s.repo.ListUpazilas(districtID)
+}
+
+func (s *locationService) ListAreas(upazilaID uint) ([]Area, error) {
+	return s.repo.ListAreas(upazilaID)
+}
+
+func (s *locationService) CreateArea(req CreateAreaRequest) (*Area, error) {
+	if _, err := s.repo.FindUpazila(req.UpazilaID); err != nil {
+		return nil, ErrUnknownUpazila
+	}
+
+	area := &Area{
+		UpazilaID: req.UpazilaID,
+		Name:      strings.TrimSpace(req.Name),
+		Postcode:  req.Postcode,
+	}
+	if err := s.repo.CreateArea(area); err != nil {
+		return nil, err
+	}
+
+	log.Printf("📍 Delivery area added: ID=%d, UpazilaID=%d, Name=%s", area.ID, area.UpazilaID, area.Name)
+	return area, nil
+}
+
+func (s *locationService) Resolve(divisionID, districtID, upazilaID uint, areaID *uint) (*Place, error) {
+	division, err := s.repo.FindDivision(divisionID)
+	if err != nil {
+		return nil, ErrUnknownDivision
+	}
+	district, err := s.repo.FindDistrict(districtID)
+	if err != nil || district.DivisionID != division.ID {
+		return nil, ErrUnknownDistrict
+	}
+	upazila, err := s.repo.FindUpazila(upazilaID)
+	if err != nil || upazila.DistrictID != district.ID {
+		return nil, ErrUnknownUpazila
+	}
+
+	place := &Place{
+		DivisionID:   division.ID,
+		Division:     division.Name,
+		DistrictID:   district.ID,
+		District:     district.Name,
+		UpazilaID:    upazila.ID,
+		Upazila:      upazila.Name,
+		DeliveryZone: upazila.DeliveryZone,
+	}
+
+	if areaID != nil {
+		area, err := s.repo.FindArea(*areaID)
+		if err != nil || area.UpazilaID != upazila.ID {
+			return nil, ErrUnknownArea
+		}
+		place.AreaID = &area.ID
+		place.Area = area.Name
+		place.Postcode = area.Postcode
+	}
+
+	return place, nil
+}
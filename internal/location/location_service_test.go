@@ -0,0 +1,94 @@
+package location
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// fakeLocationRepo embeds LocationRepository so methods a test doesn't set
+// up panic. Dhaka division holds Dhaka district, which holds Dhanmondi;
+// Chattogram division holds Chattogram district, which holds Kotwali.
+type fakeLocationRepo struct {
+	LocationRepository
+}
+
+var (
+	divisions = map[uint]*Division{1: {ID: 1, Name: "Dhaka"}, 2: {ID: 2, Name: "Chattogram"}}
+	districts = map[uint]*District{10: {ID: 10, DivisionID: 1, Name: "Dhaka"}, 20: {ID: 20, DivisionID: 2, Name: "Chattogram"}}
+	upazilas  = map[uint]*Upazila{100: {ID: 100, DistrictID: 10, Name: "Dhanmondi", DeliveryZone: "inside_dhaka"}, 200: {ID: 200, DistrictID: 20, Name: "Kotwali"}}
+	areas     = map[uint]*Area{1000: {ID: 1000, UpazilaID: 100, Name: "Road 27", Postcode: "1209"}}
+)
+
+func find[T any](items map[uint]*T, id uint) (*T, error) {
+	if item, ok := items[id]; ok {
+		return item, nil
+	}
+	return nil, errors.New("record not found")
+}
+
+func (fakeLocationRepo) FindDivision(id uint) (*Division, error) { return find(divisions, id) }
+func (fakeLocationRepo) FindDistrict(id uint) (*District, error) { return find(districts, id) }
+func (fakeLocationRepo) FindUpazila(id uint) (*Upazila, error)   { return find(upazilas, id) }
+func (fakeLocationRepo) FindArea(id uint) (*Area, error)         { return find(areas, id) }
+
+func TestResolve(t *testing.T) {
+	s := NewLocationService(fakeLocationRepo{})
+	area := uint(1000)
+
+	place, err := s.Resolve(1, 10, 100, &area)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if place.Division != "Dhaka" || place.Upazila != "Dhanmondi" || place.Area != "Road 27" ||
+		place.Postcode != "1209" || place.DeliveryZone != "inside_dhaka" {
+		t.Errorf("place = %+v", place)
+	}
+
+	if place, err := s.Resolve(2, 20, 200, nil); err != nil || place.AreaID != nil {
+		t.Errorf("without an area: %+v, %v", place, err)
+	}
+}
+
+func TestResolveRejectsMismatchedLevels(t *testing.T) {
+	s := NewLocationService(fakeLocationRepo{})
+	area, unknown := uint(1000), uint(9999)
+
+	tests := []struct {
+		name                        string
+		division, district, upazila uint
+		area                        *uint
+		want                        error
+	}{
+		{"unknown division", 9, 10, 100, nil, ErrUnknownDivision},
+		{"district of another division", 2, 10, 100, nil, ErrUnknownDistrict},
+		{"upazila of another district", 1, 10, 200, nil, ErrUnknownUpazila},
+		{"area of another upazila", 2, 20, 200, &area, ErrUnknownArea},
+		{"unknown area", 1, 10, 100, &unknown, ErrUnknownArea},
+	}
+	for _, tt := range tests {
+		if _, err := s.Resolve(tt.division, tt.district, tt.upazila, tt.area); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestAreaConflict(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		exists bool
+	}{
+		{"duplicate name", &pgconn.PgError{Code: "23505", ConstraintName: "areas_upazila_id_name_key"}, true},
+		{"duplicate id", &pgconn.PgError{Code: "23505", ConstraintName: "areas_pkey"}, false},
+		{"unknown upazila", &pgconn.PgError{Code: "23503"}, false},
+		{"other error", errors.New("connection refused"), false},
+	}
+	for _, tt := range tests {
+		got := areaConflict(tt.err)
+		if errors.Is(got, ErrAreaExists) != tt.exists || (!tt.exists && got != tt.err) {
+			t.Errorf("%s: got %v", tt.name, got)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package order
 
 import (
+	"ecommerce/internal/audit"
+	"ecommerce/internal/auth"
+	"ecommerce/internal/cart"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
@@ -34,18 +38,121 @@ func (c *OrderController) CreateOrder(ctx *gin.Context) {
 
 	order, err := c.orderService.CreateOrderFromCart(userIDUint, req)
 	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, auth.ErrUserBlocked) {
+			status = http.StatusForbidden
+		}
+		ctx.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "Order created successfully",
+		"order":   order,
+	})
+}
+
+// CreateGuestOrder checks out the guest cart named by the X-Cart-Token header.
+func (c *OrderController) CreateGuestOrder(ctx *gin.Context) {
+	cartID, err := cart.ParseCartToken(ctx.GetHeader(cart.CartTokenHeader))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": "A valid cart token is required",
+		})
+		return
+	}
+
+	var req CreateOrderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
 
+	order, err := c.orderService.CreateGuestOrder(cartID, req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, auth.ErrUserBlocked) {
+			status = http.StatusForbidden
+		}
+		ctx.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
 	ctx.JSON(http.StatusCreated, gin.H{
 		"message": "Order created successfully",
 		"order":   order,
 	})
 }
 
+// TrackOrder lets anyone with the order number and phone number see an
+// order's status and items.
+func (c *OrderController) TrackOrder(ctx *gin.Context) {
+	orderNumber := ctx.Query("order_number")
+	phone := ctx.Query("phone")
+	if orderNumber == "" || phone == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "order_number and phone are required",
+		})
+		return
+	}
+
+	order, err := c.orderService.TrackOrder(orderNumber, phone)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"order": order,
+	})
+}
+
+func (c *OrderController) GetClaimableOrders(ctx *gin.Context) {
+	orders, err := c.orderService.GetClaimableOrders(ctx.GetUint("userID"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get orders",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"orders": orders,
+		"count":  len(orders),
+	})
+}
+
+func (c *OrderController) ClaimOrders(ctx *gin.Context) {
+	var req ClaimOrdersRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	claimed, err := c.orderService.ClaimOrders(ctx.GetUint("userID"), req.OrderIDs)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to claim orders",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Orders added to your account",
+		"claimed": claimed,
+	})
+}
+
 func (c *OrderController) GetUserOrders(ctx *gin.Context) {
 	userID, exists := ctx.Get("userID")
 if !exists {
@@ -275,7 +382,7 @@ func (c *OrderController) UpdateOrderStatusAdmin(ctx *gin.Context) {
 		return
 	}
 
-	err = c.orderService.UpdateOrderStatusAdmin(uint(orderID), req.Status)
+	err = c.orderService.UpdateOrderStatusAdmin(audit.ActorFromContext(ctx), uint(orderID), req.Status)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
@@ -289,15 +396,6 @@ func (c *OrderController) UpdateOrderStatusAdmin(ctx *gin.Context) {
 }
 
 func (c *OrderController) ReviewPaymentProofAdmin(ctx *gin.Context) {
-	userID, exists := ctx.Get("userID")
-if !exists {
-    ctx.JSON(http.StatusUnauthorized, gin.H{
-        "error": "User not authenticated",
-    })
-    return
-}
-userIDUint := userID.(uint)
-
 	proofID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
@@ -326,7 +424,7 @@ userIDUint := userID.(uint)
 		return
 	}
 
-	err = c.orderService.ReviewPaymentProofAdmin(uint(proofID), req.Status, req.AdminNotes, userIDUint)
+	err = c.orderService.ReviewPaymentProofAdmin(audit.ActorFromContext(ctx), uint(proofID), req.Status, req.AdminNotes)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
@@ -9,7 +9,7 @@ import (
 
 type Order struct {
 	ID     uint `json:"id" gorm:"primaryKey"`
-	UserID uint `json:"user_id" gorm:"not null;index"`
+	UserID *uint `json:"user_id" gorm:"index"` // nil for guest orders until claimed
 
 	// Order Information
 	OrderNumber   string  `json:"order_number" gorm:"not null;uniqueIndex"`
@@ -21,8 +21,19 @@ type Order struct {
 	ShippingAddress string `json:"shipping_address" gorm:"not null;default:''"`
 	CustomerName    string `json:"customer_name" gorm:"not null;default:''"`  // ✅ Add default
 	CustomerPhone   string `json:"customer_phone" gorm:"not null;default:''"` // ✅ Add default
+	CustomerEmail   string `json:"customer_email" gorm:"not null;default:''"`
 	PaymentMethod   string `json:"payment_method" gorm:"not null;default:''"` // ✅ Add default
 
+	// Structured shipping address, copied from a saved address at checkout so
+	// later edits to the address don't change the order
+	ShippingAddressID *uint  `json:"shipping_address_id,omitempty"`
+	ShippingDivision  string `json:"shipping_division" gorm:"not null;default:''"`
+	ShippingDistrict  string `json:"shipping_district" gorm:"not null;default:''"`
+	ShippingUpazila   string `json:"shipping_upazila" gorm:"not null;default:''"`
+	ShippingArea      string `json:"shipping_area" gorm:"not null;default:''"`
+	ShippingPostcode  string `json:"shipping_postcode" gorm:"not null;default:''"`
+	DeliveryZone      string `json:"delivery_zone" gorm:"not null;default:''"`
+
 	// Additional Information
 	Notes string `json:"notes" gorm:"default:''"`
 
@@ -34,13 +45,24 @@ type Order struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 }
+// CreateOrderRequest takes either a saved address (AddressID, logged-in
+// checkout only) or a free-text ShippingAddress with the customer's name and
+// phone. With AddressID, name and phone default to the address's.
 type CreateOrderRequest struct {
-	ShippingAddress string `json:"shipping_address" binding:"required" validate:"max=500"`
-	CustomerName    string `json:"customer_name" binding:"required" validate:"max=100"`
-	CustomerPhone   string `json:"customer_phone" binding:"required" validate:"max=20"`
+	AddressID       *uint  `json:"address_id"`
+	ShippingAddress string `json:"shipping_address" binding:"required_without=AddressID" validate:"max=500"`
+	CustomerName    string `json:"customer_name" binding:"required_without=AddressID" validate:"max=100"`
+	CustomerPhone   string `json:"customer_phone" binding:"required_without=AddressID,omitempty,bdphone"`
+	CustomerEmail   string `json:"customer_email" binding:"omitempty,email"`
 	PaymentMethod   string `json:"payment_method" binding:"required" validate:"oneof=bkash nagad rocket cod"`
 	Notes           string `json:"notes" validate:"max=1000"`
 }
+
+// ClaimOrdersRequest attaches guest orders to the logged-in account.
+type ClaimOrdersRequest struct {
+	OrderIDs []uint `json:"order_ids" binding:"required,min=1"`
+}
+
 type OrderItem struct {
 	ID uint `json:"id" gorm:"primaryKey"`
 
@@ -56,7 +78,11 @@ type OrderItem struct {
 
 	// Product Details Snapshot
 	ProductImage string `json:"product_image" gorm:"default:''"` // ✅ Add default
-	ProductSKU   string `json:"product_sku" gorm:"default:''"`
+	ProductSKU   string `json:"product_sku" gorm:"default:''"` // The variant's SKU for variants
+
+	// Variant bought, with its option values at time of order
+	VariantID *uint                  `json:"variant_id,omitempty"`
+	Options   catalog.VariantOptions `json:"options,omitempty" gorm:"type:jsonb"`
 
 	// Relationships
 	Product catalog.Product `json:"product,omitempty" gorm:"foreignKey:ProductID"`
@@ -67,6 +93,54 @@ type OrderItem struct {
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
+// OrderTracking is what guest order tracking shows: the order's progress and
+// items, without the customer's name, phone or address.
+type OrderTracking struct {
+	OrderNumber   string              `json:"order_number"`
+	Status        string              `json:"status"`
+	PaymentStatus string              `json:"payment_status"`
+	PaymentMethod string              `json:"payment_method"`
+	Total         float64             `json:"total"`
+	Items         []OrderTrackingItem `json:"items"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+}
+
+type OrderTrackingItem struct {
+	ProductName  string                 `json:"name"`
+	ProductImage string                 `json:"product_image"`
+	Options      catalog.VariantOptions `json:"options,omitempty"`
+	Price        float64                `json:"price"`
+	Quantity     int                    `json:"quantity"`
+	Subtotal     float64                `json:"subtotal"`
+}
+
+// Tracking returns the order's public tracking view. Items must have been
+// loaded with the order.
+func (o *Order) Tracking() *OrderTracking {
+	tracking := &OrderTracking{
+		OrderNumber:   o.OrderNumber,
+		Status:        o.Status,
+		PaymentStatus: o.PaymentStatus,
+		PaymentMethod: o.PaymentMethod,
+		Total:         o.Total,
+		Items:         make([]OrderTrackingItem, len(o.Items)),
+		CreatedAt:     o.CreatedAt,
+		UpdatedAt:     o.UpdatedAt,
+	}
+	for i, item := range o.Items {
+		tracking.Items[i] = OrderTrackingItem{
+			ProductName:  item.ProductName,
+			ProductImage: item.ProductImage,
+			Options:      item.Options,
+			Price:        item.Price,
+			Quantity:     item.Quantity,
+			Subtotal:     item.Subtotal,
+		}
+	}
+	return tracking
+}
+
 type PaymentProof struct {
 	ID      uint `json:"id" gorm:"primaryKey"`
 	OrderID uint `json:"order_id" gorm:"not null;index"`
@@ -99,7 +173,7 @@ type SubmitPaymentProofRequest struct {
 	PaymentMethod string  `json:"payment_method" binding:"required"`
 	Amount        float64 `json:"amount" binding:"required,min=0"`
 	Screenshot    string  `json:"screenshot" binding:"required"` // Image URL
-	SenderNumber  string  `json:"sender_number" binding:"required"`
+	SenderNumber  string  `json:"sender_number" binding:"required,bdphone"`
 	SenderName    string  `json:"sender_name" binding:"required"`
 	PaymentDate   string  `json:"payment_date" binding:"required"`
 }
@@ -9,12 +9,16 @@ type OrderRepository interface {
 	CreateOrderItem(item *OrderItem) error
 	GetByUserID(userID uint) ([]Order, error)
 	GetByID(orderID uint, userID uint) (*Order, error)
+	GetByOrderNumber(orderNumber string) (*Order, error)
+	GetClaimableOrders(phone, email string) ([]Order, error)
+	ClaimOrders(orderIDs []uint, userID uint, phone, email string) (int64, error)
 	UpdateStatus(orderID uint, userID uint, status string) error
 	CreatePaymentProof(proof *PaymentProof) error
 	GetPaymentProofByOrderID(orderID uint, userID uint) (*PaymentProof, error)
 	UpdatePaymentProof(orderID uint, userID uint, proofData SubmitPaymentProofRequest) error
 
 	GetAllOrders() ([]Order, error)
+	GetByIDAdmin(orderID uint) (*Order, error)
 	UpdateOrderStatusAdmin(orderID uint, status string) error
 	GetPaymentProofByID(proofID uint) (*PaymentProof, error)
 	ReviewPaymentProof(proofID uint, status string, adminNotes string, reviewerID uint) error
@@ -53,6 +57,50 @@ func (r *orderRepository) GetByID(orderID uint, userID uint) (*Order, error) {
 		First(&order).Error
 	return &order, err
 }
+// GetByIDAdmin loads any order, whoever placed it.
+func (r *orderRepository) GetByIDAdmin(orderID uint) (*Order, error) {
+	var order Order
+	err := r.db.Where("id = ?", orderID).
+		Preload("Items").
+		First(&order).Error
+	return &order, err
+}
+
+func (r *orderRepository) GetByOrderNumber(orderNumber string) (*Order, error) {
+	var order Order
+	err := r.db.Where("order_number = ?", orderNumber).
+		Preload("Items").
+		First(&order).Error
+	return &order, err
+}
+
+// claimableScope matches guest orders placed with the given phone or email.
+// Empty values never match.
+func claimableScope(phone, email string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("user_id IS NULL").
+			Where("(? <> '' AND customer_phone = ?) OR (? <> '' AND LOWER(customer_email) = LOWER(?))",
+				phone, phone, email, email)
+	}
+}
+
+func (r *orderRepository) GetClaimableOrders(phone, email string) ([]Order, error) {
+	var orders []Order
+	err := r.db.Scopes(claimableScope(phone, email)).
+		Preload("Items").
+		Order("created_at DESC").
+		Find(&orders).Error
+	return orders, err
+}
+
+func (r *orderRepository) ClaimOrders(orderIDs []uint, userID uint, phone, email string) (int64, error) {
+	result := r.db.Model(&Order{}).
+		Scopes(claimableScope(phone, email)).
+		Where("id IN ?", orderIDs).
+		Update("user_id", userID)
+	return result.RowsAffected, result.Error
+}
+
 func (r *orderRepository) UpdateStatus(orderID uint, userID uint, status string) error {
 	return r.db.Model(&Order{}).
 		Where("id = ? AND user_id = ?", orderID, userID).
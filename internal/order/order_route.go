@@ -1,27 +1,40 @@
 package order
 
 import (
+	"ecommerce/internal/auth"
+
 	"github.com/gin-gonic/gin"
 )
 
 func SetupOrderRoutes(router *gin.Engine, orderController *OrderController) {
 	v1 := router.Group("/api/v1")
+
+	// Guest checkout and tracking, no account needed
+	v1.POST("/orders/guest", orderController.CreateGuestOrder)
+	v1.GET("/orders/track", RateLimitTracking(), orderController.TrackOrder)
+
 	orders := v1.Group("/orders")
+	orders.Use(auth.JWTAuthMiddleware())
 	{
-		orders.POST("", orderController.CreateOrder)
+		orders.POST("", auth.BlockImpersonation(), orderController.CreateOrder)
+		orders.GET("/claimable", orderController.GetClaimableOrders)
+		orders.POST("/claim", auth.BlockImpersonation(), orderController.ClaimOrders)
 		orders.GET("", orderController.GetUserOrders)
 		orders.GET("/:id", orderController.GetOrderByID)
-		orders.PUT("/:id/cancel", orderController.CancelOrder)
-		orders.POST("/:id/payment-proof", orderController.SubmitPaymentProof)
+		orders.PUT("/:id/cancel", auth.BlockImpersonation(), orderController.CancelOrder)
+		orders.POST("/:id/payment-proof", auth.BlockImpersonation(), orderController.SubmitPaymentProof)
 		orders.GET("/:id/payment-proof", orderController.GetPaymentProof)
-		orders.PUT("/:id/payment-proof", orderController.UpdatePaymentProof)
+		orders.PUT("/:id/payment-proof", auth.BlockImpersonation(), orderController.UpdatePaymentProof)
 
 	}
+	// Back-office integrations reach these with scoped API keys. Payment
+	// review has no scope, so it stays limited to logged-in admins.
 	admin := v1.Group("/admin")
+	admin.Use(auth.JWTOrAPIKeyMiddleware())
 	{
-		admin.GET("/orders", orderController.GetAllOrdersAdmin)
-		admin.PUT("/orders/:id/status", orderController.UpdateOrderStatusAdmin)
-		admin.PUT("/payment-proofs/:id/review", orderController.ReviewPaymentProofAdmin)
+		admin.GET("/orders", auth.RequireScope(auth.ScopeOrdersRead), auth.RequireRole(auth.RoleStaff, auth.RoleAdmin), orderController.GetAllOrdersAdmin)
+		admin.PUT("/orders/:id/status", auth.RequireScope(auth.ScopeOrdersWrite), auth.RequireRole(auth.RoleStaff, auth.RoleAdmin), auth.RequireMFA(), orderController.UpdateOrderStatusAdmin)
+		admin.PUT("/payment-proofs/:id/review", auth.RequireRole(auth.RoleAdmin), auth.RequireMFA(), orderController.ReviewPaymentProofAdmin)
 	}
 }
 
@@ -0,0 +1,49 @@
+package order
+
+import (
+	"ecommerce/internal/audit"
+	"ecommerce/internal/auth"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// nopAudit drops every event.
+type nopAudit struct {
+	audit.Service
+}
+
+func (nopAudit) Record(audit.Actor, string, string, interface{}, interface{}, interface{}) {}
+
+func TestImpersonationBlocksOrderActions(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	gin.SetMode(gin.TestMode)
+	auth.SetTokenStore(auth.NewMemoryTokenStore())
+
+	users := &fakeUserRepo{user: &auth.User{ID: 2, Role: auth.RoleCustomer}}
+	token, err := auth.NewUserService(users, nopAudit{}, nil).
+		Impersonate(audit.Actor{UserID: 1, Role: auth.RoleAdmin}, 2, "ticket 42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	SetupOrderRoutes(router, &OrderController{})
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPost, "/api/v1/orders"},
+		{http.MethodPost, "/api/v1/orders/claim"},
+		{http.MethodPut, "/api/v1/orders/1/cancel"},
+		{http.MethodPost, "/api/v1/orders/1/payment-proof"},
+		{http.MethodPut, "/api/v1/orders/1/payment-proof"},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(route.method, route.path, nil)
+		req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s %s: status %d, want 403", route.method, route.path, w.Code)
+		}
+	}
+}
@@ -1,10 +1,15 @@
 package order
 
 import (
+	"crypto/rand"
+	"ecommerce/internal/audit"
+	"ecommerce/internal/auth"
 	"ecommerce/internal/cart"
+	"ecommerce/internal/catalog"
+	"ecommerce/internal/phone"
 	"errors"
 	"fmt"
-	"time"
+	"strings"
 
 	gomail "gopkg.in/gomail.v2"
 
@@ -13,6 +18,10 @@ import (
 
 type OrderService interface {
 	CreateOrderFromCart(userID uint, orderData CreateOrderRequest) (*Order, error)
+	CreateGuestOrder(cartID uint, orderData CreateOrderRequest) (*Order, error)
+	TrackOrder(orderNumber, phone string) (*OrderTracking, error)
+	GetClaimableOrders(userID uint) ([]Order, error)
+	ClaimOrders(userID uint, orderIDs []uint) (int64, error)
 	GetUserOrders(userID uint) ([]Order, error)
 	GetOrderByID(orderID uint, userID uint) (*Order, error)
 	CancelOrder(orderID uint, userID uint) error
@@ -20,46 +29,135 @@ type OrderService interface {
 	GetPaymentProof(orderID uint, userID uint) (*PaymentProof, error)
 	UpdatePaymentProof(orderID uint, userID uint, proofData SubmitPaymentProofRequest) (*PaymentProof, error)
 	GetAllOrdersAdmin() ([]Order, error)
-	UpdateOrderStatusAdmin(orderID uint, status string) error
-	ReviewPaymentProofAdmin(proofID uint, status string, adminNotes string, reviewerID uint) error
+	UpdateOrderStatusAdmin(actor audit.Actor, orderID uint, status string) error
+	ReviewPaymentProofAdmin(actor audit.Actor, proofID uint, status string, adminNotes string) error
 }
 
 type orderService struct {
 	repo        OrderRepository
 	cartService cart.CartService
+	userRepo    auth.UserRepository
+	audit       audit.Service
 }
 
-func NewOrderService(repo OrderRepository, cartService cart.CartService) OrderService {
+func NewOrderService(repo OrderRepository, cartService cart.CartService, userRepo auth.UserRepository, auditService audit.Service) OrderService {
 	return &orderService{
 		repo:        repo,
 		cartService: cartService,
+		userRepo:    userRepo,
+		audit:       auditService,
 	}
 }
 
 func (s *orderService) CreateOrderFromCart(userID uint, orderData CreateOrderRequest) (*Order, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.BlockedAt != nil {
+		return nil, auth.ErrUserBlocked
+	}
+
 	// Get cart
 	userCart, err := s.cartService.GetCartByUserID(userID)
 	if err != nil {
 		return nil, errors.New("failed to get cart")
 	}
 
+	var address *auth.Address
+	if orderData.AddressID != nil {
+		address, err = s.userRepo.GetAddressByID(*orderData.AddressID, userID)
+		if err != nil {
+			return nil, errors.New("address not found")
+		}
+		applySavedAddress(&orderData, address)
+	}
+
+	order, err := s.placeOrder(&userID, userCart, orderData, address)
+	if err != nil {
+		return nil, err
+	}
+
+	// Clear cart
+	s.cartService.ClearCart(userID)
+
+	return order, nil
+}
+
+// CreateGuestOrder places an order from a guest cart. The phone number is
+// required, since it is how the guest tracks and later claims the order.
+func (s *orderService) CreateGuestOrder(cartID uint, orderData CreateOrderRequest) (*Order, error) {
+	if orderData.AddressID != nil {
+		return nil, errors.New("saved addresses require an account")
+	}
+	// ✅ A blocked customer can't get around the block by checking out as a guest
+	if number, err := phone.Normalize(orderData.CustomerPhone); err == nil {
+		if user, err := s.userRepo.FindByVerifiedPhone(number); err == nil && user.BlockedAt != nil {
+			return nil, auth.ErrUserBlocked
+		}
+	}
+
+	guestCart, err := s.cartService.GetGuestCart(cartID)
+	if err != nil {
+		return nil, errors.New("failed to get cart")
+	}
+
+	order, err := s.placeOrder(nil, guestCart, orderData, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cartService.ClearGuestCart(cartID)
+
+	return order, nil
+}
+
+// placeOrder creates the order from the cart. address is the saved address
+// being shipped to, if any; its structured fields are copied onto the order.
+func (s *orderService) placeOrder(userID *uint, userCart *cart.Cart, orderData CreateOrderRequest, address *auth.Address) (*Order, error) {
 	if len(userCart.Items) == 0 {
 		return nil, errors.New("cart is empty")
 	}
+	for _, cartItem := range userCart.Items {
+		// ✅ The variant may have been deleted since it was added
+		if cartItem.VariantID != nil && cartItem.Variant == nil {
+			return nil, fmt.Errorf("%w: %s is no longer available", catalog.ErrVariantNotFound, cartItem.Product.Name)
+		}
+	}
+	// ✅ Stored in E.164 so orders can be matched to customers and bKash senders
+	customerPhone, err := phone.Normalize(orderData.CustomerPhone)
+	if err != nil {
+		return nil, err
+	}
+
+	orderNumber, err := s.generateOrderNumber()
+	if err != nil {
+		return nil, err
+	}
 
 	// Create order
 	order := &Order{
 		UserID:          userID,
-		OrderNumber:     s.generateOrderNumber(),
+		OrderNumber:     orderNumber,
 		Status:          "pending",
 		PaymentStatus:   "pending",
 		Total:           userCart.CalculateTotal(),
 		ShippingAddress: orderData.ShippingAddress,
 		CustomerName:    orderData.CustomerName,
-		CustomerPhone:   orderData.CustomerPhone,
+		CustomerPhone:   customerPhone,
+		CustomerEmail:   strings.ToLower(strings.TrimSpace(orderData.CustomerEmail)),
 		PaymentMethod:   orderData.PaymentMethod,
 		Notes:           orderData.Notes,
 	}
+	if address != nil {
+		order.ShippingAddressID = &address.ID
+		order.ShippingDivision = address.Division
+		order.ShippingDistrict = address.City
+		order.ShippingUpazila = address.Zone
+		order.ShippingArea = address.Area
+		order.ShippingPostcode = address.Postcode
+		order.DeliveryZone = address.DeliveryZone
+	}
 
 	// Save order
 	if err := s.repo.Create(order); err != nil {
@@ -83,6 +181,16 @@ func (s *orderService) CreateOrderFromCart(userID uint, orderData CreateOrderReq
 			ProductImage: productImage,
 			ProductSKU:   cartItem.Product.SKU,
 		}
+		if variant := cartItem.Variant; variant != nil {
+			orderItem.VariantID = &variant.ID
+			orderItem.Options = variant.Options
+			orderItem.Price = variant.EffectivePrice(&cartItem.Product)
+			orderItem.Subtotal = float64(cartItem.Quantity) * orderItem.Price
+			orderItem.ProductSKU = variant.SKU
+			if len(variant.Images) > 0 {
+				orderItem.ProductImage = variant.Images[0]
+			}
+		}
 
 		if err := s.repo.CreateOrderItem(orderItem); err != nil {
 			return nil, err
@@ -103,12 +211,94 @@ func (s *orderService) CreateOrderFromCart(userID uint, orderData CreateOrderReq
     if err := d.DialAndSend(m); err != nil {
         fmt.Println("Failed to send email:", err)
     }}()
-	// Clear cart
-	s.cartService.ClearCart(userID)
 
 	return order, nil
 }
 
+// applySavedAddress fills the order's shipping details from a saved address.
+// Name and phone given at checkout win over the address's, e.g. when sending
+// a gift.
+func applySavedAddress(orderData *CreateOrderRequest, address *auth.Address) {
+	if strings.TrimSpace(orderData.CustomerName) == "" {
+		orderData.CustomerName = address.Name
+	}
+	if strings.TrimSpace(orderData.CustomerPhone) == "" {
+		orderData.CustomerPhone = address.Phone
+	}
+
+	parts := []string{address.Address}
+	for _, part := range []string{address.Area, address.Zone, address.City} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	shipping := strings.Join(parts, ", ")
+	if address.Postcode != "" {
+		shipping += " " + address.Postcode
+	}
+	orderData.ShippingAddress = shipping
+}
+
+// TrackOrder looks up an order by its number for a customer who is not
+// logged in. The phone number must match the one the order was placed with.
+// Only the order's status and items are returned, never the customer's
+// details.
+func (s *orderService) TrackOrder(orderNumber, number string) (*OrderTracking, error) {
+	order, err := s.repo.GetByOrderNumber(strings.TrimSpace(orderNumber))
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+
+	if phone.Same(order.CustomerPhone, number) {
+		return order.Tracking(), nil
+	}
+	return nil, errors.New("order not found")
+}
+
+// claimKeys returns the verified phone and email that guest orders can be
+// claimed with. Unverified contact details are never used.
+func (s *orderService) claimKeys(userID uint) (string, string, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return "", "", errors.New("user not found")
+	}
+
+	var phone, email string
+	if user.PhoneVerified {
+		phone = user.Phone
+	}
+	if user.EmailVerified {
+		email = user.Email
+	}
+	return phone, email, nil
+}
+
+// GetClaimableOrders lists guest orders placed with the user's verified phone
+// or email, so the app can offer to attach them to the account.
+func (s *orderService) GetClaimableOrders(userID uint) ([]Order, error) {
+	phone, email, err := s.claimKeys(userID)
+	if err != nil {
+		return nil, err
+	}
+	if phone == "" && email == "" {
+		return []Order{}, nil
+	}
+	return s.repo.GetClaimableOrders(phone, email)
+}
+
+// ClaimOrders attaches the given guest orders to the user. Orders that are not
+// claimable by this user are skipped.
+func (s *orderService) ClaimOrders(userID uint, orderIDs []uint) (int64, error) {
+	phone, email, err := s.claimKeys(userID)
+	if err != nil {
+		return 0, err
+	}
+	if phone == "" && email == "" {
+		return 0, nil
+	}
+	return s.repo.ClaimOrders(orderIDs, userID, phone, email)
+}
+
 func (s *orderService) GetUserOrders(userID uint) ([]Order, error) {
 	return s.repo.GetByUserID(userID)
 }
@@ -157,6 +347,10 @@ func (s *orderService) SubmitPaymentProof(orderID uint, userID uint, proofData S
 	if order.PaymentStatus == "paid" {
 		return nil, errors.New("payment already confirmed for this order")
 	}
+	senderNumber, err := phone.Normalize(proofData.SenderNumber)
+	if err != nil {
+		return nil, err
+	}
 	// Create payment proof
 	proof := &PaymentProof{
 		OrderID:       orderID,
@@ -164,7 +358,7 @@ func (s *orderService) SubmitPaymentProof(orderID uint, userID uint, proofData S
 		PaymentMethod: proofData.PaymentMethod,
 		Amount:        proofData.Amount,
 		Screenshot:    proofData.Screenshot,
-		SenderNumber:  proofData.SenderNumber,
+		SenderNumber:  senderNumber,
 		SenderName:    proofData.SenderName,
 		PaymentDate:   proofData.PaymentDate,
 		Status:        "pending",
@@ -202,6 +396,11 @@ func (s *orderService) UpdatePaymentProof(orderID uint, userID uint, proofData S
 		return nil, errors.New("cannot update payment proof that has been reviewed")
 	}
 
+	proofData.SenderNumber, err = phone.Normalize(proofData.SenderNumber)
+	if err != nil {
+		return nil, err
+	}
+
 	// Update payment proof
 	err = s.repo.UpdatePaymentProof(orderID, userID, proofData)
 	if err != nil {
@@ -215,9 +414,9 @@ func (s *orderService) UpdatePaymentProof(orderID uint, userID uint, proofData S
 func (s *orderService) GetAllOrdersAdmin() ([]Order, error) {
 	return s.repo.GetAllOrders()
 }
-func (s *orderService) UpdateOrderStatusAdmin(orderID uint, status string) error {
+func (s *orderService) UpdateOrderStatusAdmin(actor audit.Actor, orderID uint, status string) error {
 	// Check if order exists using repository method
-	_, err := s.repo.GetByID(orderID, 0) // Use 0 for userID since admin can access any order
+	order, err := s.repo.GetByIDAdmin(orderID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New("order not found")
@@ -225,10 +424,17 @@ func (s *orderService) UpdateOrderStatusAdmin(orderID uint, status string) error
 		return err
 	}
 
-	return s.repo.UpdateOrderStatusAdmin(orderID, status)
+	if err := s.repo.UpdateOrderStatusAdmin(orderID, status); err != nil {
+		return err
+	}
+
+	s.audit.Record(actor, audit.ActionOrderStatusUpdate, "order", orderID,
+		map[string]interface{}{"status": order.Status},
+		map[string]interface{}{"status": status})
+	return nil
 }
 
-func (s *orderService) ReviewPaymentProofAdmin(proofID uint, status string, adminNotes string, reviewerID uint) error {
+func (s *orderService) ReviewPaymentProofAdmin(actor audit.Actor, proofID uint, status string, adminNotes string) error {
 	// Check if payment proof exists
 	proof, err := s.repo.GetPaymentProofByID(proofID)
 	if err != nil {
@@ -243,19 +449,40 @@ func (s *orderService) ReviewPaymentProofAdmin(proofID uint, status string, admi
 	}
 
 	// Update payment proof status
-	err = s.repo.ReviewPaymentProof(proofID, status, adminNotes, reviewerID)
+	err = s.repo.ReviewPaymentProof(proofID, status, adminNotes, actor.UserID)
 	if err != nil {
 		return err
 	}
 
+	before := map[string]interface{}{"status": proof.Status, "admin_notes": proof.AdminNotes}
+	after := map[string]interface{}{"status": status, "admin_notes": adminNotes}
+
 	// If approved, update order payment status using repository method
 	if status == "approved" {
-		return s.repo.UpdateOrderPaymentStatus(proof.OrderID, "paid") // ✅ Use repository method
+		if err := s.repo.UpdateOrderPaymentStatus(proof.OrderID, "paid"); err != nil { // ✅ Use repository method
+			return err
+		}
+		before["order_payment_status"] = proof.Order.PaymentStatus
+		after["order_payment_status"] = "paid"
 	}
 
+	s.audit.Record(actor, audit.ActionPaymentProofReview, "payment_proof", proofID, before, after)
 	return nil
 }
 
-func (s *orderService) generateOrderNumber() string {
-	return fmt.Sprintf("ORD%d", time.Now().Unix())
+// orderNumberAlphabet leaves out 0, 1, I and O, which are easily misread.
+const orderNumberAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// generateOrderNumber returns ORD and ten random characters. Order numbers
+// are a credential for guest tracking, so they must not be guessable, e.g.
+// from the time an order was placed.
+func (s *orderService) generateOrderNumber() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = orderNumberAlphabet[int(b[i])%len(orderNumberAlphabet)]
+	}
+	return "ORD" + string(b), nil
 }
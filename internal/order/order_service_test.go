@@ -0,0 +1,147 @@
+package order
+
+import (
+	"ecommerce/internal/auth"
+	"ecommerce/internal/catalog"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// fakeOrderRepo embeds OrderRepository so methods a test doesn't set up
+// panic.
+type fakeOrderRepo struct {
+	OrderRepository
+	orders []*Order
+
+	claimPhone, claimEmail string
+}
+
+func (r *fakeOrderRepo) GetByOrderNumber(orderNumber string) (*Order, error) {
+	for _, order := range r.orders {
+		if order.OrderNumber == orderNumber {
+			return order, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeOrderRepo) ClaimOrders(orderIDs []uint, userID uint, phone, email string) (int64, error) {
+	r.claimPhone, r.claimEmail = phone, email
+	return int64(len(orderIDs)), nil
+}
+
+type fakeUserRepo struct {
+	auth.UserRepository
+	user *auth.User
+}
+
+func (r *fakeUserRepo) FindByID(id uint) (*auth.User, error) {
+	if r.user == nil || r.user.ID != id {
+		return nil, errors.New("record not found")
+	}
+	return r.user, nil
+}
+
+func (r *fakeUserRepo) FindByVerifiedPhone(phone string) (*auth.User, error) {
+	if r.user == nil || r.user.Phone != phone || !r.user.PhoneVerified {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.user, nil
+}
+
+func TestGenerateOrderNumber(t *testing.T) {
+	s := &orderService{}
+	format := regexp.MustCompile(`^ORD[2-9A-HJ-NP-Z]{10}$`)
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		number, err := s.generateOrderNumber()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !format.MatchString(number) {
+			t.Fatalf("order number %q has the wrong format", number)
+		}
+		if seen[number] {
+			t.Fatalf("order number %q repeated", number)
+		}
+		seen[number] = true
+	}
+}
+
+func TestTrackOrder(t *testing.T) {
+	repo := &fakeOrderRepo{orders: []*Order{{
+		OrderNumber:     "ORDABC",
+		Status:          "shipped",
+		CustomerName:    "Jane",
+		CustomerPhone:   "+8801712345678",
+		ShippingAddress: "House 1, Dhaka",
+		Items:           []OrderItem{{ProductName: "Tea", Quantity: 2, Options: catalog.VariantOptions{"Size": "500g"}}},
+	}}}
+	s := &orderService{repo: repo}
+
+	tracking, err := s.TrackOrder(" ORDABC ", "01712-345678")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tracking.Status != "shipped" || len(tracking.Items) != 1 || tracking.Items[0].ProductName != "Tea" {
+		t.Errorf("tracking = %+v", tracking)
+	}
+	if tracking.Items[0].Options["Size"] != "500g" {
+		t.Errorf("item options = %v, want the variant bought", tracking.Items[0].Options)
+	}
+
+	for name, phone := range map[string]string{"wrong phone": "01812345678", "no phone": ""} {
+		if _, err := s.TrackOrder("ORDABC", phone); err == nil {
+			t.Errorf("%s: order found", name)
+		}
+	}
+	if _, err := s.TrackOrder("ORDXYZ", "01712345678"); err == nil {
+		t.Error("unknown order found")
+	}
+}
+
+func TestClaimOrdersUsesVerifiedContactsOnly(t *testing.T) {
+	tests := []struct {
+		name                 string
+		user                 auth.User
+		wantPhone, wantEmail string
+		wantClaimed          int64
+	}{
+		{"both verified", auth.User{Phone: "+8801712345678", PhoneVerified: true, Email: "j@example.com", EmailVerified: true}, "+8801712345678", "j@example.com", 2},
+		{"email unverified", auth.User{Phone: "+8801712345678", PhoneVerified: true, Email: "j@example.com"}, "+8801712345678", "", 2},
+		{"nothing verified", auth.User{Phone: "+8801712345678", Email: "j@example.com"}, "", "", 0},
+	}
+	for _, tt := range tests {
+		tt.user.ID = 7
+		repo := &fakeOrderRepo{}
+		s := &orderService{repo: repo, userRepo: &fakeUserRepo{user: &tt.user}}
+
+		claimed, err := s.ClaimOrders(7, []uint{1, 2})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if claimed != tt.wantClaimed || repo.claimPhone != tt.wantPhone || repo.claimEmail != tt.wantEmail {
+			t.Errorf("%s: claimed %d with (%q, %q), want %d with (%q, %q)",
+				tt.name, claimed, repo.claimPhone, repo.claimEmail, tt.wantClaimed, tt.wantPhone, tt.wantEmail)
+		}
+	}
+}
+
+func TestBlockedUserCannotOrder(t *testing.T) {
+	blockedAt := time.Now()
+	user := &auth.User{ID: 1, Phone: "+8801712345678", PhoneVerified: true, BlockedAt: &blockedAt}
+	s := &orderService{userRepo: &fakeUserRepo{user: user}}
+
+	if _, err := s.CreateOrderFromCart(1, CreateOrderRequest{}); !errors.Is(err, auth.ErrUserBlocked) {
+		t.Errorf("signed in: got %v, want ErrUserBlocked", err)
+	}
+	// Checking out as a guest with the same number is refused too
+	guest := CreateOrderRequest{CustomerPhone: "017-1234-5678"}
+	if _, err := s.CreateGuestOrder(1, guest); !errors.Is(err, auth.ErrUserBlocked) {
+		t.Errorf("as a guest: got %v, want ErrUserBlocked", err)
+	}
+}
@@ -0,0 +1,73 @@
+package order
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Guest tracking is keyed on an order number and phone, so each client only
+// gets a few guesses per window.
+const (
+	trackLimit  = 20
+	trackWindow = 10 * time.Minute
+)
+
+// ipLimiter counts requests per client IP in fixed windows. Counts are kept
+// in memory, so each replica limits on its own.
+type ipLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	clients map[string]*ipWindow
+	pruneAt time.Time
+}
+
+type ipWindow struct {
+	start time.Time
+	count int
+}
+
+func newIPLimiter(limit int, window time.Duration) *ipLimiter {
+	return &ipLimiter{limit: limit, window: window, clients: make(map[string]*ipWindow)}
+}
+
+// allow counts a request from ip and reports whether it is within the limit.
+func (l *ipLimiter) allow(ip string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.After(l.pruneAt) {
+		for client, w := range l.clients {
+			if now.Sub(w.start) >= l.window {
+				delete(l.clients, client)
+			}
+		}
+		l.pruneAt = now.Add(l.window)
+	}
+
+	w, ok := l.clients[ip]
+	if !ok || now.Sub(w.start) >= l.window {
+		w = &ipWindow{start: now}
+		l.clients[ip] = w
+	}
+	w.count++
+	return w.count <= l.limit
+}
+
+// RateLimitTracking rejects clients making too many guest tracking lookups.
+func RateLimitTracking() gin.HandlerFunc {
+	limiter := newIPLimiter(trackLimit, trackWindow)
+	return func(c *gin.Context) {
+		if !limiter.allow(c.ClientIP(), time.Now()) {
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error": "too many tracking requests, try again later",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,34 @@
+package order
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIPLimiter(t *testing.T) {
+	limiter := newIPLimiter(2, time.Minute)
+	now := time.Now()
+
+	for i, want := range []bool{true, true, false} {
+		if got := limiter.allow("10.0.0.1", now); got != want {
+			t.Errorf("request %d: allow = %v, want %v", i+1, got, want)
+		}
+	}
+	if !limiter.allow("10.0.0.2", now) {
+		t.Error("another client was limited")
+	}
+	if !limiter.allow("10.0.0.1", now.Add(time.Minute)) {
+		t.Error("client still limited in the next window")
+	}
+}
+
+func TestIPLimiterPrunesOldWindows(t *testing.T) {
+	limiter := newIPLimiter(2, time.Minute)
+	now := time.Now()
+	limiter.allow("10.0.0.1", now)
+	limiter.allow("10.0.0.2", now.Add(2*time.Minute))
+
+	if _, ok := limiter.clients["10.0.0.1"]; ok {
+		t.Error("expired window was not pruned")
+	}
+}
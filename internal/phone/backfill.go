@@ -0,0 +1,116 @@
+package phone
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+const backfillBatchSize = 500
+
+// column is a phone number column that NormalizeExisting rewrites.
+type column struct {
+	table string
+	name  string
+	// verified is set for users.phone, where two verified numbers may not
+	// normalize to the same value since it is a login identifier.
+	verified bool
+}
+
+var columns = []column{
+	{table: "users", name: "phone", verified: true},
+	{table: "addresses", name: "phone"},
+	{table: "orders", name: "customer_phone"},
+	{table: "payment_proofs", name: "sender_number"},
+}
+
+// ColumnReport counts what NormalizeExisting did with one column.
+type ColumnReport struct {
+	Column    string `json:"column"`
+	Scanned   int    `json:"scanned"`
+	Updated   int    `json:"updated"`
+	Invalid   int    `json:"invalid"`   // Left as is; not a BD mobile number
+	Conflicts int    `json:"conflicts"` // Left as is; would clash with another verified user
+}
+
+// NormalizeExisting rewrites the phone numbers stored before numbers were
+// normalized on input. It is safe to run more than once.
+func NormalizeExisting(db *gorm.DB) ([]ColumnReport, error) {
+	reports := make([]ColumnReport, 0, len(columns))
+	for _, col := range columns {
+		report, err := normalizeColumn(db, col)
+		if err != nil {
+			return reports, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+func normalizeColumn(db *gorm.DB, col column) (ColumnReport, error) {
+	report := ColumnReport{Column: col.table + "." + col.name}
+
+	type row struct {
+		ID       uint
+		Value    string
+		Verified bool
+	}
+
+	selectSQL := "id, " + col.name + " AS value"
+	if col.verified {
+		selectSQL += ", phone_verified AS verified"
+	}
+
+	var lastID uint
+	for {
+		var rows []row
+		err := db.Table(col.table).
+			Select(selectSQL).
+			Where("id > ? AND "+col.name+" <> ''", lastID).
+			Order("id").
+			Limit(backfillBatchSize).
+			Scan(&rows).Error
+		if err != nil {
+			return report, err
+		}
+		if len(rows) == 0 {
+			return report, nil
+		}
+
+		for _, r := range rows {
+			lastID = r.ID
+			report.Scanned++
+
+			normalized, err := Normalize(r.Value)
+			if err != nil {
+				report.Invalid++
+				log.Printf("⚠️ %s #%d: cannot normalize %q", report.Column, r.ID, r.Value)
+				continue
+			}
+			if normalized == r.Value {
+				continue
+			}
+
+			if col.verified && r.Verified {
+				var taken int64
+				err := db.Table(col.table).
+					Where(col.name+" = ? AND phone_verified AND id <> ?", normalized, r.ID).
+					Count(&taken).Error
+				if err != nil {
+					return report, err
+				}
+				if taken > 0 {
+					report.Conflicts++
+					log.Printf("⚠️ %s #%d: %s is already verified by another user", report.Column, r.ID, normalized)
+					continue
+				}
+			}
+
+			err = db.Table(col.table).Where("id = ?", r.ID).UpdateColumn(col.name, normalized).Error
+			if err != nil {
+				return report, err
+			}
+			report.Updated++
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Package phone validates and normalizes Bangladeshi mobile numbers.
+package phone
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+var ErrInvalid = errors.New("invalid phone number")
+
+// operators maps the digit after "01" to the mobile operator it belongs to.
+var operators = map[byte]string{
+	'3': "Grameenphone",
+	'4': "Banglalink",
+	'5': "Teletalk",
+	'6': "Airtel",
+	'7': "Grameenphone",
+	'8': "Robi",
+	'9': "Banglalink",
+}
+
+// Normalize converts a Bangladeshi mobile number to E.164 (+8801XXXXXXXXX).
+// It accepts the local form (01712345678) as well as 8801... and +8801...,
+// ignoring spaces, dashes and brackets.
+func Normalize(number string) (string, error) {
+	digits := strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(strings.TrimSpace(number))
+	digits = strings.TrimPrefix(digits, "+")
+
+	switch {
+	case strings.HasPrefix(digits, "880"):
+		digits = digits[3:]
+	case strings.HasPrefix(digits, "0"):
+		digits = digits[1:]
+	}
+
+	// 1 + operator digit + 8 subscriber digits
+	if len(digits) != 10 || digits[0] != '1' {
+		return "", ErrInvalid
+	}
+	if _, ok := operators[digits[1]]; !ok {
+		return "", ErrInvalid
+	}
+	for _, d := range digits {
+		if d < '0' || d > '9' {
+			return "", ErrInvalid
+		}
+	}
+	return "+880" + digits, nil
+}
+
+// IsValid reports whether number is a Bangladeshi mobile number in any of the
+// forms Normalize accepts.
+func IsValid(number string) bool {
+	_, err := Normalize(number)
+	return err == nil
+}
+
+// Operator returns the mobile operator for number, or "" if it is invalid.
+func Operator(number string) string {
+	normalized, err := Normalize(number)
+	if err != nil {
+		return ""
+	}
+	return operators[normalized[5]]
+}
+
+// Same reports whether a and b are the same number. Numbers that cannot be
+// normalized only match if they are identical.
+func Same(a, b string) bool {
+	na, errA := Normalize(a)
+	nb, errB := Normalize(b)
+	if errA != nil || errB != nil {
+		return strings.TrimSpace(a) != "" && strings.TrimSpace(a) == strings.TrimSpace(b)
+	}
+	return na == nb
+}
+
+// RegisterValidator adds the "bdphone" binding tag, so request structs can
+// declare `binding:"required,bdphone"`. Handlers still store the result of
+// Normalize, not the raw input.
+func RegisterValidator() error {
+	engine, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("unexpected validator engine")
+	}
+	return engine.RegisterValidation("bdphone", func(fl validator.FieldLevel) bool {
+		return IsValid(fl.Field().String())
+	})
+}
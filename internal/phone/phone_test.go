@@ -0,0 +1,72 @@
+package phone
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"01712345678":    "+8801712345678",
+		"+8801712345678": "+8801712345678",
+		"8801712345678":  "+8801712345678",
+		"017-1234 5678":  "+8801712345678",
+		"(017) 12345678": "+8801712345678",
+		" 01912345678 ":  "+8801912345678",
+		"01212345678":    "",
+		"0171234567":     "",
+		"017123456789":   "",
+		"0171234567a":    "",
+		"+14155550100":   "",
+		"":               "",
+	}
+	for in, want := range tests {
+		got, err := Normalize(in)
+		if want == "" {
+			if !errors.Is(err, ErrInvalid) {
+				t.Errorf("Normalize(%q) = %q, %v; want ErrInvalid", in, got, err)
+			}
+			continue
+		}
+		if err != nil || got != want {
+			t.Errorf("Normalize(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+}
+
+func TestOperator(t *testing.T) {
+	tests := map[string]string{
+		"01712345678":    "Grameenphone",
+		"+8801312345678": "Grameenphone",
+		"01912345678":    "Banglalink",
+		"01812345678":    "Robi",
+		"01612345678":    "Airtel",
+		"01512345678":    "Teletalk",
+		"01212345678":    "",
+	}
+	for in, want := range tests {
+		if got := Operator(in); got != want {
+			t.Errorf("Operator(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSame(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"01712345678", "+8801712345678", true},
+		{"017-1234-5678", "8801712345678", true},
+		{"01712345678", "01812345678", false},
+		// Numbers that don't normalize only match themselves
+		{"12345", "12345", true},
+		{"12345", "012345", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		if got := Same(tt.a, tt.b); got != tt.want {
+			t.Errorf("Same(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package visitor
+
+import "time"
+
+// Ways a visitor report can be grouped.
+const (
+	GroupByCountry = "country"
+	GroupByRegion  = "region"
+	GroupByCity    = "city"
+	GroupByDay     = "day"
+	GroupByHour    = "hour"
+	GroupByPath    = "path"
+)
+
+// Dimensions stored in the daily rollup. Reports by day read dimensionTotal;
+// reports by hour read dimensionHour, whose value is the hour of day ("00"-"23").
+const (
+	dimensionTotal   = "total"
+	dimensionCountry = "country"
+	dimensionRegion  = "region"
+	dimensionCity    = "city"
+	dimensionPath    = "path"
+	dimensionHour    = "hour"
+)
+
+var rollupDimensions = []string{dimensionTotal, dimensionCountry, dimensionRegion, dimensionCity, dimensionPath, dimensionHour}
+
+// DailyStat is one row of the daily rollup of visitor_logs: the visits on Day
+// that share a value of a dimension, such as the country "Bangladesh".
+// UniqueVisitors counts distinct IPs on that day.
+type DailyStat struct {
+	Day            time.Time `gorm:"primaryKey;type:date" json:"day"`
+	Dimension      string    `gorm:"primaryKey" json:"dimension"`
+	Value          string    `gorm:"primaryKey" json:"value"`
+	Visits         int64     `gorm:"not null" json:"visits"`
+	UniqueVisitors int64     `gorm:"not null" json:"unique_visitors"`
+}
+
+func (DailyStat) TableName() string {
+	return "visitor_daily_stats"
+}
+
+// RolledUpDay marks a day whose visits are in visitor_daily_stats. Reports
+// read rolled up days from there and the rest from visitor_logs.
+type RolledUpDay struct {
+	Day        time.Time `gorm:"primaryKey;type:date"`
+	RolledUpAt time.Time
+}
+
+func (RolledUpDay) TableName() string {
+	return "visitor_rollup_days"
+}
+
+// ReportQuery is GET /api/v1/admin/analytics/visitors. From and To are
+// inclusive dates and default to the last 30 days.
+type ReportQuery struct {
+	GroupBy string    `form:"group_by" binding:"required,oneof=country region city day hour path"`
+	From    time.Time `form:"from" time_format:"2006-01-02"`
+	To      time.Time `form:"to" time_format:"2006-01-02"`
+	Limit   int       `form:"limit"` // Rows to return, for the non-time groupings
+}
+
+// Counts are total visits and unique visitors. Over more than one day,
+// unique visitors are summed per day: someone visiting on two days counts
+// twice.
+type Counts struct {
+	Visits         int64 `json:"visits"`
+	UniqueVisitors int64 `json:"unique_visitors"`
+}
+
+type ReportRow struct {
+	Key string `json:"key"`
+	Counts
+}
+
+type Report struct {
+	GroupBy string      `json:"group_by"`
+	From    string      `json:"from"`
+	To      string      `json:"to"`
+	Totals  Counts      `json:"totals"`
+	Rows    []ReportRow `json:"rows"`
+}
@@ -0,0 +1,91 @@
+package visitor
+
+import (
+	"errors"
+	"log"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+var ErrInvalidIP = errors.New("invalid IP address")
+
+// Locator resolves IP addresses to locations without leaving the process.
+type Locator interface {
+	Lookup(ip string) (Location, error)
+	Close() error
+}
+
+// cityRecord is the part of a GeoIP2/GeoLite2 City record we use.
+type cityRecord struct {
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+type mmdbLocator struct {
+	reader *maxminddb.Reader
+}
+
+// OpenGeoIP opens a MaxMind-format City database, such as GeoLite2-City.mmdb
+// or DB-IP City Lite. With no path, or a file that cannot be opened, visits
+// are still tracked, just without a location.
+func OpenGeoIP(path string) Locator {
+	if path == "" {
+		log.Printf("⚠️ GEOIP_DB_PATH not set, visitor locations will be empty")
+		return noopLocator{}
+	}
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		log.Printf("⚠️ Failed to open GeoIP database %s: %v", path, err)
+		return noopLocator{}
+	}
+	log.Printf("📍 GeoIP database loaded: %s (%s)", path, reader.Metadata.DatabaseType)
+	return &mmdbLocator{reader: reader}
+}
+
+func (l *mmdbLocator) Lookup(ip string) (Location, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return Location{}, ErrInvalidIP
+	}
+
+	var record cityRecord
+	if err := l.reader.Lookup(parsed, &record); err != nil {
+		return Location{}, err
+	}
+
+	location := Location{
+		CountryCode: record.Country.ISOCode,
+		Country:     record.Country.Names["en"],
+		City:        record.City.Names["en"],
+	}
+	if len(record.Subdivisions) > 0 {
+		location.Region = record.Subdivisions[0].Names["en"]
+	}
+	return location, nil
+}
+
+func (l *mmdbLocator) Close() error {
+	return l.reader.Close()
+}
+
+type noopLocator struct{}
+
+func (noopLocator) Lookup(ip string) (Location, error) {
+	if net.ParseIP(ip) == nil {
+		return Location{}, ErrInvalidIP
+	}
+	return Location{}, nil
+}
+
+func (noopLocator) Close() error {
+	return nil
+}
@@ -0,0 +1,77 @@
+package visitor
+
+import (
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultAnonymizeAfterDays = 30
+	defaultDeleteAfterDays    = 180
+	retentionBatchSize        = 1000
+)
+
+// RetentionPolicy says how long visitor_logs keeps personal data. Raw IPs
+// are truncated after AnonymizeAfter, and rows are deleted after DeleteAfter
+// if their day has been rolled up. Zero turns a step off.
+type RetentionPolicy struct {
+	AnonymizeAfter time.Duration
+	DeleteAfter    time.Duration
+}
+
+// RetentionFromEnv reads VISITOR_ANONYMIZE_IP_DAYS (default 30) and
+// VISITOR_RETENTION_DAYS (default 180).
+func RetentionFromEnv() RetentionPolicy {
+	return RetentionPolicy{
+		AnonymizeAfter: envDays("VISITOR_ANONYMIZE_IP_DAYS", defaultAnonymizeAfterDays),
+		DeleteAfter:    envDays("VISITOR_RETENTION_DAYS", defaultDeleteAfterDays),
+	}
+}
+
+func envDays(key string, fallback int) time.Duration {
+	days, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || days < 0 {
+		days = fallback
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// RetentionResult counts what one run of the retention job did.
+type RetentionResult struct {
+	RolledUpDays int   `json:"rolled_up_days"`
+	Anonymized   int64 `json:"anonymized"`
+	Deleted      int64 `json:"deleted"`
+}
+
+// StartRetention applies the policy every interval, starting now.
+func StartRetention(service Service, policy RetentionPolicy, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			result, err := service.ApplyRetention(policy)
+			if err != nil {
+				log.Printf("⚠️ Visitor retention failed: %v", err)
+			} else if result.Anonymized > 0 || result.Deleted > 0 {
+				log.Printf("🗑️ Visitor retention: %d IPs anonymized, %d rows deleted", result.Anonymized, result.Deleted)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// AnonymizeIP truncates an IPv4 address to its /24 and an IPv6 address to
+// its /48, e.g. 203.0.113.57 becomes 203.0.113.0. Anything else becomes "".
+func AnonymizeIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(48, 128)).String()
+}
@@ -0,0 +1,127 @@
+package visitor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeRetentionRepo keeps visits in memory for the retention job.
+type fakeRetentionRepo struct {
+	fakeReportRepo
+	visits  []Visit
+	deleted int64
+}
+
+func (r *fakeRetentionRepo) FindUnanonymized(before time.Time, limit int) ([]Visit, error) {
+	var found []Visit
+	for _, visit := range r.visits {
+		if visit.CreatedAt.Before(before) && !visit.IPAnonymized && len(found) < limit {
+			found = append(found, visit)
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeRetentionRepo) SetAnonymizedIP(ids []uint, ip string) error {
+	for _, id := range ids {
+		for i := range r.visits {
+			if r.visits[i].ID == id {
+				r.visits[i].IP = ip
+				r.visits[i].IPAnonymized = true
+			}
+		}
+	}
+	return nil
+}
+
+func (r *fakeRetentionRepo) DeleteRolledUpBefore(before time.Time) (int64, error) {
+	return r.deleted, nil
+}
+
+func TestAnonymizeIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"203.0.113.57", "203.0.113.0"},
+		{"10.1.2.255", "10.1.2.0"},
+		{"::ffff:203.0.113.57", "203.0.113.0"},
+		{"2001:db8:abcd:12:3456::1", "2001:db8:abcd::"},
+		{"2001:db8::1", "2001:db8::"},
+		{"", ""},
+		{"not-an-ip", ""},
+		{"203.0.113.57:8080", ""},
+	}
+	for _, tt := range tests {
+		if got := AnonymizeIP(tt.ip); got != tt.want {
+			t.Errorf("AnonymizeIP(%q) = %q, want %q", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestApplyRetention(t *testing.T) {
+	old := time.Now().AddDate(0, 0, -40)
+	repo := &fakeRetentionRepo{
+		fakeReportRepo: fakeReportRepo{pending: []time.Time{startOfDay(old)}},
+		visits: []Visit{
+			{ID: 1, IP: "203.0.113.57", CreatedAt: old},
+			{ID: 2, IP: "203.0.113.9", CreatedAt: old},
+			{ID: 3, IP: "198.51.100.7", CreatedAt: time.Now()},
+		},
+		deleted: 4,
+	}
+	policy := RetentionPolicy{AnonymizeAfter: 30 * 24 * time.Hour, DeleteAfter: 180 * 24 * time.Hour}
+
+	result, err := NewService(repo).ApplyRetention(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.RolledUpDays != 1 || result.Anonymized != 2 || result.Deleted != 4 {
+		t.Errorf("result = %+v", result)
+	}
+	want := []string{"203.0.113.0", "203.0.113.0", "198.51.100.7"}
+	for i, visit := range repo.visits {
+		if visit.IP != want[i] {
+			t.Errorf("visit %d IP = %q, want %q", visit.ID, visit.IP, want[i])
+		}
+	}
+}
+
+func TestRetentionFromEnv(t *testing.T) {
+	t.Setenv("VISITOR_ANONYMIZE_IP_DAYS", "0")
+	t.Setenv("VISITOR_RETENTION_DAYS", "-5")
+	policy := RetentionFromEnv()
+	if policy.AnonymizeAfter != 0 {
+		t.Errorf("AnonymizeAfter = %v, want 0 to turn it off", policy.AnonymizeAfter)
+	}
+	if policy.DeleteAfter != defaultDeleteAfterDays*24*time.Hour {
+		t.Errorf("DeleteAfter = %v, want the default for a negative value", policy.DeleteAfter)
+	}
+}
+
+func TestMiddlewareHonorsOptOut(t *testing.T) {
+	tracker := NewTracker(&fakeRepo{}, fakeLocator{}, testConfig())
+	router := gin.New()
+	router.Use(Middleware(tracker))
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for ip, header := range map[string]string{
+		"203.0.113.1": "DNT",
+		"203.0.113.2": "Sec-GPC",
+		"203.0.113.3": "",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		if header != "" {
+			req.Header.Set(header, "1")
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if len(tracker.seen) != 1 {
+		t.Errorf("tracked %d visitors, want only the one without an opt-out header", len(tracker.seen))
+	}
+}
@@ -0,0 +1,224 @@
+package visitor
+
+import (
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultDedupWindow   = 30 * time.Minute
+	defaultBufferSize    = 4096
+	defaultBatchSize     = 200
+	defaultFlushInterval = 5 * time.Second
+	maxSeenIPs           = 200000 // Bounds memory if a flood of IPs hits us
+	maxPathLength        = 255
+)
+
+// Config controls the tracker. ConfigFromEnv fills it from the environment.
+type Config struct {
+	Enabled       bool
+	DedupWindow   time.Duration // Repeat requests from an IP within it are one visit
+	BufferSize    int           // Visits queued for the writer; more are dropped
+	BatchSize     int
+	FlushInterval time.Duration
+}
+
+// ConfigFromEnv reads VISITOR_TRACKING ("off" disables tracking) and
+// VISITOR_DEDUP_WINDOW (a Go duration, default 30m).
+func ConfigFromEnv() Config {
+	config := Config{
+		Enabled:       true,
+		DedupWindow:   defaultDedupWindow,
+		BufferSize:    defaultBufferSize,
+		BatchSize:     defaultBatchSize,
+		FlushInterval: defaultFlushInterval,
+	}
+	switch strings.ToLower(os.Getenv("VISITOR_TRACKING")) {
+	case "off", "false", "0":
+		config.Enabled = false
+	}
+	if window, err := time.ParseDuration(os.Getenv("VISITOR_DEDUP_WINDOW")); err == nil && window > 0 {
+		config.DedupWindow = window
+	}
+	return config
+}
+
+type hit struct {
+	ip   string
+	path string
+	at   time.Time
+}
+
+// Tracker records visits off the request path. Track de-duplicates and queues
+// a visit; a single writer goroutine resolves locations from the local GeoIP
+// database and inserts visits in batches. When the queue is full, visits are
+// dropped rather than slowing requests down.
+type Tracker struct {
+	repo    Repository
+	locator Locator
+	config  Config
+
+	hits    chan hit
+	done    chan struct{}
+	stopped chan struct{}
+	dropped atomic.Int64
+
+	mu   sync.Mutex
+	seen map[string]time.Time // IP -> start of its current visit
+}
+
+func NewTracker(repo Repository, locator Locator, config Config) *Tracker {
+	return &Tracker{
+		repo:    repo,
+		locator: locator,
+		config:  config,
+		hits:    make(chan hit, config.BufferSize),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+		seen:    make(map[string]time.Time),
+	}
+}
+
+// Start runs the writer. Call it once.
+func (t *Tracker) Start() {
+	go t.run()
+}
+
+// Stop flushes queued visits and waits for the writer to finish.
+func (t *Tracker) Stop() {
+	close(t.done)
+	<-t.stopped
+}
+
+// Track queues a visit from ip landing on path, unless the IP was already seen
+// within the de-duplication window. It never blocks.
+func (t *Tracker) Track(ip, path string) bool {
+	if !t.config.Enabled || !isPublicIP(ip) {
+		return false
+	}
+
+	now := time.Now()
+	if !t.firstSeen(ip, now) {
+		return false
+	}
+
+	if len(path) > maxPathLength {
+		path = path[:maxPathLength]
+	}
+	select {
+	case t.hits <- hit{ip: ip, path: path, at: now}:
+		return true
+	default:
+		t.dropped.Add(1)
+		return false
+	}
+}
+
+// firstSeen reports whether ip starts a new visit at now and remembers it.
+func (t *Tracker) firstSeen(ip string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if last, ok := t.seen[ip]; ok && now.Sub(last) < t.config.DedupWindow {
+		return false
+	}
+	if len(t.seen) >= maxSeenIPs {
+		t.seen = make(map[string]time.Time)
+	}
+	t.seen[ip] = now
+	return true
+}
+
+// forgetExpired drops IPs whose visit window has ended.
+func (t *Tracker) forgetExpired(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for ip, last := range t.seen {
+		if now.Sub(last) >= t.config.DedupWindow {
+			delete(t.seen, ip)
+		}
+	}
+}
+
+func (t *Tracker) run() {
+	defer close(t.stopped)
+
+	ticker := time.NewTicker(t.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]hit, 0, t.config.BatchSize)
+	lastPrune := time.Now()
+	for {
+		select {
+		case h := <-t.hits:
+			batch = append(batch, h)
+			if len(batch) >= t.config.BatchSize {
+				t.flush(batch)
+				batch = batch[:0]
+			}
+		case now := <-ticker.C:
+			t.flush(batch)
+			batch = batch[:0]
+			if now.Sub(lastPrune) >= t.config.DedupWindow {
+				t.forgetExpired(now)
+				lastPrune = now
+			}
+		case <-t.done:
+			for {
+				select {
+				case h := <-t.hits:
+					batch = append(batch, h)
+				default:
+					t.flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (t *Tracker) flush(batch []hit) {
+	if dropped := t.dropped.Swap(0); dropped > 0 {
+		log.Printf("⚠️ Visitor queue full, dropped %d visits", dropped)
+	}
+	if len(batch) == 0 {
+		return
+	}
+
+	visits := make([]Visit, 0, len(batch))
+	for _, h := range batch {
+		location, err := t.locator.Lookup(h.ip)
+		if err != nil {
+			log.Printf("⚠️ GeoIP lookup failed for %s: %v", h.ip, err)
+		}
+		visits = append(visits, Visit{
+			IP:          h.ip,
+			CountryCode: location.CountryCode,
+			Country:     location.Country,
+			Region:      location.Region,
+			City:        location.City,
+			Path:        h.path,
+			CreatedAt:   h.at,
+		})
+	}
+
+	if err := t.repo.CreateBatch(visits); err != nil {
+		log.Printf("⚠️ Failed to save %d visits: %v", len(visits), err)
+	}
+}
+
+// isPublicIP leaves out local and private addresses, which have no location
+// and are usually health checks or the proxy itself.
+func isPublicIP(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	return !parsed.IsLoopback() && !parsed.IsPrivate() && !parsed.IsUnspecified() && !parsed.IsLinkLocalUnicast()
+}
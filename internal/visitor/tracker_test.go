@@ -0,0 +1,129 @@
+package visitor
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeRepo struct {
+	Repository
+	mu      sync.Mutex
+	batches [][]Visit
+}
+
+func (r *fakeRepo) CreateBatch(visits []Visit) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, append([]Visit(nil), visits...))
+	return nil
+}
+
+type fakeLocator struct{}
+
+func (fakeLocator) Lookup(ip string) (Location, error) {
+	return Location{CountryCode: "BD", Country: "Bangladesh", City: "Dhaka"}, nil
+}
+
+func (fakeLocator) Close() error { return nil }
+
+func (r *fakeRepo) batchCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.batches)
+}
+
+func waitForBatches(t *testing.T, repo *fakeRepo, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for repo.batchCount() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d batches written, want %d", repo.batchCount(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func testConfig() Config {
+	return Config{
+		Enabled:       true,
+		DedupWindow:   time.Minute,
+		BufferSize:    16,
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+	}
+}
+
+func TestTrackDeduplicates(t *testing.T) {
+	tracker := NewTracker(&fakeRepo{}, fakeLocator{}, testConfig())
+
+	if !tracker.Track("203.0.113.1", "/") {
+		t.Fatal("first visit not tracked")
+	}
+	if tracker.Track("203.0.113.1", "/products") {
+		t.Error("repeat request within the window tracked as a new visit")
+	}
+	if !tracker.Track("203.0.113.2", "/") {
+		t.Error("visit from another IP not tracked")
+	}
+
+	// The window has passed for the first IP
+	tracker.seen["203.0.113.1"] = time.Now().Add(-2 * time.Minute)
+	if !tracker.Track("203.0.113.1", "/") {
+		t.Error("visit after the window not tracked")
+	}
+}
+
+func TestTrackSkipsLocalAddresses(t *testing.T) {
+	tracker := NewTracker(&fakeRepo{}, fakeLocator{}, testConfig())
+	for _, ip := range []string{"127.0.0.1", "10.0.0.5", "192.168.1.10", "::1", "fe80::1", "", "not-an-ip"} {
+		if tracker.Track(ip, "/") {
+			t.Errorf("tracked %q", ip)
+		}
+	}
+
+	config := testConfig()
+	config.Enabled = false
+	if NewTracker(&fakeRepo{}, fakeLocator{}, config).Track("203.0.113.1", "/") {
+		t.Error("tracked with tracking disabled")
+	}
+}
+
+func TestTrackDropsWhenQueueIsFull(t *testing.T) {
+	config := testConfig()
+	config.BufferSize = 1
+	tracker := NewTracker(&fakeRepo{}, fakeLocator{}, config)
+
+	// No writer is running, so the queue stays full
+	tracker.Track("203.0.113.1", "/")
+	if tracker.Track("203.0.113.2", "/") {
+		t.Error("visit queued beyond the buffer")
+	}
+	if got := tracker.dropped.Load(); got != 1 {
+		t.Errorf("dropped = %d, want 1", got)
+	}
+}
+
+func TestTrackerWritesInBatches(t *testing.T) {
+	repo := &fakeRepo{}
+	tracker := NewTracker(repo, fakeLocator{}, testConfig())
+	tracker.Start()
+
+	tracker.Track("203.0.113.1", "/")
+	tracker.Track("203.0.113.2", "/"+strings.Repeat("a", 300))
+	waitForBatches(t, repo, 1)
+	tracker.Track("203.0.113.3", "/cart")
+	tracker.Stop()
+
+	if len(repo.batches) != 2 || len(repo.batches[0]) != 2 || len(repo.batches[1]) != 1 {
+		t.Fatalf("batches = %v, want a full batch then the rest on stop", repo.batches)
+	}
+	visit := repo.batches[0][1]
+	if visit.IP != "203.0.113.2" || visit.City != "Dhaka" || visit.CountryCode != "BD" {
+		t.Errorf("visit = %+v", visit)
+	}
+	if len(visit.Path) != maxPathLength {
+		t.Errorf("path length %d, want it cut to %d", len(visit.Path), maxPathLength)
+	}
+}
@@ -0,0 +1,88 @@
+package visitor
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Controller struct {
+	locator Locator
+	service Service
+}
+
+func NewController(locator Locator, service Service) *Controller {
+	return &Controller{locator: locator, service: service}
+}
+
+// VisitorDivision tells the caller where their IP address is, e.g. to
+// preselect the division on the address form.
+func (c *Controller) VisitorDivision(ctx *gin.Context) {
+	ip := ctx.ClientIP()
+	location, err := c.locator.Lookup(ip)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to get location",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"ip":           ip,
+		"country_code": location.CountryCode,
+		"country":      location.Country,
+		"region":       location.Region,
+		"city":         location.City,
+	})
+}
+
+// VisitorReport groups visits over a date range. ?format=csv downloads the
+// rows as CSV.
+func (c *Controller) VisitorReport(ctx *gin.Context) {
+	var query ReportQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	report, err := c.service.Report(query)
+	if err != nil {
+		if errors.Is(err, ErrInvalidRange) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "from must be on or before to, at most 366 days apart",
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to build visitor report",
+		})
+		return
+	}
+
+	switch ctx.DefaultQuery("format", "json") {
+	case "json":
+		ctx.JSON(http.StatusOK, report)
+	case "csv":
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.Write([]string{report.GroupBy, "visits", "unique_visitors"})
+		for _, row := range report.Rows {
+			w.Write([]string{row.Key, strconv.FormatInt(row.Visits, 10), strconv.FormatInt(row.UniqueVisitors, 10)})
+		}
+		w.Flush()
+
+		filename := "visitors-" + report.GroupBy + "-" + report.From + "-" + report.To + ".csv"
+		ctx.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+		ctx.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "format must be 'json' or 'csv'",
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package visitor
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware records the request as a visit once the handler has run. It only
+// does a map lookup and a non-blocking queue send, so it adds no I/O to the
+// request. Browsers sending Do Not Track or Global Privacy Control are not
+// tracked.
+func Middleware(tracker *Tracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if optedOut(c) {
+			return
+		}
+
+		// Page views only: skip writes, preflights, static files and unknown
+		// paths, which are mostly scanners
+		if c.Request.Method != http.MethodGet || c.Writer.Status() == http.StatusNotFound {
+			return
+		}
+		if strings.HasPrefix(c.Request.URL.Path, "/uploads/") {
+			return
+		}
+		tracker.Track(c.ClientIP(), c.Request.URL.Path)
+	}
+}
+
+// optedOut reports whether the client asked not to be tracked with the DNT
+// or Sec-GPC header.
+func optedOut(c *gin.Context) bool {
+	return c.GetHeader("DNT") == "1" || c.GetHeader("Sec-GPC") == "1"
+}
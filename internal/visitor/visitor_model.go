@@ -0,0 +1,31 @@
+package visitor
+
+import "time"
+
+// Visit is one row in visitor_logs: the first request from an IP within the
+// de-duplication window. Path is the landing path of that visit. Once the
+// retention policy anonymizes it, IP is truncated to its /24 or /48 network.
+type Visit struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	IP           string    `json:"ip"`
+	IPAnonymized bool      `gorm:"not null;default:false" json:"ip_anonymized"`
+	CountryCode  string    `json:"country_code"`
+	Country      string    `json:"country"`
+	Region       string    `json:"region"`
+	City         string    `json:"city"`
+	Path         string    `json:"path"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (Visit) TableName() string {
+	return "visitor_logs"
+}
+
+// Location is where an IP address is, as far as the GeoIP database knows.
+// Fields are empty when it does not.
+type Location struct {
+	CountryCode string `json:"country_code"`
+	Country     string `json:"country"`
+	Region      string `json:"region"`
+	City        string `json:"city"`
+}
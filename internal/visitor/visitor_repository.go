@@ -0,0 +1,137 @@
+package visitor
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	CreateBatch(visits []Visit) error
+
+	// Rollup methods
+	RolledUpDays(from, to time.Time) ([]time.Time, error)
+	PendingRollupDays(before time.Time) ([]time.Time, error)
+	RollUpDay(day time.Time) error
+
+	// Report methods. Both return per-day rows for one dimension in [from, to).
+	DailyStats(dimension string, from, to time.Time) ([]DailyStat, error)
+	RawStats(dimension string, from, to time.Time, skipDays []time.Time) ([]DailyStat, error)
+
+	// Retention methods
+	FindUnanonymized(before time.Time, limit int) ([]Visit, error)
+	SetAnonymizedIP(ids []uint, ip string) error
+	DeleteRolledUpBefore(before time.Time) (int64, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateBatch(visits []Visit) error {
+	return r.db.CreateInBatches(visits, len(visits)).Error
+}
+
+// dimensionColumns are the SQL expressions for each rollup dimension. The
+// location columns predate the tracker and are nullable.
+var dimensionColumns = map[string]string{
+	dimensionTotal:   "''",
+	dimensionCountry: "COALESCE(country, '')",
+	dimensionRegion:  "COALESCE(region, '')",
+	dimensionCity:    "COALESCE(city, '')",
+	dimensionPath:    "path",
+	dimensionHour:    "to_char(created_at, 'HH24')",
+}
+
+func (r *repository) RolledUpDays(from, to time.Time) ([]time.Time, error) {
+	var days []time.Time
+	err := r.db.Model(&RolledUpDay{}).
+		Where("day >= ? AND day < ?", from, to).
+		Order("day").
+		Pluck("day", &days).Error
+	return days, err
+}
+
+func (r *repository) PendingRollupDays(before time.Time) ([]time.Time, error) {
+	var days []time.Time
+	err := r.db.Raw(`SELECT DISTINCT created_at::date AS day FROM visitor_logs
+		WHERE created_at < ? AND created_at::date NOT IN (SELECT day FROM visitor_rollup_days)
+		ORDER BY day`, before).
+		Scan(&days).Error
+	return days, err
+}
+
+// RollUpDay replaces the day's rows in visitor_daily_stats and marks it as
+// rolled up, in one transaction so reports never see half a day.
+func (r *repository) RollUpDay(day time.Time) error {
+	next := day.AddDate(0, 0, 1)
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("day = ?", day).Delete(&DailyStat{}).Error; err != nil {
+			return err
+		}
+		for _, dimension := range rollupDimensions {
+			err := tx.Exec(`INSERT INTO visitor_daily_stats (day, dimension, value, visits, unique_visitors)
+				SELECT ?::date, ?, `+dimensionColumns[dimension]+`, COUNT(*), COUNT(DISTINCT ip)
+				FROM visitor_logs
+				WHERE created_at >= ? AND created_at < ?
+				GROUP BY 3`, day, dimension, day, next).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "day"}},
+			DoUpdates: clause.AssignmentColumns([]string{"rolled_up_at"}),
+		}).Create(&RolledUpDay{Day: day, RolledUpAt: time.Now()}).Error
+	})
+}
+
+func (r *repository) DailyStats(dimension string, from, to time.Time) ([]DailyStat, error) {
+	var stats []DailyStat
+	err := r.db.Where("dimension = ? AND day >= ? AND day < ?", dimension, from, to).
+		Find(&stats).Error
+	return stats, err
+}
+
+func (r *repository) RawStats(dimension string, from, to time.Time, skipDays []time.Time) ([]DailyStat, error) {
+	query := r.db.Model(&Visit{}).
+		Select("created_at::date AS day, ? AS dimension, "+dimensionColumns[dimension]+" AS value, COUNT(*) AS visits, COUNT(DISTINCT ip) AS unique_visitors", dimension).
+		Where("created_at >= ? AND created_at < ?", from, to)
+	// ✅ An empty NOT IN list would match nothing
+	if len(skipDays) > 0 {
+		query = query.Where("created_at::date NOT IN ?", skipDays)
+	}
+
+	var stats []DailyStat
+	err := query.Group("1, 3").Scan(&stats).Error
+	return stats, err
+}
+
+func (r *repository) FindUnanonymized(before time.Time, limit int) ([]Visit, error) {
+	var visits []Visit
+	err := r.db.Select("id", "ip").
+		Where("created_at < ? AND NOT ip_anonymized", before).
+		Order("id").
+		Limit(limit).
+		Find(&visits).Error
+	return visits, err
+}
+
+func (r *repository) SetAnonymizedIP(ids []uint, ip string) error {
+	return r.db.Model(&Visit{}).Where("id IN ?", ids).
+		Updates(map[string]interface{}{"ip": ip, "ip_anonymized": true}).Error
+}
+
+// DeleteRolledUpBefore deletes raw visits older than before, keeping days
+// that are not in the rollup yet so no data is lost.
+func (r *repository) DeleteRolledUpBefore(before time.Time) (int64, error) {
+	result := r.db.
+		Where("created_at < ? AND created_at::date IN (SELECT day FROM visitor_rollup_days)", before).
+		Delete(&Visit{})
+	return result.RowsAffected, result.Error
+}
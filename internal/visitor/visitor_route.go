@@ -0,0 +1,17 @@
+package visitor
+
+import (
+	"ecommerce/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupVisitorRoutes(router *gin.Engine, controller *Controller) {
+	router.GET("/api/v1/visitor-division", controller.VisitorDivision)
+
+	admin := router.Group("/api/v1/admin/analytics")
+	admin.Use(auth.JWTAuthMiddleware(), auth.RequireRole(auth.RoleAdmin))
+	{
+		admin.GET("/visitors", controller.VisitorReport)
+	}
+}
@@ -0,0 +1,246 @@
+package visitor
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"time"
+)
+
+const (
+	defaultReportDays  = 30
+	maxReportDays      = 366
+	defaultReportLimit = 100
+	maxReportLimit     = 1000
+
+	// rollupDelay leaves time for the tracker to flush a day's last visits
+	// before the day is rolled up.
+	rollupDelay = time.Hour
+)
+
+var ErrInvalidRange = errors.New("invalid date range")
+
+type Service interface {
+	Report(query ReportQuery) (*Report, error)
+	RollUp() (int, error)
+	ApplyRetention(policy RetentionPolicy) (*RetentionResult, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+// StartRollups rolls up finished days every interval, starting now.
+func StartRollups(service Service, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if days, err := service.RollUp(); err != nil {
+				log.Printf("⚠️ Failed to roll up visitor stats: %v", err)
+			} else if days > 0 {
+				log.Printf("📊 Rolled up visitor stats for %d days", days)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// RollUp aggregates every finished day that has not been rolled up yet and
+// returns how many days it did.
+func (s *service) RollUp() (int, error) {
+	days, err := s.repo.PendingRollupDays(startOfDay(time.Now().Add(-rollupDelay)))
+	if err != nil {
+		return 0, err
+	}
+	for i, day := range days {
+		if err := s.repo.RollUpDay(day); err != nil {
+			return i, err
+		}
+	}
+	return len(days), nil
+}
+
+// ApplyRetention rolls up finished days first, so the reports keep their
+// unique visitor counts, then anonymizes and deletes old rows.
+func (s *service) ApplyRetention(policy RetentionPolicy) (*RetentionResult, error) {
+	result := &RetentionResult{}
+
+	days, err := s.RollUp()
+	result.RolledUpDays = days
+	if err != nil {
+		return result, err
+	}
+
+	now := time.Now()
+	if policy.AnonymizeAfter > 0 {
+		for {
+			visits, err := s.repo.FindUnanonymized(now.Add(-policy.AnonymizeAfter), retentionBatchSize)
+			if err != nil {
+				return result, err
+			}
+			if len(visits) == 0 {
+				break
+			}
+
+			// One update per network rather than per row
+			networks := make(map[string][]uint)
+			for _, visit := range visits {
+				network := AnonymizeIP(visit.IP)
+				networks[network] = append(networks[network], visit.ID)
+			}
+			for network, ids := range networks {
+				if err := s.repo.SetAnonymizedIP(ids, network); err != nil {
+					return result, err
+				}
+			}
+			result.Anonymized += int64(len(visits))
+		}
+	}
+
+	if policy.DeleteAfter > 0 {
+		deleted, err := s.repo.DeleteRolledUpBefore(now.Add(-policy.DeleteAfter))
+		result.Deleted = deleted
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// Report reads rolled up days from the daily stats and any others, such as
+// today, straight from visitor_logs.
+func (s *service) Report(query ReportQuery) (*Report, error) {
+	from, to, err := reportRange(query.From, query.To)
+	if err != nil {
+		return nil, err
+	}
+	end := to.AddDate(0, 0, 1)
+
+	rolledUp, err := s.repo.RolledUpDays(from, end)
+	if err != nil {
+		return nil, err
+	}
+
+	dimension := query.GroupBy
+	switch query.GroupBy {
+	case GroupByDay:
+		dimension = dimensionTotal
+	case GroupByHour:
+		dimension = dimensionHour
+	}
+	stats, err := s.stats(dimension, from, end, rolledUp)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := s.stats(dimensionTotal, from, end, rolledUp)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		GroupBy: query.GroupBy,
+		From:    from.Format("2006-01-02"),
+		To:      to.Format("2006-01-02"),
+		Rows:    groupStats(query.GroupBy, stats),
+	}
+	for _, stat := range totals {
+		report.Totals.Visits += stat.Visits
+		report.Totals.UniqueVisitors += stat.UniqueVisitors
+	}
+
+	if query.GroupBy != GroupByDay && query.GroupBy != GroupByHour {
+		limit := query.Limit
+		if limit < 1 {
+			limit = defaultReportLimit
+		}
+		if limit > maxReportLimit {
+			limit = maxReportLimit
+		}
+		if len(report.Rows) > limit {
+			report.Rows = report.Rows[:limit]
+		}
+	}
+	return report, nil
+}
+
+func (s *service) stats(dimension string, from, end time.Time, rolledUp []time.Time) ([]DailyStat, error) {
+	stats, err := s.repo.DailyStats(dimension, from, end)
+	if err != nil {
+		return nil, err
+	}
+	if len(rolledUp) == int(end.Sub(from).Hours()/24) {
+		return stats, nil
+	}
+	raw, err := s.repo.RawStats(dimension, from, end, rolledUp)
+	if err != nil {
+		return nil, err
+	}
+	return append(stats, raw...), nil
+}
+
+// groupStats sums per-day stats into report rows. Time groupings are sorted
+// chronologically, the rest by visits.
+func groupStats(groupBy string, stats []DailyStat) []ReportRow {
+	index := make(map[string]int)
+	rows := make([]ReportRow, 0)
+	for _, stat := range stats {
+		key := stat.Value
+		switch groupBy {
+		case GroupByDay:
+			key = stat.Day.Format("2006-01-02")
+		case GroupByHour:
+			key = stat.Day.Format("2006-01-02") + "T" + stat.Value + ":00"
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(rows)
+			index[key] = i
+			rows = append(rows, ReportRow{Key: key})
+		}
+		rows[i].Visits += stat.Visits
+		rows[i].UniqueVisitors += stat.UniqueVisitors
+	}
+
+	if groupBy == GroupByDay || groupBy == GroupByHour {
+		sort.Slice(rows, func(i, j int) bool { return rows[i].Key < rows[j].Key })
+	} else {
+		sort.Slice(rows, func(i, j int) bool {
+			if rows[i].Visits != rows[j].Visits {
+				return rows[i].Visits > rows[j].Visits
+			}
+			return rows[i].Key < rows[j].Key
+		})
+	}
+	return rows
+}
+
+// reportRange applies the defaults to an inclusive date range and checks it.
+func reportRange(from, to time.Time) (time.Time, time.Time, error) {
+	if to.IsZero() {
+		to = startOfDay(time.Now())
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -(defaultReportDays - 1))
+	}
+	from, to = startOfDay(from), startOfDay(to)
+
+	if from.After(to) {
+		return from, to, ErrInvalidRange
+	}
+	if to.Sub(from) >= maxReportDays*24*time.Hour {
+		return from, to, ErrInvalidRange
+	}
+	return from, to, nil
+}
+
+// startOfDay is midnight in t's time zone. visitor_logs stores the server's
+// local time, so days are the server's days.
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,188 @@
+package visitor
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// fakeReportRepo serves rolled up stats from daily and the rest from raw.
+type fakeReportRepo struct {
+	Repository
+	rolledUp []time.Time
+	daily    map[string][]DailyStat
+	raw      map[string][]DailyStat
+
+	rawCalls   int
+	pending    []time.Time
+	rolledDays []time.Time
+}
+
+func (r *fakeReportRepo) RolledUpDays(from, to time.Time) ([]time.Time, error) {
+	return r.rolledUp, nil
+}
+
+func (r *fakeReportRepo) DailyStats(dimension string, from, to time.Time) ([]DailyStat, error) {
+	return r.daily[dimension], nil
+}
+
+func (r *fakeReportRepo) RawStats(dimension string, from, to time.Time, skipDays []time.Time) ([]DailyStat, error) {
+	r.rawCalls++
+	return r.raw[dimension], nil
+}
+
+func (r *fakeReportRepo) PendingRollupDays(before time.Time) ([]time.Time, error) {
+	return r.pending, nil
+}
+
+func (r *fakeReportRepo) RollUpDay(day time.Time) error {
+	r.rolledDays = append(r.rolledDays, day)
+	return nil
+}
+
+func date(s string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestReportRange(t *testing.T) {
+	from, to, err := reportRange(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !to.Equal(startOfDay(time.Now())) || to.Sub(from) != (defaultReportDays-1)*24*time.Hour {
+		t.Errorf("default range = %v to %v, want the last %d days", from, to, defaultReportDays)
+	}
+
+	if _, _, err := reportRange(date("2026-03-02"), date("2026-03-01")); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("from after to: got %v, want ErrInvalidRange", err)
+	}
+	if _, _, err := reportRange(date("2025-01-01"), date("2026-03-01")); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("over a year: got %v, want ErrInvalidRange", err)
+	}
+	if _, _, err := reportRange(date("2026-03-01"), date("2026-03-01")); err != nil {
+		t.Errorf("single day: %v", err)
+	}
+}
+
+func TestReportCombinesRolledUpAndRawDays(t *testing.T) {
+	repo := &fakeReportRepo{
+		rolledUp: []time.Time{date("2026-03-01")},
+		daily: map[string][]DailyStat{
+			dimensionCity:  {{Day: date("2026-03-01"), Value: "Dhaka", Visits: 5, UniqueVisitors: 4}},
+			dimensionTotal: {{Day: date("2026-03-01"), Visits: 6, UniqueVisitors: 5}},
+		},
+		raw: map[string][]DailyStat{
+			dimensionCity: {
+				{Day: date("2026-03-02"), Value: "Dhaka", Visits: 1, UniqueVisitors: 1},
+				{Day: date("2026-03-02"), Value: "Sylhet", Visits: 9, UniqueVisitors: 2},
+			},
+			dimensionTotal: {{Day: date("2026-03-02"), Visits: 10, UniqueVisitors: 3}},
+		},
+	}
+	s := NewService(repo)
+
+	report, err := s.Report(ReportQuery{GroupBy: GroupByCity, From: date("2026-03-01"), To: date("2026-03-02"), Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Totals.Visits != 16 || report.Totals.UniqueVisitors != 8 {
+		t.Errorf("totals = %+v, want 16 visits, 8 unique", report.Totals)
+	}
+	// Sylhet has the most visits; the limit cuts Dhaka
+	if len(report.Rows) != 1 || report.Rows[0].Key != "Sylhet" || report.Rows[0].Visits != 9 {
+		t.Errorf("rows = %+v", report.Rows)
+	}
+
+	// With every day rolled up, visitor_logs is not read
+	repo.rawCalls = 0
+	if _, err := s.Report(ReportQuery{GroupBy: GroupByDay, From: date("2026-03-01"), To: date("2026-03-01")}); err != nil {
+		t.Fatal(err)
+	}
+	if repo.rawCalls != 0 {
+		t.Errorf("read raw visits %d times for a rolled up range", repo.rawCalls)
+	}
+}
+
+func TestGroupStatsByTime(t *testing.T) {
+	stats := []DailyStat{
+		{Day: date("2026-03-02"), Value: "09", Visits: 1},
+		{Day: date("2026-03-01"), Value: "23", Visits: 4},
+		{Day: date("2026-03-02"), Value: "09", Visits: 2},
+	}
+	rows := groupStats(GroupByHour, stats)
+	if len(rows) != 2 || rows[0].Key != "2026-03-01T23:00" || rows[1].Key != "2026-03-02T09:00" || rows[1].Visits != 3 {
+		t.Errorf("rows = %+v, want hours in order with repeats summed", rows)
+	}
+
+	rows = groupStats(GroupByDay, stats)
+	if len(rows) != 2 || rows[0].Key != "2026-03-01" || rows[1].Visits != 3 {
+		t.Errorf("rows = %+v", rows)
+	}
+}
+
+func TestRollUp(t *testing.T) {
+	repo := &fakeReportRepo{pending: []time.Time{date("2026-03-01"), date("2026-03-02")}}
+	days, err := NewService(repo).RollUp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if days != 2 || len(repo.rolledDays) != 2 {
+		t.Errorf("rolled up %d days, %v; want both pending days", days, repo.rolledDays)
+	}
+}
+
+func getReport(t *testing.T, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	repo := &fakeReportRepo{
+		raw: map[string][]DailyStat{
+			dimensionCountry: {{Day: date("2026-03-01"), Value: "Bangladesh", Visits: 3, UniqueVisitors: 2}},
+			dimensionTotal:   {{Day: date("2026-03-01"), Visits: 3, UniqueVisitors: 2}},
+		},
+	}
+	router := gin.New()
+	router.GET("/", NewController(nil, NewService(repo)).VisitorReport)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?"+query, nil))
+	return w
+}
+
+func TestVisitorReportCSV(t *testing.T) {
+	w := getReport(t, "group_by=country&from=2026-03-01&to=2026-03-01&format=csv")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	want := "country,visits,unique_visitors\nBangladesh,3,2\n"
+	if w.Body.String() != want {
+		t.Errorf("body = %q, want %q", w.Body, want)
+	}
+	if got := w.Header().Get("Content-Disposition"); !strings.Contains(got, "visitors-country-2026-03-01-2026-03-01.csv") {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+}
+
+func TestVisitorReportRejectsBadQueries(t *testing.T) {
+	for _, query := range []string{
+		"",
+		"group_by=ip",
+		"group_by=city&from=2026-03-02&to=2026-03-01",
+		"group_by=city&from=yesterday",
+		"group_by=city&format=xml",
+	} {
+		if w := getReport(t, query); w.Code != http.StatusBadRequest {
+			t.Errorf("%q: status %d, want 400", query, w.Code)
+		}
+	}
+}
@@ -3,13 +3,20 @@ package main
 import (
 	"ecommerce/config"
 	"ecommerce/database"
+	"ecommerce/internal/account"
+	"ecommerce/internal/audit"
 	"ecommerce/internal/auth"
 	"ecommerce/internal/cart"
 	"ecommerce/internal/catalog"
+	"ecommerce/internal/customer"
+	"ecommerce/internal/location"
+	"ecommerce/internal/phone"
+	"ecommerce/internal/visitor"
 
 	//"ecommerce/internal/health"
 	"ecommerce/internal/order"
 	"log"
+	"os"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -21,34 +28,81 @@ func main() {
 	if err != nil {
 		log.Fatalf("Error connecting to database: %v", err)
 	}
+	if len(os.Args) > 1 {
+		runCommand(db, os.Args[1])
+		return
+	}
 	gin.SetMode(gin.ReleaseMode)
-	config.InitGoogleAuth()
+	if err := phone.RegisterValidator(); err != nil {
+		log.Fatalf("Error registering phone validator: %v", err)
+	}
+	config.InitOAuthProviders()
+	if err := auth.LoadJWTKeys(); err != nil {
+		log.Fatalf("Error loading JWT keys: %v", err)
+	}
+
+	// Token revocation survives restarts and is shared between replicas
+	auth.SetTokenStore(auth.NewPostgresTokenStore(db))
+	auth.StartTokenCleanup(time.Hour)
 
 	// Initialize repositories
+	auditRepo := audit.NewRepository(db)
+	accountRepo := account.NewAccountRepository(db)
+	locationRepo := location.NewLocationRepository(db)
 	userRepo := auth.NewUserRepository(db)
+	auth.SetUserRepository(userRepo)
 	productRepo := catalog.NewProductRepository(db)
 	cartRepo := cart.NewCartRepository(db)
 	orderRepo := order.NewOrderRepository(db)
+	customerRepo := customer.NewCustomerRepository(db)
+	apiKeyRepo := auth.NewAPIKeyRepository(db)
+	auth.SetAPIKeyRepository(apiKeyRepo)
+
+	// ✅ Visitors are located from a local GeoIP database and saved in the
+	// background, so tracking adds no latency to requests
+	geoIP := visitor.OpenGeoIP(os.Getenv("GEOIP_DB_PATH"))
+	visitorRepo := visitor.NewRepository(db)
+	visitorTracker := visitor.NewTracker(visitorRepo, geoIP, visitor.ConfigFromEnv())
+	visitorTracker.Start()
 
 	// Initialize services
-	userService := auth.NewUserService(userRepo)
-	productService := catalog.NewProductService(productRepo)
+	auditService := audit.NewService(auditRepo)
+	auth.SetAuditService(auditService)
+	locationService := location.NewLocationService(locationRepo)
+	userService := auth.NewUserService(userRepo, auditService, locationService)
+	productService := catalog.NewProductService(productRepo, auditService)
 	cartService := cart.NewCartService(cartRepo, productRepo)
-	orderService := order.NewOrderService(orderRepo, cartService) // No db parameter
+	orderService := order.NewOrderService(orderRepo, cartService, userRepo, auditService) // No db parameter
+	apiKeyService := auth.NewAPIKeyService(apiKeyRepo, auditService)
+	accountService := account.NewAccountService(accountRepo, userService, auditService)
+	customerService := customer.NewCustomerService(customerRepo)
+	visitorService := visitor.NewService(visitorRepo)
+	visitor.StartRollups(visitorService, time.Hour)
+	visitor.StartRetention(visitorService, visitor.RetentionFromEnv(), 24*time.Hour)
 
 	// Initialize controllers
-	userController := auth.NewUserController(userService)
+	userController := auth.NewUserController(userService, auth.NewIdentityProviders())
 	productController := catalog.NewProductController(productService)
 	cartController := cart.NewCartController(cartService)
 	orderController := order.NewOrderController(orderService)
+	auditController := audit.NewController(auditService)
+	accountController := account.NewAccountController(accountService)
+	customerController := customer.NewCustomerController(customerService)
+	locationController := location.NewLocationController(locationService)
+	apiKeyController := auth.NewAPIKeyController(apiKeyService)
+	visitorController := visitor.NewController(geoIP, visitorService)
 
 	// Setup router and routes
 	router := gin.Default()
+	if err := config.ConfigureTrustedProxies(router); err != nil {
+		log.Fatalf("Error configuring trusted proxies: %v", err)
+	}
 
 
 	// Add request logging
 	router.Use(gin.Logger())
-	//router.Use(auth.LocationTrackingMiddleware(db))
+	router.Use(audit.RequestIDMiddleware())
+	router.Use(visitor.Middleware(visitorTracker))
 	router.Static("/uploads", "./uploads")
 
 	// Configure CORS
@@ -61,8 +115,8 @@ func main() {
         "http://localhost:3001",          // 🔥 Alternative local port
     },
         AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-        AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-        ExposeHeaders:    []string{"Content-Length"},
+        AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Cart-Token", "X-API-Key", "X-Request-ID"},
+        ExposeHeaders:    []string{"Content-Length", "X-Cart-Token", "X-Request-ID"},
         AllowCredentials: true,
         MaxAge:          12 * time.Hour,
     }))
@@ -75,11 +129,16 @@ func main() {
 	})
 
 	auth.SetupAuthRoutes(router, userController)
+	auth.SetupAPIKeyRoutes(router, apiKeyController)
 	catalog.SetupCatalogRoutes(router, productController)
 	cart.SetupCartRoutes(router, cartController)
 	order.SetupOrderRoutes(router, orderController)
+	account.SetupAccountRoutes(router, accountController)
+	customer.SetupCustomerRoutes(router, customerController)
+	location.SetupLocationRoutes(router, locationController, auth.JWTAuthMiddleware(), auth.RequireRole(auth.RoleAdmin))
+	audit.SetupAuditRoutes(router, auditController, auth.JWTAuthMiddleware(), auth.RequireRole(auth.RoleAdmin))
 
-	//router.GET("/api/v1/visitor-division", health.VisitorDivision)
+	visitor.SetupVisitorRoutes(router, visitorController)
 
 	// Start server - bind to all interfaces
 	log.Println("Starting server on 0.0.0.0:8080")
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'customer';
+
+CREATE INDEX idx_users_role ON users(role);
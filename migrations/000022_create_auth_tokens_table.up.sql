@@ -0,0 +1,10 @@
+CREATE TABLE auth_tokens (
+    token_id VARCHAR(255) PRIMARY KEY,
+    user_id INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_auth_tokens_user_id ON auth_tokens(user_id);
+CREATE INDEX idx_auth_tokens_expires_at ON auth_tokens(expires_at);
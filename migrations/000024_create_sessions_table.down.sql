@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_auth_tokens_session_id;
+ALTER TABLE auth_tokens DROP COLUMN IF EXISTS session_id;
+
+DROP TABLE IF EXISTS sessions;
@@ -0,0 +1,16 @@
+CREATE TABLE sessions (
+    id VARCHAR(64) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    device VARCHAR(100),
+    user_agent TEXT,
+    ip VARCHAR(45),
+    last_seen_at TIMESTAMP DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_sessions_user_id ON sessions(user_id);
+
+ALTER TABLE auth_tokens ADD COLUMN session_id VARCHAR(64);
+CREATE INDEX idx_auth_tokens_session_id ON auth_tokens(session_id);
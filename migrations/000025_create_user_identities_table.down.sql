@@ -0,0 +1,5 @@
+UPDATE users SET google_id = '' WHERE google_id IS NULL;
+ALTER TABLE users ALTER COLUMN google_id DROP DEFAULT;
+ALTER TABLE users ALTER COLUMN google_id SET NOT NULL;
+
+DROP TABLE IF EXISTS user_identities;
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_users_email;
+ALTER TABLE users ALTER COLUMN email DROP DEFAULT;
+CREATE UNIQUE INDEX idx_users_email ON users(email);
+
+DROP INDEX IF EXISTS idx_users_verified_phone;
+ALTER TABLE users DROP COLUMN IF EXISTS phone_verified;
+
+DROP TABLE IF EXISTS phone_otps;
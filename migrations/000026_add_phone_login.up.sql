@@ -0,0 +1,20 @@
+CREATE TABLE phone_otps (
+    id SERIAL PRIMARY KEY,
+    phone VARCHAR(20) NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    consumed_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_phone_otps_phone_created_at ON phone_otps(phone, created_at);
+
+ALTER TABLE users ADD COLUMN phone_verified BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE UNIQUE INDEX idx_users_verified_phone ON users(phone) WHERE phone_verified;
+
+-- Phone-only accounts have no email, so uniqueness only applies to real addresses
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+DROP INDEX IF EXISTS idx_users_email;
+ALTER TABLE users ALTER COLUMN email SET DEFAULT '';
+CREATE UNIQUE INDEX idx_users_email ON users(email) WHERE email <> '';
@@ -0,0 +1,8 @@
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified;
+
+DROP INDEX IF EXISTS idx_orders_guest_email;
+DROP INDEX IF EXISTS idx_orders_guest_phone;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS customer_email;
+DELETE FROM orders WHERE user_id IS NULL;
+ALTER TABLE orders ALTER COLUMN user_id SET NOT NULL;
@@ -0,0 +1,11 @@
+-- Guest orders have no user until the customer claims them
+ALTER TABLE orders ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE orders ADD COLUMN customer_email VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_orders_guest_phone ON orders(customer_phone) WHERE user_id IS NULL;
+CREATE INDEX idx_orders_guest_email ON orders(LOWER(customer_email)) WHERE user_id IS NULL AND customer_email <> '';
+
+-- Claiming guest orders by email requires a verified address
+ALTER TABLE users ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE users SET email_verified = TRUE
+WHERE id IN (SELECT user_id FROM user_identities WHERE provider = 'google');
@@ -0,0 +1,9 @@
+ALTER TABLE sessions DROP COLUMN IF EXISTS mfa_at;
+
+DROP TABLE IF EXISTS mfa_recovery_codes;
+
+ALTER TABLE users DROP COLUMN IF EXISTS mfa_locked_until;
+ALTER TABLE users DROP COLUMN IF EXISTS mfa_failed_attempts;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_last_step;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_enabled;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_secret;
@@ -0,0 +1,13 @@
+CREATE TABLE api_keys (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) NOT NULL UNIQUE,
+    key_hash VARCHAR(64) NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_by INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    last_used_at TIMESTAMP NULL,
+    last_used_ip VARCHAR(45) NOT NULL DEFAULT '',
+    expires_at TIMESTAMP NULL,
+    revoked_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
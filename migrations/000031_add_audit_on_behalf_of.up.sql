@@ -0,0 +1,3 @@
+ALTER TABLE audit_events ADD COLUMN on_behalf_of INTEGER NULL;
+
+CREATE INDEX idx_audit_events_on_behalf_of ON audit_events(on_behalf_of) WHERE on_behalf_of IS NOT NULL;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS areas;
+DROP TABLE IF EXISTS upazilas;
+DROP TABLE IF EXISTS districts;
+DROP TABLE IF EXISTS divisions;
@@ -0,0 +1,720 @@
+-- Bangladesh administrative areas used to validate addresses: 8 divisions,
+-- 64 districts, their upazilas and metropolitan thanas (is_metro), and
+-- delivery areas with postcodes. delivery_zone is the courier pricing band.
+CREATE TABLE divisions (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(64) NOT NULL UNIQUE
+);
+
+CREATE TABLE districts (
+    id SERIAL PRIMARY KEY,
+    division_id INTEGER NOT NULL REFERENCES divisions(id),
+    name VARCHAR(64) NOT NULL,
+    UNIQUE (division_id, name)
+);
+
+CREATE TABLE upazilas (
+    id SERIAL PRIMARY KEY,
+    district_id INTEGER NOT NULL REFERENCES districts(id),
+    name VARCHAR(64) NOT NULL,
+    is_metro BOOLEAN NOT NULL DEFAULT FALSE,
+    delivery_zone VARCHAR(20) NOT NULL DEFAULT 'outside_dhaka',
+    UNIQUE (district_id, name, is_metro)
+);
+
+CREATE TABLE areas (
+    id SERIAL PRIMARY KEY,
+    upazila_id INTEGER NOT NULL REFERENCES upazilas(id),
+    name VARCHAR(100) NOT NULL,
+    postcode VARCHAR(4) NOT NULL DEFAULT '',
+    UNIQUE (upazila_id, name)
+);
+
+CREATE INDEX idx_districts_division_id ON districts(division_id);
+CREATE INDEX idx_upazilas_district_id ON upazilas(district_id);
+CREATE INDEX idx_areas_upazila_id ON areas(upazila_id);
+
+INSERT INTO divisions (id, name) VALUES
+    (1, 'Barishal'),
+    (2, 'Chattogram'),
+    (3, 'Dhaka'),
+    (4, 'Khulna'),
+    (5, 'Mymensingh'),
+    (6, 'Rajshahi'),
+    (7, 'Rangpur'),
+    (8, 'Sylhet');
+
+INSERT INTO districts (id, division_id, name) VALUES
+    (1, 1, 'Barguna'),
+    (2, 1, 'Barishal'),
+    (3, 1, 'Bhola'),
+    (4, 1, 'Jhalokati'),
+    (5, 1, 'Patuakhali'),
+    (6, 1, 'Pirojpur'),
+    (7, 2, 'Bandarban'),
+    (8, 2, 'Brahmanbaria'),
+    (9, 2, 'Chandpur'),
+    (10, 2, 'Chattogram'),
+    (11, 2, 'Cox''s Bazar'),
+    (12, 2, 'Cumilla'),
+    (13, 2, 'Feni'),
+    (14, 2, 'Khagrachhari'),
+    (15, 2, 'Lakshmipur'),
+    (16, 2, 'Noakhali'),
+    (17, 2, 'Rangamati'),
+    (18, 3, 'Dhaka'),
+    (19, 3, 'Faridpur'),
+    (20, 3, 'Gazipur'),
+    (21, 3, 'Gopalganj'),
+    (22, 3, 'Kishoreganj'),
+    (23, 3, 'Madaripur'),
+    (24, 3, 'Manikganj'),
+    (25, 3, 'Munshiganj'),
+    (26, 3, 'Narayanganj'),
+    (27, 3, 'Narsingdi'),
+    (28, 3, 'Rajbari'),
+    (29, 3, 'Shariatpur'),
+    (30, 3, 'Tangail'),
+    (31, 4, 'Bagerhat'),
+    (32, 4, 'Chuadanga'),
+    (33, 4, 'Jashore'),
+    (34, 4, 'Jhenaidah'),
+    (35, 4, 'Khulna'),
+    (36, 4, 'Kushtia'),
+    (37, 4, 'Magura'),
+    (38, 4, 'Meherpur'),
+    (39, 4, 'Narail'),
+    (40, 4, 'Satkhira'),
+    (41, 5, 'Jamalpur'),
+    (42, 5, 'Mymensingh'),
+    (43, 5, 'Netrokona'),
+    (44, 5, 'Sherpur'),
+    (45, 6, 'Bogura'),
+    (46, 6, 'Chapainawabganj'),
+    (47, 6, 'Joypurhat'),
+    (48, 6, 'Naogaon'),
+    (49, 6, 'Natore'),
+    (50, 6, 'Pabna'),
+    (51, 6, 'Rajshahi'),
+    (52, 6, 'Sirajganj'),
+    (53, 7, 'Dinajpur'),
+    (54, 7, 'Gaibandha'),
+    (55, 7, 'Kurigram'),
+    (56, 7, 'Lalmonirhat'),
+    (57, 7, 'Nilphamari'),
+    (58, 7, 'Panchagarh'),
+    (59, 7, 'Rangpur'),
+    (60, 7, 'Thakurgaon'),
+    (61, 8, 'Habiganj'),
+    (62, 8, 'Moulvibazar'),
+    (63, 8, 'Sunamganj'),
+    (64, 8, 'Sylhet');
+
+INSERT INTO upazilas (id, district_id, name, is_metro, delivery_zone) VALUES
+    (1, 1, 'Amtali', FALSE, 'outside_dhaka'),
+    (2, 1, 'Bamna', FALSE, 'outside_dhaka'),
+    (3, 1, 'Barguna Sadar', FALSE, 'outside_dhaka'),
+    (4, 1, 'Betagi', FALSE, 'outside_dhaka'),
+    (5, 1, 'Patharghata', FALSE, 'outside_dhaka'),
+    (6, 1, 'Taltali', FALSE, 'outside_dhaka'),
+    (7, 2, 'Agailjhara', FALSE, 'outside_dhaka'),
+    (8, 2, 'Babuganj', FALSE, 'outside_dhaka'),
+    (9, 2, 'Bakerganj', FALSE, 'outside_dhaka'),
+    (10, 2, 'Banaripara', FALSE, 'outside_dhaka'),
+    (11, 2, 'Barishal Sadar', FALSE, 'outside_dhaka'),
+    (12, 2, 'Gaurnadi', FALSE, 'outside_dhaka'),
+    (13, 2, 'Hizla', FALSE, 'outside_dhaka'),
+    (14, 2, 'Mehendiganj', FALSE, 'outside_dhaka'),
+    (15, 2, 'Muladi', FALSE, 'outside_dhaka'),
+    (16, 2, 'Wazirpur', FALSE, 'outside_dhaka'),
+    (17, 3, 'Bhola Sadar', FALSE, 'outside_dhaka'),
+    (18, 3, 'Burhanuddin', FALSE, 'outside_dhaka'),
+    (19, 3, 'Char Fasson', FALSE, 'outside_dhaka'),
+    (20, 3, 'Daulatkhan', FALSE, 'outside_dhaka'),
+    (21, 3, 'Lalmohan', FALSE, 'outside_dhaka'),
+    (22, 3, 'Manpura', FALSE, 'outside_dhaka'),
+    (23, 3, 'Tazumuddin', FALSE, 'outside_dhaka'),
+    (24, 4, 'Jhalokati Sadar', FALSE, 'outside_dhaka'),
+    (25, 4, 'Kathalia', FALSE, 'outside_dhaka'),
+    (26, 4, 'Nalchity', FALSE, 'outside_dhaka'),
+    (27, 4, 'Rajapur', FALSE, 'outside_dhaka'),
+    (28, 5, 'Bauphal', FALSE, 'outside_dhaka'),
+    (29, 5, 'Dashmina', FALSE, 'outside_dhaka'),
+    (30, 5, 'Dumki', FALSE, 'outside_dhaka'),
+    (31, 5, 'Galachipa', FALSE, 'outside_dhaka'),
+    (32, 5, 'Kalapara', FALSE, 'outside_dhaka'),
+    (33, 5, 'Mirzaganj', FALSE, 'outside_dhaka'),
+    (34, 5, 'Patuakhali Sadar', FALSE, 'outside_dhaka'),
+    (35, 5, 'Rangabali', FALSE, 'outside_dhaka'),
+    (36, 6, 'Bhandaria', FALSE, 'outside_dhaka'),
+    (37, 6, 'Indurkani', FALSE, 'outside_dhaka'),
+    (38, 6, 'Kawkhali', FALSE, 'outside_dhaka'),
+    (39, 6, 'Mathbaria', FALSE, 'outside_dhaka'),
+    (40, 6, 'Nazirpur', FALSE, 'outside_dhaka'),
+    (41, 6, 'Nesarabad', FALSE, 'outside_dhaka'),
+    (42, 6, 'Pirojpur Sadar', FALSE, 'outside_dhaka'),
+    (43, 7, 'Alikadam', FALSE, 'outside_dhaka'),
+    (44, 7, 'Bandarban Sadar', FALSE, 'outside_dhaka'),
+    (45, 7, 'Lama', FALSE, 'outside_dhaka'),
+    (46, 7, 'Naikhongchhari', FALSE, 'outside_dhaka'),
+    (47, 7, 'Rowangchhari', FALSE, 'outside_dhaka'),
+    (48, 7, 'Ruma', FALSE, 'outside_dhaka'),
+    (49, 7, 'Thanchi', FALSE, 'outside_dhaka'),
+    (50, 8, 'Akhaura', FALSE, 'outside_dhaka'),
+    (51, 8, 'Ashuganj', FALSE, 'outside_dhaka'),
+    (52, 8, 'Bancharampur', FALSE, 'outside_dhaka'),
+    (53, 8, 'Bijoynagar', FALSE, 'outside_dhaka'),
+    (54, 8, 'Brahmanbaria Sadar', FALSE, 'outside_dhaka'),
+    (55, 8, 'Kasba', FALSE, 'outside_dhaka'),
+    (56, 8, 'Nabinagar', FALSE, 'outside_dhaka'),
+    (57, 8, 'Nasirnagar', FALSE, 'outside_dhaka'),
+    (58, 8, 'Sarail', FALSE, 'outside_dhaka'),
+    (59, 9, 'Chandpur Sadar', FALSE, 'outside_dhaka'),
+    (60, 9, 'Faridganj', FALSE, 'outside_dhaka'),
+    (61, 9, 'Haimchar', FALSE, 'outside_dhaka'),
+    (62, 9, 'Haziganj', FALSE, 'outside_dhaka'),
+    (63, 9, 'Kachua', FALSE, 'outside_dhaka'),
+    (64, 9, 'Matlab Dakshin', FALSE, 'outside_dhaka'),
+    (65, 9, 'Matlab Uttar', FALSE, 'outside_dhaka'),
+    (66, 9, 'Shahrasti', FALSE, 'outside_dhaka'),
+    (67, 10, 'Anwara', FALSE, 'outside_dhaka'),
+    (68, 10, 'Banshkhali', FALSE, 'outside_dhaka'),
+    (69, 10, 'Boalkhali', FALSE, 'outside_dhaka'),
+    (70, 10, 'Chandanaish', FALSE, 'outside_dhaka'),
+    (71, 10, 'Fatikchhari', FALSE, 'outside_dhaka'),
+    (72, 10, 'Hathazari', FALSE, 'outside_dhaka'),
+    (73, 10, 'Karnaphuli', FALSE, 'outside_dhaka'),
+    (74, 10, 'Lohagara', FALSE, 'outside_dhaka'),
+    (75, 10, 'Mirsharai', FALSE, 'outside_dhaka'),
+    (76, 10, 'Patiya', FALSE, 'outside_dhaka'),
+    (77, 10, 'Rangunia', FALSE, 'outside_dhaka'),
+    (78, 10, 'Raozan', FALSE, 'outside_dhaka'),
+    (79, 10, 'Sandwip', FALSE, 'outside_dhaka'),
+    (80, 10, 'Satkania', FALSE, 'outside_dhaka'),
+    (81, 10, 'Sitakunda', FALSE, 'outside_dhaka'),
+    (82, 10, 'Akbar Shah', TRUE, 'outside_dhaka'),
+    (83, 10, 'Bakalia', TRUE, 'outside_dhaka'),
+    (84, 10, 'Bandar', TRUE, 'outside_dhaka'),
+    (85, 10, 'Bayazid Bostami', TRUE, 'outside_dhaka'),
+    (86, 10, 'Chandgaon', TRUE, 'outside_dhaka'),
+    (87, 10, 'Chawkbazar', TRUE, 'outside_dhaka'),
+    (88, 10, 'Double Mooring', TRUE, 'outside_dhaka'),
+    (89, 10, 'EPZ', TRUE, 'outside_dhaka'),
+    (90, 10, 'Halishahar', TRUE, 'outside_dhaka'),
+    (91, 10, 'Khulshi', TRUE, 'outside_dhaka'),
+    (92, 10, 'Kotwali', TRUE, 'outside_dhaka'),
+    (93, 10, 'Pahartali', TRUE, 'outside_dhaka'),
+    (94, 10, 'Panchlaish', TRUE, 'outside_dhaka'),
+    (95, 10, 'Patenga', TRUE, 'outside_dhaka'),
+    (96, 10, 'Sadarghat', TRUE, 'outside_dhaka'),
+    (97, 11, 'Chakaria', FALSE, 'outside_dhaka'),
+    (98, 11, 'Cox''s Bazar Sadar', FALSE, 'outside_dhaka'),
+    (99, 11, 'Eidgaon', FALSE, 'outside_dhaka'),
+    (100, 11, 'Kutubdia', FALSE, 'outside_dhaka'),
+    (101, 11, 'Maheshkhali', FALSE, 'outside_dhaka'),
+    (102, 11, 'Pekua', FALSE, 'outside_dhaka'),
+    (103, 11, 'Ramu', FALSE, 'outside_dhaka'),
+    (104, 11, 'Teknaf', FALSE, 'outside_dhaka'),
+    (105, 11, 'Ukhia', FALSE, 'outside_dhaka'),
+    (106, 12, 'Barura', FALSE, 'outside_dhaka'),
+    (107, 12, 'Brahmanpara', FALSE, 'outside_dhaka'),
+    (108, 12, 'Burichang', FALSE, 'outside_dhaka'),
+    (109, 12, 'Chandina', FALSE, 'outside_dhaka'),
+    (110, 12, 'Chauddagram', FALSE, 'outside_dhaka'),
+    (111, 12, 'Cumilla Adarsha Sadar', FALSE, 'outside_dhaka'),
+    (112, 12, 'Cumilla Sadar Dakshin', FALSE, 'outside_dhaka'),
+    (113, 12, 'Daudkandi', FALSE, 'outside_dhaka'),
+    (114, 12, 'Debidwar', FALSE, 'outside_dhaka'),
+    (115, 12, 'Homna', FALSE, 'outside_dhaka'),
+    (116, 12, 'Laksam', FALSE, 'outside_dhaka'),
+    (117, 12, 'Lalmai', FALSE, 'outside_dhaka'),
+    (118, 12, 'Meghna', FALSE, 'outside_dhaka'),
+    (119, 12, 'Monohargonj', FALSE, 'outside_dhaka'),
+    (120, 12, 'Muradnagar', FALSE, 'outside_dhaka'),
+    (121, 12, 'Nangalkot', FALSE, 'outside_dhaka'),
+    (122, 12, 'Titas', FALSE, 'outside_dhaka'),
+    (123, 13, 'Chhagalnaiya', FALSE, 'outside_dhaka'),
+    (124, 13, 'Daganbhuiyan', FALSE, 'outside_dhaka'),
+    (125, 13, 'Feni Sadar', FALSE, 'outside_dhaka'),
+    (126, 13, 'Fulgazi', FALSE, 'outside_dhaka'),
+    (127, 13, 'Parshuram', FALSE, 'outside_dhaka'),
+    (128, 13, 'Sonagazi', FALSE, 'outside_dhaka'),
+    (129, 14, 'Dighinala', FALSE, 'outside_dhaka'),
+    (130, 14, 'Guimara', FALSE, 'outside_dhaka'),
+    (131, 14, 'Khagrachhari Sadar', FALSE, 'outside_dhaka'),
+    (132, 14, 'Lakshmichhari', FALSE, 'outside_dhaka'),
+    (133, 14, 'Mahalchhari', FALSE, 'outside_dhaka'),
+    (134, 14, 'Manikchhari', FALSE, 'outside_dhaka'),
+    (135, 14, 'Matiranga', FALSE, 'outside_dhaka'),
+    (136, 14, 'Panchhari', FALSE, 'outside_dhaka'),
+    (137, 14, 'Ramgarh', FALSE, 'outside_dhaka'),
+    (138, 15, 'Kamalnagar', FALSE, 'outside_dhaka'),
+    (139, 15, 'Lakshmipur Sadar', FALSE, 'outside_dhaka'),
+    (140, 15, 'Raipur', FALSE, 'outside_dhaka'),
+    (141, 15, 'Ramganj', FALSE, 'outside_dhaka'),
+    (142, 15, 'Ramgati', FALSE, 'outside_dhaka'),
+    (143, 16, 'Begumganj', FALSE, 'outside_dhaka'),
+    (144, 16, 'Chatkhil', FALSE, 'outside_dhaka'),
+    (145, 16, 'Companiganj', FALSE, 'outside_dhaka'),
+    (146, 16, 'Hatiya', FALSE, 'outside_dhaka'),
+    (147, 16, 'Kabirhat', FALSE, 'outside_dhaka'),
+    (148, 16, 'Noakhali Sadar', FALSE, 'outside_dhaka'),
+    (149, 16, 'Senbagh', FALSE, 'outside_dhaka'),
+    (150, 16, 'Sonaimuri', FALSE, 'outside_dhaka'),
+    (151, 16, 'Subarnachar', FALSE, 'outside_dhaka'),
+    (152, 17, 'Baghaichhari', FALSE, 'outside_dhaka'),
+    (153, 17, 'Barkal', FALSE, 'outside_dhaka'),
+    (154, 17, 'Belaichhari', FALSE, 'outside_dhaka'),
+    (155, 17, 'Juraichhari', FALSE, 'outside_dhaka'),
+    (156, 17, 'Kaptai', FALSE, 'outside_dhaka'),
+    (157, 17, 'Kawkhali', FALSE, 'outside_dhaka'),
+    (158, 17, 'Langadu', FALSE, 'outside_dhaka'),
+    (159, 17, 'Naniarchar', FALSE, 'outside_dhaka'),
+    (160, 17, 'Rajasthali', FALSE, 'outside_dhaka'),
+    (161, 17, 'Rangamati Sadar', FALSE, 'outside_dhaka'),
+    (162, 18, 'Dhamrai', FALSE, 'dhaka_suburbs'),
+    (163, 18, 'Dohar', FALSE, 'dhaka_suburbs'),
+    (164, 18, 'Keraniganj', FALSE, 'dhaka_suburbs'),
+    (165, 18, 'Nawabganj', FALSE, 'dhaka_suburbs'),
+    (166, 18, 'Savar', FALSE, 'dhaka_suburbs'),
+    (167, 18, 'Adabor', TRUE, 'inside_dhaka'),
+    (168, 18, 'Badda', TRUE, 'inside_dhaka'),
+    (169, 18, 'Banani', TRUE, 'inside_dhaka'),
+    (170, 18, 'Bangshal', TRUE, 'inside_dhaka'),
+    (171, 18, 'Bhashantek', TRUE, 'inside_dhaka'),
+    (172, 18, 'Bhatara', TRUE, 'inside_dhaka'),
+    (173, 18, 'Bimanbandar', TRUE, 'inside_dhaka'),
+    (174, 18, 'Cantonment', TRUE, 'inside_dhaka'),
+    (175, 18, 'Chawkbazar', TRUE, 'inside_dhaka'),
+    (176, 18, 'Dakshinkhan', TRUE, 'inside_dhaka'),
+    (177, 18, 'Darus Salam', TRUE, 'inside_dhaka'),
+    (178, 18, 'Demra', TRUE, 'inside_dhaka'),
+    (179, 18, 'Dhanmondi', TRUE, 'inside_dhaka'),
+    (180, 18, 'Gendaria', TRUE, 'inside_dhaka'),
+    (181, 18, 'Gulshan', TRUE, 'inside_dhaka'),
+    (182, 18, 'Hatirjheel', TRUE, 'inside_dhaka'),
+    (183, 18, 'Hazaribagh', TRUE, 'inside_dhaka'),
+    (184, 18, 'Jatrabari', TRUE, 'inside_dhaka'),
+    (185, 18, 'Kadamtali', TRUE, 'inside_dhaka'),
+    (186, 18, 'Kafrul', TRUE, 'inside_dhaka'),
+    (187, 18, 'Kalabagan', TRUE, 'inside_dhaka'),
+    (188, 18, 'Kamrangirchar', TRUE, 'inside_dhaka'),
+    (189, 18, 'Khilgaon', TRUE, 'inside_dhaka'),
+    (190, 18, 'Khilkhet', TRUE, 'inside_dhaka'),
+    (191, 18, 'Kotwali', TRUE, 'inside_dhaka'),
+    (192, 18, 'Lalbagh', TRUE, 'inside_dhaka'),
+    (193, 18, 'Mirpur Model', TRUE, 'inside_dhaka'),
+    (194, 18, 'Mohammadpur', TRUE, 'inside_dhaka'),
+    (195, 18, 'Motijheel', TRUE, 'inside_dhaka'),
+    (196, 18, 'Mugda', TRUE, 'inside_dhaka'),
+    (197, 18, 'New Market', TRUE, 'inside_dhaka'),
+    (198, 18, 'Pallabi', TRUE, 'inside_dhaka'),
+    (199, 18, 'Paltan Model', TRUE, 'inside_dhaka'),
+    (200, 18, 'Ramna Model', TRUE, 'inside_dhaka'),
+    (201, 18, 'Rampura', TRUE, 'inside_dhaka'),
+    (202, 18, 'Rupnagar', TRUE, 'inside_dhaka'),
+    (203, 18, 'Sabujbagh', TRUE, 'inside_dhaka'),
+    (204, 18, 'Shah Ali', TRUE, 'inside_dhaka'),
+    (205, 18, 'Shahbagh', TRUE, 'inside_dhaka'),
+    (206, 18, 'Shahjahanpur', TRUE, 'inside_dhaka'),
+    (207, 18, 'Sher-e-Bangla Nagar', TRUE, 'inside_dhaka'),
+    (208, 18, 'Shyampur', TRUE, 'inside_dhaka'),
+    (209, 18, 'Sutrapur', TRUE, 'inside_dhaka'),
+    (210, 18, 'Tejgaon', TRUE, 'inside_dhaka'),
+    (211, 18, 'Tejgaon Industrial Area', TRUE, 'inside_dhaka'),
+    (212, 18, 'Turag', TRUE, 'inside_dhaka'),
+    (213, 18, 'Uttar Khan', TRUE, 'inside_dhaka'),
+    (214, 18, 'Uttara East', TRUE, 'inside_dhaka'),
+    (215, 18, 'Uttara West', TRUE, 'inside_dhaka'),
+    (216, 18, 'Vatara', TRUE, 'inside_dhaka'),
+    (217, 18, 'Wari', TRUE, 'inside_dhaka'),
+    (218, 19, 'Alfadanga', FALSE, 'outside_dhaka'),
+    (219, 19, 'Bhanga', FALSE, 'outside_dhaka'),
+    (220, 19, 'Boalmari', FALSE, 'outside_dhaka'),
+    (221, 19, 'Charbhadrasan', FALSE, 'outside_dhaka'),
+    (222, 19, 'Faridpur Sadar', FALSE, 'outside_dhaka'),
+    (223, 19, 'Madhukhali', FALSE, 'outside_dhaka'),
+    (224, 19, 'Nagarkanda', FALSE, 'outside_dhaka'),
+    (225, 19, 'Sadarpur', FALSE, 'outside_dhaka'),
+    (226, 19, 'Saltha', FALSE, 'outside_dhaka'),
+    (227, 20, 'Gazipur Sadar', FALSE, 'dhaka_suburbs'),
+    (228, 20, 'Kaliakair', FALSE, 'dhaka_suburbs'),
+    (229, 20, 'Kaliganj', FALSE, 'dhaka_suburbs'),
+    (230, 20, 'Kapasia', FALSE, 'dhaka_suburbs'),
+    (231, 20, 'Sreepur', FALSE, 'dhaka_suburbs'),
+    (232, 21, 'Gopalganj Sadar', FALSE, 'outside_dhaka'),
+    (233, 21, 'Kashiani', FALSE, 'outside_dhaka'),
+    (234, 21, 'Kotalipara', FALSE, 'outside_dhaka'),
+    (235, 21, 'Muksudpur', FALSE, 'outside_dhaka'),
+    (236, 21, 'Tungipara', FALSE, 'outside_dhaka'),
+    (237, 22, 'Austagram', FALSE, 'outside_dhaka'),
+    (238, 22, 'Bajitpur', FALSE, 'outside_dhaka'),
+    (239, 22, 'Bhairab', FALSE, 'outside_dhaka'),
+    (240, 22, 'Hossainpur', FALSE, 'outside_dhaka'),
+    (241, 22, 'Itna', FALSE, 'outside_dhaka'),
+    (242, 22, 'Karimganj', FALSE, 'outside_dhaka'),
+    (243, 22, 'Katiadi', FALSE, 'outside_dhaka'),
+    (244, 22, 'Kishoreganj Sadar', FALSE, 'outside_dhaka'),
+    (245, 22, 'Kuliarchar', FALSE, 'outside_dhaka'),
+    (246, 22, 'Mithamain', FALSE, 'outside_dhaka'),
+    (247, 22, 'Nikli', FALSE, 'outside_dhaka'),
+    (248, 22, 'Pakundia', FALSE, 'outside_dhaka'),
+    (249, 22, 'Tarail', FALSE, 'outside_dhaka'),
+    (250, 23, 'Dasar', FALSE, 'outside_dhaka'),
+    (251, 23, 'Kalkini', FALSE, 'outside_dhaka'),
+    (252, 23, 'Madaripur Sadar', FALSE, 'outside_dhaka'),
+    (253, 23, 'Rajoir', FALSE, 'outside_dhaka'),
+    (254, 23, 'Shibchar', FALSE, 'outside_dhaka'),
+    (255, 24, 'Daulatpur', FALSE, 'outside_dhaka'),
+    (256, 24, 'Ghior', FALSE, 'outside_dhaka'),
+    (257, 24, 'Harirampur', FALSE, 'outside_dhaka'),
+    (258, 24, 'Manikganj Sadar', FALSE, 'outside_dhaka'),
+    (259, 24, 'Saturia', FALSE, 'outside_dhaka'),
+    (260, 24, 'Shivalaya', FALSE, 'outside_dhaka'),
+    (261, 24, 'Singair', FALSE, 'outside_dhaka'),
+    (262, 25, 'Gazaria', FALSE, 'outside_dhaka'),
+    (263, 25, 'Lohajang', FALSE, 'outside_dhaka'),
+    (264, 25, 'Munshiganj Sadar', FALSE, 'outside_dhaka'),
+    (265, 25, 'Sirajdikhan', FALSE, 'outside_dhaka'),
+    (266, 25, 'Sreenagar', FALSE, 'outside_dhaka'),
+    (267, 25, 'Tongibari', FALSE, 'outside_dhaka'),
+    (268, 26, 'Araihazar', FALSE, 'dhaka_suburbs'),
+    (269, 26, 'Bandar', FALSE, 'dhaka_suburbs'),
+    (270, 26, 'Narayanganj Sadar', FALSE, 'dhaka_suburbs'),
+    (271, 26, 'Rupganj', FALSE, 'dhaka_suburbs'),
+    (272, 26, 'Sonargaon', FALSE, 'dhaka_suburbs'),
+    (273, 27, 'Belabo', FALSE, 'outside_dhaka'),
+    (274, 27, 'Monohardi', FALSE, 'outside_dhaka'),
+    (275, 27, 'Narsingdi Sadar', FALSE, 'outside_dhaka'),
+    (276, 27, 'Palash', FALSE, 'outside_dhaka'),
+    (277, 27, 'Raipura', FALSE, 'outside_dhaka'),
+    (278, 27, 'Shibpur', FALSE, 'outside_dhaka'),
+    (279, 28, 'Baliakandi', FALSE, 'outside_dhaka'),
+    (280, 28, 'Goalanda', FALSE, 'outside_dhaka'),
+    (281, 28, 'Kalukhali', FALSE, 'outside_dhaka'),
+    (282, 28, 'Pangsha', FALSE, 'outside_dhaka'),
+    (283, 28, 'Rajbari Sadar', FALSE, 'outside_dhaka'),
+    (284, 29, 'Bhedarganj', FALSE, 'outside_dhaka'),
+    (285, 29, 'Damudya', FALSE, 'outside_dhaka'),
+    (286, 29, 'Gosairhat', FALSE, 'outside_dhaka'),
+    (287, 29, 'Naria', FALSE, 'outside_dhaka'),
+    (288, 29, 'Shariatpur Sadar', FALSE, 'outside_dhaka'),
+    (289, 29, 'Zajira', FALSE, 'outside_dhaka'),
+    (290, 30, 'Basail', FALSE, 'outside_dhaka'),
+    (291, 30, 'Bhuapur', FALSE, 'outside_dhaka'),
+    (292, 30, 'Delduar', FALSE, 'outside_dhaka'),
+    (293, 30, 'Dhanbari', FALSE, 'outside_dhaka'),
+    (294, 30, 'Ghatail', FALSE, 'outside_dhaka'),
+    (295, 30, 'Gopalpur', FALSE, 'outside_dhaka'),
+    (296, 30, 'Kalihati', FALSE, 'outside_dhaka'),
+    (297, 30, 'Madhupur', FALSE, 'outside_dhaka'),
+    (298, 30, 'Mirzapur', FALSE, 'outside_dhaka'),
+    (299, 30, 'Nagarpur', FALSE, 'outside_dhaka'),
+    (300, 30, 'Sakhipur', FALSE, 'outside_dhaka'),
+    (301, 30, 'Tangail Sadar', FALSE, 'outside_dhaka'),
+    (302, 31, 'Bagerhat Sadar', FALSE, 'outside_dhaka'),
+    (303, 31, 'Chitalmari', FALSE, 'outside_dhaka'),
+    (304, 31, 'Fakirhat', FALSE, 'outside_dhaka'),
+    (305, 31, 'Kachua', FALSE, 'outside_dhaka'),
+    (306, 31, 'Mollahat', FALSE, 'outside_dhaka'),
+    (307, 31, 'Mongla', FALSE, 'outside_dhaka'),
+    (308, 31, 'Morrelganj', FALSE, 'outside_dhaka'),
+    (309, 31, 'Rampal', FALSE, 'outside_dhaka'),
+    (310, 31, 'Sarankhola', FALSE, 'outside_dhaka'),
+    (311, 32, 'Alamdanga', FALSE, 'outside_dhaka'),
+    (312, 32, 'Chuadanga Sadar', FALSE, 'outside_dhaka'),
+    (313, 32, 'Damurhuda', FALSE, 'outside_dhaka'),
+    (314, 32, 'Jibannagar', FALSE, 'outside_dhaka'),
+    (315, 33, 'Abhaynagar', FALSE, 'outside_dhaka'),
+    (316, 33, 'Bagherpara', FALSE, 'outside_dhaka'),
+    (317, 33, 'Chaugachha', FALSE, 'outside_dhaka'),
+    (318, 33, 'Jashore Sadar', FALSE, 'outside_dhaka'),
+    (319, 33, 'Jhikargachha', FALSE, 'outside_dhaka'),
+    (320, 33, 'Keshabpur', FALSE, 'outside_dhaka'),
+    (321, 33, 'Manirampur', FALSE, 'outside_dhaka'),
+    (322, 33, 'Sharsha', FALSE, 'outside_dhaka'),
+    (323, 34, 'Harinakunda', FALSE, 'outside_dhaka'),
+    (324, 34, 'Jhenaidah Sadar', FALSE, 'outside_dhaka'),
+    (325, 34, 'Kaliganj', FALSE, 'outside_dhaka'),
+    (326, 34, 'Kotchandpur', FALSE, 'outside_dhaka'),
+    (327, 34, 'Maheshpur', FALSE, 'outside_dhaka'),
+    (328, 34, 'Shailkupa', FALSE, 'outside_dhaka'),
+    (329, 35, 'Batiaghata', FALSE, 'outside_dhaka'),
+    (330, 35, 'Dacope', FALSE, 'outside_dhaka'),
+    (331, 35, 'Dighalia', FALSE, 'outside_dhaka'),
+    (332, 35, 'Dumuria', FALSE, 'outside_dhaka'),
+    (333, 35, 'Koyra', FALSE, 'outside_dhaka'),
+    (334, 35, 'Paikgachha', FALSE, 'outside_dhaka'),
+    (335, 35, 'Phultala', FALSE, 'outside_dhaka'),
+    (336, 35, 'Rupsa', FALSE, 'outside_dhaka'),
+    (337, 35, 'Terokhada', FALSE, 'outside_dhaka'),
+    (338, 35, 'Daulatpur', TRUE, 'outside_dhaka'),
+    (339, 35, 'Khalishpur', TRUE, 'outside_dhaka'),
+    (340, 35, 'Khan Jahan Ali', TRUE, 'outside_dhaka'),
+    (341, 35, 'Khulna Sadar', TRUE, 'outside_dhaka'),
+    (342, 35, 'Sonadanga', TRUE, 'outside_dhaka'),
+    (343, 36, 'Bheramara', FALSE, 'outside_dhaka'),
+    (344, 36, 'Daulatpur', FALSE, 'outside_dhaka'),
+    (345, 36, 'Khoksa', FALSE, 'outside_dhaka'),
+    (346, 36, 'Kumarkhali', FALSE, 'outside_dhaka'),
+    (347, 36, 'Kushtia Sadar', FALSE, 'outside_dhaka'),
+    (348, 36, 'Mirpur', FALSE, 'outside_dhaka'),
+    (349, 37, 'Magura Sadar', FALSE, 'outside_dhaka'),
+    (350, 37, 'Mohammadpur', FALSE, 'outside_dhaka'),
+    (351, 37, 'Shalikha', FALSE, 'outside_dhaka'),
+    (352, 37, 'Sreepur', FALSE, 'outside_dhaka'),
+    (353, 38, 'Gangni', FALSE, 'outside_dhaka'),
+    (354, 38, 'Meherpur Sadar', FALSE, 'outside_dhaka'),
+    (355, 38, 'Mujibnagar', FALSE, 'outside_dhaka'),
+    (356, 39, 'Kalia', FALSE, 'outside_dhaka'),
+    (357, 39, 'Lohagara', FALSE, 'outside_dhaka'),
+    (358, 39, 'Narail Sadar', FALSE, 'outside_dhaka'),
+    (359, 40, 'Assasuni', FALSE, 'outside_dhaka'),
+    (360, 40, 'Debhata', FALSE, 'outside_dhaka'),
+    (361, 40, 'Kalaroa', FALSE, 'outside_dhaka'),
+    (362, 40, 'Kaliganj', FALSE, 'outside_dhaka'),
+    (363, 40, 'Satkhira Sadar', FALSE, 'outside_dhaka'),
+    (364, 40, 'Shyamnagar', FALSE, 'outside_dhaka'),
+    (365, 40, 'Tala', FALSE, 'outside_dhaka'),
+    (366, 41, 'Bakshiganj', FALSE, 'outside_dhaka'),
+    (367, 41, 'Dewanganj', FALSE, 'outside_dhaka'),
+    (368, 41, 'Islampur', FALSE, 'outside_dhaka'),
+    (369, 41, 'Jamalpur Sadar', FALSE, 'outside_dhaka'),
+    (370, 41, 'Madarganj', FALSE, 'outside_dhaka'),
+    (371, 41, 'Melandaha', FALSE, 'outside_dhaka'),
+    (372, 41, 'Sarishabari', FALSE, 'outside_dhaka'),
+    (373, 42, 'Bhaluka', FALSE, 'outside_dhaka'),
+    (374, 42, 'Dhobaura', FALSE, 'outside_dhaka'),
+    (375, 42, 'Fulbaria', FALSE, 'outside_dhaka'),
+    (376, 42, 'Gafargaon', FALSE, 'outside_dhaka'),
+    (377, 42, 'Gauripur', FALSE, 'outside_dhaka'),
+    (378, 42, 'Haluaghat', FALSE, 'outside_dhaka'),
+    (379, 42, 'Ishwarganj', FALSE, 'outside_dhaka'),
+    (380, 42, 'Muktagachha', FALSE, 'outside_dhaka'),
+    (381, 42, 'Mymensingh Sadar', FALSE, 'outside_dhaka'),
+    (382, 42, 'Nandail', FALSE, 'outside_dhaka'),
+    (383, 42, 'Phulpur', FALSE, 'outside_dhaka'),
+    (384, 42, 'Tarakanda', FALSE, 'outside_dhaka'),
+    (385, 42, 'Trishal', FALSE, 'outside_dhaka'),
+    (386, 43, 'Atpara', FALSE, 'outside_dhaka'),
+    (387, 43, 'Barhatta', FALSE, 'outside_dhaka'),
+    (388, 43, 'Durgapur', FALSE, 'outside_dhaka'),
+    (389, 43, 'Kalmakanda', FALSE, 'outside_dhaka'),
+    (390, 43, 'Kendua', FALSE, 'outside_dhaka'),
+    (391, 43, 'Khaliajuri', FALSE, 'outside_dhaka'),
+    (392, 43, 'Madan', FALSE, 'outside_dhaka'),
+    (393, 43, 'Mohanganj', FALSE, 'outside_dhaka'),
+    (394, 43, 'Netrokona Sadar', FALSE, 'outside_dhaka'),
+    (395, 43, 'Purbadhala', FALSE, 'outside_dhaka'),
+    (396, 44, 'Jhenaigati', FALSE, 'outside_dhaka'),
+    (397, 44, 'Nakla', FALSE, 'outside_dhaka'),
+    (398, 44, 'Nalitabari', FALSE, 'outside_dhaka'),
+    (399, 44, 'Sherpur Sadar', FALSE, 'outside_dhaka'),
+    (400, 44, 'Sreebardi', FALSE, 'outside_dhaka'),
+    (401, 45, 'Adamdighi', FALSE, 'outside_dhaka'),
+    (402, 45, 'Bogura Sadar', FALSE, 'outside_dhaka'),
+    (403, 45, 'Dhunat', FALSE, 'outside_dhaka'),
+    (404, 45, 'Dhupchanchia', FALSE, 'outside_dhaka'),
+    (405, 45, 'Gabtali', FALSE, 'outside_dhaka'),
+    (406, 45, 'Kahaloo', FALSE, 'outside_dhaka'),
+    (407, 45, 'Nandigram', FALSE, 'outside_dhaka'),
+    (408, 45, 'Sariakandi', FALSE, 'outside_dhaka'),
+    (409, 45, 'Shajahanpur', FALSE, 'outside_dhaka'),
+    (410, 45, 'Sherpur', FALSE, 'outside_dhaka'),
+    (411, 45, 'Shibganj', FALSE, 'outside_dhaka'),
+    (412, 45, 'Sonatala', FALSE, 'outside_dhaka'),
+    (413, 46, 'Bholahat', FALSE, 'outside_dhaka'),
+    (414, 46, 'Chapainawabganj Sadar', FALSE, 'outside_dhaka'),
+    (415, 46, 'Gomastapur', FALSE, 'outside_dhaka'),
+    (416, 46, 'Nachole', FALSE, 'outside_dhaka'),
+    (417, 46, 'Shibganj', FALSE, 'outside_dhaka'),
+    (418, 47, 'Akkelpur', FALSE, 'outside_dhaka'),
+    (419, 47, 'Joypurhat Sadar', FALSE, 'outside_dhaka'),
+    (420, 47, 'Kalai', FALSE, 'outside_dhaka'),
+    (421, 47, 'Khetlal', FALSE, 'outside_dhaka'),
+    (422, 47, 'Panchbibi', FALSE, 'outside_dhaka'),
+    (423, 48, 'Atrai', FALSE, 'outside_dhaka'),
+    (424, 48, 'Badalgachhi', FALSE, 'outside_dhaka'),
+    (425, 48, 'Dhamoirhat', FALSE, 'outside_dhaka'),
+    (426, 48, 'Manda', FALSE, 'outside_dhaka'),
+    (427, 48, 'Mohadevpur', FALSE, 'outside_dhaka'),
+    (428, 48, 'Naogaon Sadar', FALSE, 'outside_dhaka'),
+    (429, 48, 'Niamatpur', FALSE, 'outside_dhaka'),
+    (430, 48, 'Patnitala', FALSE, 'outside_dhaka'),
+    (431, 48, 'Porsha', FALSE, 'outside_dhaka'),
+    (432, 48, 'Raninagar', FALSE, 'outside_dhaka'),
+    (433, 48, 'Sapahar', FALSE, 'outside_dhaka'),
+    (434, 49, 'Bagatipara', FALSE, 'outside_dhaka'),
+    (435, 49, 'Baraigram', FALSE, 'outside_dhaka'),
+    (436, 49, 'Gurudaspur', FALSE, 'outside_dhaka'),
+    (437, 49, 'Lalpur', FALSE, 'outside_dhaka'),
+    (438, 49, 'Naldanga', FALSE, 'outside_dhaka'),
+    (439, 49, 'Natore Sadar', FALSE, 'outside_dhaka'),
+    (440, 49, 'Singra', FALSE, 'outside_dhaka'),
+    (441, 50, 'Atgharia', FALSE, 'outside_dhaka'),
+    (442, 50, 'Bera', FALSE, 'outside_dhaka'),
+    (443, 50, 'Bhangura', FALSE, 'outside_dhaka'),
+    (444, 50, 'Chatmohar', FALSE, 'outside_dhaka'),
+    (445, 50, 'Faridpur', FALSE, 'outside_dhaka'),
+    (446, 50, 'Ishwardi', FALSE, 'outside_dhaka'),
+    (447, 50, 'Pabna Sadar', FALSE, 'outside_dhaka'),
+    (448, 50, 'Santhia', FALSE, 'outside_dhaka'),
+    (449, 50, 'Sujanagar', FALSE, 'outside_dhaka'),
+    (450, 51, 'Bagha', FALSE, 'outside_dhaka'),
+    (451, 51, 'Bagmara', FALSE, 'outside_dhaka'),
+    (452, 51, 'Charghat', FALSE, 'outside_dhaka'),
+    (453, 51, 'Durgapur', FALSE, 'outside_dhaka'),
+    (454, 51, 'Godagari', FALSE, 'outside_dhaka'),
+    (455, 51, 'Mohanpur', FALSE, 'outside_dhaka'),
+    (456, 51, 'Paba', FALSE, 'outside_dhaka'),
+    (457, 51, 'Puthia', FALSE, 'outside_dhaka'),
+    (458, 51, 'Tanore', FALSE, 'outside_dhaka'),
+    (459, 51, 'Boalia', TRUE, 'outside_dhaka'),
+    (460, 51, 'Motihar', TRUE, 'outside_dhaka'),
+    (461, 51, 'Rajpara', TRUE, 'outside_dhaka'),
+    (462, 51, 'Shah Makhdum', TRUE, 'outside_dhaka'),
+    (463, 52, 'Belkuchi', FALSE, 'outside_dhaka'),
+    (464, 52, 'Chauhali', FALSE, 'outside_dhaka'),
+    (465, 52, 'Kamarkhanda', FALSE, 'outside_dhaka'),
+    (466, 52, 'Kazipur', FALSE, 'outside_dhaka'),
+    (467, 52, 'Raiganj', FALSE, 'outside_dhaka'),
+    (468, 52, 'Shahjadpur', FALSE, 'outside_dhaka'),
+    (469, 52, 'Sirajganj Sadar', FALSE, 'outside_dhaka'),
+    (470, 52, 'Tarash', FALSE, 'outside_dhaka'),
+    (471, 52, 'Ullahpara', FALSE, 'outside_dhaka'),
+    (472, 53, 'Birampur', FALSE, 'outside_dhaka'),
+    (473, 53, 'Birganj', FALSE, 'outside_dhaka'),
+    (474, 53, 'Biral', FALSE, 'outside_dhaka'),
+    (475, 53, 'Bochaganj', FALSE, 'outside_dhaka'),
+    (476, 53, 'Chirirbandar', FALSE, 'outside_dhaka'),
+    (477, 53, 'Dinajpur Sadar', FALSE, 'outside_dhaka'),
+    (478, 53, 'Ghoraghat', FALSE, 'outside_dhaka'),
+    (479, 53, 'Hakimpur', FALSE, 'outside_dhaka'),
+    (480, 53, 'Kaharole', FALSE, 'outside_dhaka'),
+    (481, 53, 'Khansama', FALSE, 'outside_dhaka'),
+    (482, 53, 'Nawabganj', FALSE, 'outside_dhaka'),
+    (483, 53, 'Parbatipur', FALSE, 'outside_dhaka'),
+    (484, 53, 'Phulbari', FALSE, 'outside_dhaka'),
+    (485, 54, 'Fulchhari', FALSE, 'outside_dhaka'),
+    (486, 54, 'Gaibandha Sadar', FALSE, 'outside_dhaka'),
+    (487, 54, 'Gobindaganj', FALSE, 'outside_dhaka'),
+    (488, 54, 'Palashbari', FALSE, 'outside_dhaka'),
+    (489, 54, 'Sadullapur', FALSE, 'outside_dhaka'),
+    (490, 54, 'Saghata', FALSE, 'outside_dhaka'),
+    (491, 54, 'Sundarganj', FALSE, 'outside_dhaka'),
+    (492, 55, 'Bhurungamari', FALSE, 'outside_dhaka'),
+    (493, 55, 'Char Rajibpur', FALSE, 'outside_dhaka'),
+    (494, 55, 'Chilmari', FALSE, 'outside_dhaka'),
+    (495, 55, 'Kurigram Sadar', FALSE, 'outside_dhaka'),
+    (496, 55, 'Nageshwari', FALSE, 'outside_dhaka'),
+    (497, 55, 'Phulbari', FALSE, 'outside_dhaka'),
+    (498, 55, 'Rajarhat', FALSE, 'outside_dhaka'),
+    (499, 55, 'Raomari', FALSE, 'outside_dhaka'),
+    (500, 55, 'Ulipur', FALSE, 'outside_dhaka'),
+    (501, 56, 'Aditmari', FALSE, 'outside_dhaka'),
+    (502, 56, 'Hatibandha', FALSE, 'outside_dhaka'),
+    (503, 56, 'Kaliganj', FALSE, 'outside_dhaka'),
+    (504, 56, 'Lalmonirhat Sadar', FALSE, 'outside_dhaka'),
+    (505, 56, 'Patgram', FALSE, 'outside_dhaka'),
+    (506, 57, 'Dimla', FALSE, 'outside_dhaka'),
+    (507, 57, 'Domar', FALSE, 'outside_dhaka'),
+    (508, 57, 'Jaldhaka', FALSE, 'outside_dhaka'),
+    (509, 57, 'Kishoreganj', FALSE, 'outside_dhaka'),
+    (510, 57, 'Nilphamari Sadar', FALSE, 'outside_dhaka'),
+    (511, 57, 'Saidpur', FALSE, 'outside_dhaka'),
+    (512, 58, 'Atwari', FALSE, 'outside_dhaka'),
+    (513, 58, 'Boda', FALSE, 'outside_dhaka'),
+    (514, 58, 'Debiganj', FALSE, 'outside_dhaka'),
+    (515, 58, 'Panchagarh Sadar', FALSE, 'outside_dhaka'),
+    (516, 58, 'Tetulia', FALSE, 'outside_dhaka'),
+    (517, 59, 'Badarganj', FALSE, 'outside_dhaka'),
+    (518, 59, 'Gangachara', FALSE, 'outside_dhaka'),
+    (519, 59, 'Kaunia', FALSE, 'outside_dhaka'),
+    (520, 59, 'Mithapukur', FALSE, 'outside_dhaka'),
+    (521, 59, 'Pirgachha', FALSE, 'outside_dhaka'),
+    (522, 59, 'Pirganj', FALSE, 'outside_dhaka'),
+    (523, 59, 'Rangpur Sadar', FALSE, 'outside_dhaka'),
+    (524, 59, 'Taraganj', FALSE, 'outside_dhaka'),
+    (525, 60, 'Baliadangi', FALSE, 'outside_dhaka'),
+    (526, 60, 'Haripur', FALSE, 'outside_dhaka'),
+    (527, 60, 'Pirganj', FALSE, 'outside_dhaka'),
+    (528, 60, 'Ranisankail', FALSE, 'outside_dhaka'),
+    (529, 60, 'Thakurgaon Sadar', FALSE, 'outside_dhaka'),
+    (530, 61, 'Ajmiriganj', FALSE, 'outside_dhaka'),
+    (531, 61, 'Bahubal', FALSE, 'outside_dhaka'),
+    (532, 61, 'Baniachong', FALSE, 'outside_dhaka'),
+    (533, 61, 'Chunarughat', FALSE, 'outside_dhaka'),
+    (534, 61, 'Habiganj Sadar', FALSE, 'outside_dhaka'),
+    (535, 61, 'Lakhai', FALSE, 'outside_dhaka'),
+    (536, 61, 'Madhabpur', FALSE, 'outside_dhaka'),
+    (537, 61, 'Nabiganj', FALSE, 'outside_dhaka'),
+    (538, 61, 'Shayestaganj', FALSE, 'outside_dhaka'),
+    (539, 62, 'Barlekha', FALSE, 'outside_dhaka'),
+    (540, 62, 'Juri', FALSE, 'outside_dhaka'),
+    (541, 62, 'Kamalganj', FALSE, 'outside_dhaka'),
+    (542, 62, 'Kulaura', FALSE, 'outside_dhaka'),
+    (543, 62, 'Moulvibazar Sadar', FALSE, 'outside_dhaka'),
+    (544, 62, 'Rajnagar', FALSE, 'outside_dhaka'),
+    (545, 62, 'Sreemangal', FALSE, 'outside_dhaka'),
+    (546, 63, 'Bishwamvarpur', FALSE, 'outside_dhaka'),
+    (547, 63, 'Chhatak', FALSE, 'outside_dhaka'),
+    (548, 63, 'Derai', FALSE, 'outside_dhaka'),
+    (549, 63, 'Dharamapasha', FALSE, 'outside_dhaka'),
+    (550, 63, 'Dowarabazar', FALSE, 'outside_dhaka'),
+    (551, 63, 'Jagannathpur', FALSE, 'outside_dhaka'),
+    (552, 63, 'Jamalganj', FALSE, 'outside_dhaka'),
+    (553, 63, 'Madhyanagar', FALSE, 'outside_dhaka'),
+    (554, 63, 'Shantiganj', FALSE, 'outside_dhaka'),
+    (555, 63, 'Sullah', FALSE, 'outside_dhaka'),
+    (556, 63, 'Sunamganj Sadar', FALSE, 'outside_dhaka'),
+    (557, 63, 'Tahirpur', FALSE, 'outside_dhaka'),
+    (558, 64, 'Balaganj', FALSE, 'outside_dhaka'),
+    (559, 64, 'Beanibazar', FALSE, 'outside_dhaka'),
+    (560, 64, 'Bishwanath', FALSE, 'outside_dhaka'),
+    (561, 64, 'Companiganj', FALSE, 'outside_dhaka'),
+    (562, 64, 'Dakshin Surma', FALSE, 'outside_dhaka'),
+    (563, 64, 'Fenchuganj', FALSE, 'outside_dhaka'),
+    (564, 64, 'Golapganj', FALSE, 'outside_dhaka'),
+    (565, 64, 'Gowainghat', FALSE, 'outside_dhaka'),
+    (566, 64, 'Jaintiapur', FALSE, 'outside_dhaka'),
+    (567, 64, 'Kanaighat', FALSE, 'outside_dhaka'),
+    (568, 64, 'Osmani Nagar', FALSE, 'outside_dhaka'),
+    (569, 64, 'Sylhet Sadar', FALSE, 'outside_dhaka'),
+    (570, 64, 'Zakiganj', FALSE, 'outside_dhaka');
+
+INSERT INTO areas (id, upazila_id, name, postcode) VALUES
+    (1, 181, 'Gulshan 1', '1212'),
+    (2, 181, 'Gulshan 2', '1212'),
+    (3, 181, 'Niketan', '1212'),
+    (4, 169, 'Banani', '1213'),
+    (5, 169, 'Banani DOHS', '1206'),
+    (6, 179, 'Dhanmondi', '1209'),
+    (7, 179, 'Jigatola', '1209'),
+    (8, 194, 'Mohammadpur', '1207'),
+    (9, 194, 'Shyamoli', '1207'),
+    (10, 210, 'Tejgaon', '1215'),
+    (11, 210, 'Farmgate', '1215'),
+    (12, 193, 'Mirpur 1', '1216'),
+    (13, 193, 'Mirpur 2', '1216'),
+    (14, 198, 'Mirpur 11', '1216'),
+    (15, 198, 'Mirpur 12', '1216'),
+    (16, 214, 'Uttara Sector 1', '1230'),
+    (17, 214, 'Uttara Sector 3', '1230'),
+    (18, 215, 'Uttara Sector 10', '1230'),
+    (19, 215, 'Uttara Sector 13', '1230'),
+    (20, 195, 'Motijheel', '1000'),
+    (21, 195, 'Arambagh', '1000'),
+    (22, 189, 'Khilgaon', '1219'),
+    (23, 189, 'Goran', '1219'),
+    (24, 192, 'Lalbagh', '1211'),
+    (25, 217, 'Wari', '1203'),
+    (26, 197, 'Nilkhet', '1205'),
+    (27, 168, 'Badda', '1212'),
+    (28, 168, 'Merul Badda', '1212'),
+    (29, 201, 'Rampura', '1219'),
+    (30, 174, 'Dhaka Cantonment', '1206');
+
+SELECT setval('divisions_id_seq', (SELECT MAX(id) FROM divisions));
+SELECT setval('districts_id_seq', (SELECT MAX(id) FROM districts));
+SELECT setval('upazilas_id_seq', (SELECT MAX(id) FROM upazilas));
+SELECT setval('areas_id_seq', (SELECT MAX(id) FROM areas));
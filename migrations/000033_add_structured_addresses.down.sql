@@ -0,0 +1,19 @@
+DROP INDEX IF EXISTS idx_orders_delivery_zone;
+ALTER TABLE orders DROP COLUMN IF EXISTS delivery_zone;
+ALTER TABLE orders DROP COLUMN IF EXISTS shipping_postcode;
+ALTER TABLE orders DROP COLUMN IF EXISTS shipping_area;
+ALTER TABLE orders DROP COLUMN IF EXISTS shipping_upazila;
+ALTER TABLE orders DROP COLUMN IF EXISTS shipping_district;
+ALTER TABLE orders DROP COLUMN IF EXISTS shipping_division;
+ALTER TABLE orders DROP COLUMN IF EXISTS shipping_address_id;
+
+DROP INDEX IF EXISTS idx_addresses_user_default;
+ALTER TABLE addresses DROP COLUMN IF EXISTS is_default;
+ALTER TABLE addresses DROP COLUMN IF EXISTS delivery_zone;
+ALTER TABLE addresses DROP COLUMN IF EXISTS postcode;
+ALTER TABLE addresses DROP COLUMN IF EXISTS area;
+ALTER TABLE addresses DROP COLUMN IF EXISTS division;
+ALTER TABLE addresses DROP COLUMN IF EXISTS area_id;
+ALTER TABLE addresses DROP COLUMN IF EXISTS upazila_id;
+ALTER TABLE addresses DROP COLUMN IF EXISTS district_id;
+ALTER TABLE addresses DROP COLUMN IF EXISTS division_id;
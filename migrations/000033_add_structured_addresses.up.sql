@@ -0,0 +1,27 @@
+ALTER TABLE addresses ADD COLUMN division_id INTEGER NULL REFERENCES divisions(id);
+ALTER TABLE addresses ADD COLUMN district_id INTEGER NULL REFERENCES districts(id);
+ALTER TABLE addresses ADD COLUMN upazila_id INTEGER NULL REFERENCES upazilas(id);
+ALTER TABLE addresses ADD COLUMN area_id INTEGER NULL REFERENCES areas(id);
+ALTER TABLE addresses ADD COLUMN division VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE addresses ADD COLUMN area VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE addresses ADD COLUMN postcode VARCHAR(4) NOT NULL DEFAULT '';
+ALTER TABLE addresses ADD COLUMN delivery_zone VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE addresses ADD COLUMN is_default BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Each user's oldest address becomes their default
+UPDATE addresses SET is_default = TRUE
+WHERE id IN (SELECT DISTINCT ON (user_id) id FROM addresses ORDER BY user_id, created_at, id);
+
+CREATE UNIQUE INDEX idx_addresses_user_default ON addresses(user_id) WHERE is_default;
+
+-- Shipping address snapshot taken at checkout. No foreign key, since the
+-- saved address may be edited or deleted later.
+ALTER TABLE orders ADD COLUMN shipping_address_id INTEGER NULL;
+ALTER TABLE orders ADD COLUMN shipping_division VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN shipping_district VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN shipping_upazila VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN shipping_area VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN shipping_postcode VARCHAR(4) NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN delivery_zone VARCHAR(20) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_orders_delivery_zone ON orders(delivery_zone);
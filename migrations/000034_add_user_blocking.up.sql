@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN blocked_at TIMESTAMPTZ NULL;
+ALTER TABLE users ADD COLUMN blocked_reason VARCHAR(500) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_users_blocked_at ON users(blocked_at) WHERE blocked_at IS NOT NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_visitor_logs_created_at;
+
+ALTER TABLE visitor_logs DROP COLUMN IF EXISTS path;
+ALTER TABLE visitor_logs DROP COLUMN IF EXISTS country_code;
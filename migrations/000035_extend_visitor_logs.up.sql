@@ -0,0 +1,4 @@
+ALTER TABLE visitor_logs ADD COLUMN country_code VARCHAR(2) NOT NULL DEFAULT '';
+ALTER TABLE visitor_logs ADD COLUMN path VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_visitor_logs_created_at ON visitor_logs(created_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS visitor_rollup_days;
+DROP TABLE IF EXISTS visitor_daily_stats;
@@ -0,0 +1,19 @@
+-- Daily rollup of visitor_logs for the analytics reports. dimension is one of
+-- total, country, region, city, path or hour; value is its value that day
+-- ('' for total, "00"-"23" for hour).
+CREATE TABLE visitor_daily_stats (
+    day DATE NOT NULL,
+    dimension VARCHAR(16) NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    visits BIGINT NOT NULL,
+    unique_visitors BIGINT NOT NULL,
+    PRIMARY KEY (day, dimension, value)
+);
+
+CREATE INDEX idx_visitor_daily_stats_dimension_day ON visitor_daily_stats(dimension, day);
+
+-- Days already rolled up; reports read every other day from visitor_logs
+CREATE TABLE visitor_rollup_days (
+    day DATE PRIMARY KEY,
+    rolled_up_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_visitor_logs_unanonymized;
+
+ALTER TABLE visitor_logs DROP COLUMN IF EXISTS ip_anonymized;
@@ -0,0 +1,3 @@
+ALTER TABLE visitor_logs ADD COLUMN ip_anonymized BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_visitor_logs_unanonymized ON visitor_logs(created_at) WHERE NOT ip_anonymized;
@@ -0,0 +1,38 @@
+CREATE TABLE option_types (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_option_types_name ON option_types(lower(name));
+
+INSERT INTO option_types (name) VALUES ('Size'), ('Color');
+
+CREATE TABLE product_variants (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    sku VARCHAR(255) NOT NULL,
+    price DECIMAL(10,2),
+    stock INTEGER NOT NULL DEFAULT 0,
+    images TEXT[] NOT NULL DEFAULT '{}',
+    options JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    deleted_at TIMESTAMP NULL
+);
+
+-- A product has one variant per combination of option values
+CREATE UNIQUE INDEX idx_product_variants_options ON product_variants(product_id, options) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX idx_product_variants_sku ON product_variants(sku) WHERE deleted_at IS NULL;
+CREATE INDEX idx_product_variants_deleted_at ON product_variants(deleted_at);
+
+ALTER TABLE cart_items
+    ADD COLUMN variant_id INTEGER REFERENCES product_variants(id),
+    ADD COLUMN options JSONB;
+
+ALTER TABLE order_items
+    ADD COLUMN variant_id INTEGER REFERENCES product_variants(id),
+    ADD COLUMN options JSONB;
+
+CREATE INDEX idx_order_items_variant_id ON order_items(variant_id);
@@ -0,0 +1,25 @@
+CREATE TABLE attribute_definitions (
+    id SERIAL PRIMARY KEY,
+    key VARCHAR(50) NOT NULL,
+    label VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('text', 'number', 'enum', 'boolean')),
+    unit VARCHAR(20) NOT NULL DEFAULT '',
+    options TEXT[] NOT NULL DEFAULT '{}',
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    position INTEGER NOT NULL DEFAULT 0,
+    category_id INTEGER REFERENCES categories(id) ON DELETE CASCADE,
+    sub_category_id INTEGER REFERENCES sub_categories(id) ON DELETE CASCADE,
+    sub_sub_category_id INTEGER REFERENCES sub_sub_categories(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    -- Defined on exactly one level of the category hierarchy
+    CHECK (num_nonnulls(category_id, sub_category_id, sub_sub_category_id) = 1)
+);
+
+CREATE UNIQUE INDEX idx_attribute_definitions_category_key ON attribute_definitions(category_id, key) WHERE category_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_attribute_definitions_sub_category_key ON attribute_definitions(sub_category_id, key) WHERE sub_category_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_attribute_definitions_sub_sub_category_key ON attribute_definitions(sub_sub_category_id, key) WHERE sub_sub_category_id IS NOT NULL;
+
+ALTER TABLE products ADD COLUMN attributes JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_products_attributes ON products USING GIN (attributes jsonb_path_ops);
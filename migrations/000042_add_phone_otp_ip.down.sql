@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_phone_otps_created_at;
+DROP INDEX IF EXISTS idx_phone_otps_ip_created_at;
+ALTER TABLE phone_otps DROP COLUMN IF EXISTS ip;
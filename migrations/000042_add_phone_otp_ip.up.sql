@@ -0,0 +1,4 @@
+ALTER TABLE phone_otps ADD COLUMN ip VARCHAR(45) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_phone_otps_ip_created_at ON phone_otps(ip, created_at);
+CREATE INDEX idx_phone_otps_created_at ON phone_otps(created_at);